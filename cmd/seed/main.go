@@ -39,16 +39,25 @@ func main() {
 	userID := uuid.New()
 	email := "test@example.com"
 
-	// Check if user exists
-	query := `INSERT INTO users (id, email, api_key_hash, role, rate_limit_tier, is_active, created_at, updated_at)
-	          VALUES ($1, $2, $3, 'admin', 'premium', true, NOW(), NOW())
-	          ON CONFLICT (email) DO UPDATE SET api_key_hash = EXCLUDED.api_key_hash`
+	// Create the user, or reuse the existing one
+	query := `INSERT INTO users (id, email, role, rate_limit_tier, is_active, created_at, updated_at)
+	          VALUES ($1, $2, 'admin', 'premium', true, NOW(), NOW())
+	          ON CONFLICT (email) DO UPDATE SET updated_at = NOW()
+	          RETURNING id`
 
-	_, err = db.ExecContext(ctx, query, userID, email, hashedKey)
-	if err != nil {
+	if err := db.GetContext(ctx, &userID, query, userID, email); err != nil {
 		logger.Fatal().Err(err).Msg("failed to seed user")
 	}
 
+	// Attach the well-known test key with every scope
+	keyQuery := `INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, created_at)
+	             VALUES ($1, 'seed', $2, $3, ARRAY['*'], NOW())
+	             ON CONFLICT (key_hash) DO UPDATE SET user_id = EXCLUDED.user_id, revoked_at = NULL, expires_at = NULL`
+
+	if _, err := db.ExecContext(ctx, keyQuery, userID, apiKey, hashedKey); err != nil {
+		logger.Fatal().Err(err).Msg("failed to seed api key")
+	}
+
 	logger.Info().Msgf("Seeded user: %s", email)
 	logger.Info().Msgf("API Key: %s", apiKey)
 }
@@ -6,17 +6,22 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
+	"notification-system/internal/auth"
 	"notification-system/internal/cache"
 	"notification-system/internal/config"
+	"notification-system/internal/health"
 	"notification-system/internal/queue"
-
 	"notification-system/internal/repository"
 	"notification-system/internal/router"
 	"notification-system/internal/scheduler"
 	"notification-system/internal/service"
+	"notification-system/internal/shutdown"
+	"notification-system/internal/storage"
+	"notification-system/internal/tracing"
 	"notification-system/pkg/logger"
 )
 
@@ -34,6 +39,19 @@ func main() {
 
 	log.Info().Msg("starting notification API server")
 
+	// Initialize tracing
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing, "notification-api")
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize tracing")
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Error().Err(err).Msg("failed to flush traces")
+		}
+	}()
+
 	// Connect to database
 	db, err := repository.NewDB(cfg.Database)
 	if err != nil {
@@ -66,30 +84,110 @@ func main() {
 		log.Fatal().Err(err).Msg("failed to declare exchange")
 	}
 
+	// Readiness checks
+	checker := health.NewChecker(cfg.Health.Timeout, cfg.Health.Optional)
+	checker.Add("postgres", db.PingContext)
+	checker.Add("redis", func(ctx context.Context) error { return rdb.Ping(ctx).Err() })
+	checker.Add("rabbitmq", rmq.Check)
+
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
 	messageRepo := repository.NewMessageRepository(db)
 	recipientRepo := repository.NewRecipientRepository(db)
+	attemptRepo := repository.NewDeliveryAttemptRepository(db)
+	usageRepo := repository.NewUsageRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	scheduleRepo := repository.NewScheduleRepository(db)
+	bulkJobRepo := repository.NewBulkJobRepository(db)
+	exportRepo := repository.NewExportRepository(db)
+	analyticsRepo := repository.NewAnalyticsRepository(db)
+
+	// Export file storage
+	exportStore, err := storage.New(cfg.Exports.Storage)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize export storage")
+	}
+
+	// Bearer token verification, when enabled
+	var tokenVerifier *auth.TokenVerifier
+	if cfg.Auth.JWT.Enabled {
+		jwks, err := auth.NewJWKS(context.Background(), cfg.Auth.JWT.JWKSURL, cfg.Auth.JWT.JWKSFile, cfg.Auth.JWT.JWKSRefresh)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to load jwks")
+		}
+		tokenVerifier = auth.NewTokenVerifier(jwks, cfg.Auth.JWT)
+		log.Info().Msg("jwt bearer authentication enabled")
+	}
 
-	// Initialize message service (for scheduler)
-	msgService := service.NewMessageService(db, messageRepo, recipientRepo, publisher)
+	// Initialize services, shared by the API and the background jobs
+	msgService := service.NewMessageService(db, messageRepo, recipientRepo, publisher, cfg.SMS)
+	scheduleService := service.NewScheduleService(db, scheduleRepo, msgService)
+	bulkService := service.NewBulkService(db, bulkJobRepo, msgService)
+	exportService := service.NewExportService(exportRepo, messageRepo, exportStore, cfg.Exports.TTL)
+	analyticsService := service.NewAnalyticsService(db, analyticsRepo)
 
 	// Build router
 	r := router.NewRouter(router.Deps{
-		DB:            db,
-		UserRepo:      userRepo,
-		MessageRepo:   messageRepo,
-		RecipientRepo: recipientRepo,
-		RedisClient:   rdb,
-		RateLimit:     cfg.RateLimit,
-		Publisher:     publisher,
+		DB:               db,
+		UserRepo:         userRepo,
+		APIKeyRepo:       apiKeyRepo,
+		MessageRepo:      messageRepo,
+		RecipientRepo:    recipientRepo,
+		AttemptRepo:      attemptRepo,
+		UsageRepo:        usageRepo,
+		AuditRepo:        auditRepo,
+		MessageService:   msgService,
+		ScheduleService:  scheduleService,
+		BulkService:      bulkService,
+		ExportService:    exportService,
+		AnalyticsService: analyticsService,
+		CredCache:        cache.NewCredentialCache(rdb, cfg.Auth.Cache),
+		TokenVerifier:    tokenVerifier,
+		RedisClient:      rdb,
+		Health:           checker,
+		RateLimit:        cfg.RateLimit,
 	})
 
-	// Start scheduler
+	// Start background jobs
 	schedCtx, schedCancel := context.WithCancel(context.Background())
 	defer schedCancel()
-	sched := scheduler.NewScheduler(messageRepo, msgService, 10*time.Second, 50)
-	go sched.Start(schedCtx)
+	var jobs sync.WaitGroup
+
+	sched := scheduler.NewScheduler(msgService, scheduleService, 10*time.Second, 50)
+	jobs.Add(1)
+	go func() {
+		defer jobs.Done()
+		sched.Start(schedCtx)
+	}()
+
+	bulkProcessor := scheduler.NewBulkProcessor(bulkService, cfg.Bulk.Concurrency, cfg.Bulk.BatchSize, cfg.Bulk.PollInterval)
+	jobs.Add(1)
+	go func() {
+		defer jobs.Done()
+		bulkProcessor.Start(schedCtx)
+	}()
+
+	exportProcessor := scheduler.NewExportProcessor(exportService, cfg.Exports.PollInterval)
+	jobs.Add(1)
+	go func() {
+		defer jobs.Done()
+		exportProcessor.Start(schedCtx)
+	}()
+
+	analyticsRollup := scheduler.NewAnalyticsRollup(analyticsService, cfg.Analytics.RollupInterval, cfg.Analytics.Lag, cfg.Analytics.Overlap)
+	jobs.Add(1)
+	go func() {
+		defer jobs.Done()
+		analyticsRollup.Start(schedCtx)
+	}()
+
+	auditRetention := scheduler.NewAuditRetention(auditRepo, cfg.Audit.Retention, cfg.Audit.PurgeInterval)
+	jobs.Add(1)
+	go func() {
+		defer jobs.Done()
+		auditRetention.Start(schedCtx)
+	}()
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -114,12 +212,21 @@ func main() {
 	sig := <-quit
 
 	log.Info().Str("signal", sig.String()).Msg("shutting down server")
+	checker.Drain()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
+	// Stop accepting requests and let in-flight ones finish publishing, then
+	// let the scheduler finish its current batch. Connections are closed by
+	// the deferred calls only after both have drained.
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal().Err(err).Msg("server forced to shutdown")
+		log.Error().Err(err).Msg("server forced to shutdown")
+	}
+
+	schedCancel()
+	if !shutdown.Wait(ctx, &jobs) {
+		log.Warn().Msg("background jobs did not stop before shutdown timeout")
 	}
 
 	log.Info().Msg("server stopped")
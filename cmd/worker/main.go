@@ -3,15 +3,23 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"notification-system/internal/adapter"
 	"notification-system/internal/config"
+	"notification-system/internal/health"
 	"notification-system/internal/queue"
 	"notification-system/internal/repository"
+	"notification-system/internal/shutdown"
+	"notification-system/internal/tracing"
+	"notification-system/internal/usage"
 	"notification-system/internal/worker"
 	"notification-system/pkg/logger"
 )
@@ -30,6 +38,19 @@ func main() {
 
 	log.Info().Msg("starting notification worker")
 
+	// Initialize tracing
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing, "notification-worker")
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize tracing")
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Error().Err(err).Msg("failed to flush traces")
+		}
+	}()
+
 	// Connect to database
 	db, err := repository.NewDB(cfg.Database)
 	if err != nil {
@@ -59,6 +80,8 @@ func main() {
 	// Initialize repositories
 	recipientRepo := repository.NewRecipientRepository(db)
 	messageRepo := repository.NewMessageRepository(db)
+	usageRepo := repository.NewUsageRepository(db)
+	attemptRepo := repository.NewDeliveryAttemptRepository(db)
 
 	// Load platform credentials
 	twilioCfg, sendgridCfg, _, _ := config.LoadPlatformCredentials()
@@ -88,9 +111,14 @@ func main() {
 	adapters["telegram"] = adapter.NewMockAdapter("telegram")
 	log.Info().Msg("using Mock adapters for whatsapp and telegram")
 
+	for platform, sender := range adapters {
+		adapters[platform] = adapter.WithTracing(sender)
+	}
+
 	// Create consumer and worker
-	consumer := queue.NewConsumer(rmq)
-	w := worker.NewWorker(consumer, recipientRepo, messageRepo, adapters)
+	consumer := queue.NewConsumer(rmq, cfg.Worker.DrainTimeout)
+	prices := usage.NewPriceTable(cfg.Usage)
+	w := worker.NewWorker(consumer, recipientRepo, messageRepo, usageRepo, attemptRepo, prices, adapters)
 
 	// Context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
@@ -100,34 +128,90 @@ func main() {
 	queues := []struct {
 		name       string
 		routingKey string
+		platform   string
 	}{
-		{name: "notification.sms", routingKey: queue.RoutingKeySMS},
-		{name: "notification.email", routingKey: queue.RoutingKeyEmail},
-		{name: "notification.whatsapp", routingKey: queue.RoutingKeyWhatsApp},
-		{name: "notification.telegram", routingKey: queue.RoutingKeyTelegram},
+		{name: "notification.sms", routingKey: queue.RoutingKeySMS, platform: "sms"},
+		{name: "notification.email", routingKey: queue.RoutingKeyEmail, platform: "email"},
+		{name: "notification.whatsapp", routingKey: queue.RoutingKeyWhatsApp, platform: "whatsapp"},
+		{name: "notification.telegram", routingKey: queue.RoutingKeyTelegram, platform: "telegram"},
 	}
 
 	// Start workers for each queue
 	var wg sync.WaitGroup
 	for _, q := range queues {
+		opts := queue.ConsumeOptions{Concurrency: cfg.Worker.QueueConcurrency(q.platform)}
+		if cfg.Worker.OrderByRecipient {
+			opts.OrderingKey = worker.RecipientKey
+		}
+		if opts.Concurrency > cfg.RabbitMQ.PrefetchCount {
+			log.Warn().
+				Str("queue", q.name).
+				Int("concurrency", opts.Concurrency).
+				Int("prefetch_count", cfg.RabbitMQ.PrefetchCount).
+				Msg("concurrency exceeds prefetch count; extra handlers will stay idle")
+		}
+
 		wg.Add(1)
-		go func(queueName, routingKey string) {
+		go func(queueName, routingKey string, opts queue.ConsumeOptions) {
 			defer wg.Done()
-			if err := w.Start(ctx, queueName, routingKey); err != nil {
+			if err := w.Start(ctx, queueName, routingKey, opts); err != nil {
 				log.Error().Err(err).Str("queue", queueName).Msg("worker stopped with error")
 			}
-		}(q.name, q.routingKey)
+		}(q.name, q.routingKey, opts)
 	}
 
 	log.Info().Int("queues", len(queues)).Msg("all workers started")
 
+	// Readiness checks
+	checker := health.NewChecker(cfg.Health.Timeout, cfg.Health.Optional)
+	checker.Add("postgres", db.PingContext)
+	checker.Add("rabbitmq", rmq.Check)
+
+	// Metrics and probe listener
+	var metricsSrv *http.Server
+	if cfg.Worker.MetricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		mux.Handle("/livez", health.LiveHandler())
+		mux.Handle("/readyz", checker.ReadyHandler())
+		metricsSrv = &http.Server{
+			Addr:              cfg.Worker.MetricsAddr,
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		go func() {
+			log.Info().Str("addr", metricsSrv.Addr).Msg("metrics server listening")
+			if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Error().Err(err).Msg("metrics server error")
+			}
+		}()
+	}
+
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	sig := <-quit
 
 	log.Info().Str("signal", sig.String()).Msg("shutting down worker")
+	checker.Drain()
+
+	// Cancel the subscriptions and wait for in-flight deliveries to be acked.
+	// Consumers abort handlers at the drain timeout; the extra grace covers
+	// handlers that are slow to return after that. The channel and
+	// connection are closed by the deferred calls only after this.
 	cancel()
-	wg.Wait()
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), cfg.Worker.DrainTimeout+5*time.Second)
+	defer drainCancel()
+	if !shutdown.Wait(drainCtx, &wg) {
+		log.Warn().Msg("consumers did not stop before drain timeout, unacked deliveries will be redelivered")
+	}
+
+	if metricsSrv != nil {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		if err := metricsSrv.Shutdown(shutdownCtx); err != nil {
+			log.Error().Err(err).Msg("failed to stop metrics server")
+		}
+	}
 	log.Info().Msg("worker stopped")
 }
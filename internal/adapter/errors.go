@@ -0,0 +1,53 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ProviderError is returned by a Sender when the upstream provider rejected
+// the request, as opposed to the request never reaching it.
+type ProviderError struct {
+	Provider   string
+	StatusCode int
+	// Code is the provider's own error code, when it sends one.
+	Code    string
+	Message string
+}
+
+func (e *ProviderError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%s error: %s", e.Provider, e.Message)
+	}
+	return fmt.Sprintf("%s error: status %d", e.Provider, e.StatusCode)
+}
+
+// ErrorCode returns a short, low-cardinality code describing err, suitable
+// for a metric label: the provider's error code or HTTP status for a
+// ProviderError, "timeout" for deadline errors and "transport" otherwise.
+func ErrorCode(err error) string {
+	var perr *ProviderError
+	switch {
+	case errors.As(err, &perr):
+		if perr.Code != "" {
+			return perr.Code
+		}
+		return strconv.Itoa(perr.StatusCode)
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "transport"
+	}
+}
+
+// StatusCode returns the provider's HTTP status for a ProviderError, or 0
+// when err never reached the provider.
+func StatusCode(err error) int {
+	var perr *ProviderError
+	if errors.As(err, &perr) {
+		return perr.StatusCode
+	}
+	return 0
+}
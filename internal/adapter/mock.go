@@ -6,7 +6,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/rs/zerolog/log"
+
+	"notification-system/pkg/logger"
 )
 
 // MockAdapter simulates sending notifications for local development and testing.
@@ -34,7 +35,7 @@ func (m *MockAdapter) Send(ctx context.Context, to, subject, body string) (*Send
 
 	providerID := fmt.Sprintf("mock-%s-%s", m.platform, uuid.New().String()[:8])
 
-	log.Info().
+	logger.Ctx(ctx).Info().
 		Str("platform", m.platform).
 		Str("to", to).
 		Str("subject", subject).
@@ -48,3 +49,8 @@ func (m *MockAdapter) Send(ctx context.Context, to, subject, body string) (*Send
 func (m *MockAdapter) Platform() string {
 	return m.platform
 }
+
+// Provider returns "mock".
+func (m *MockAdapter) Provider() string {
+	return "mock"
+}
@@ -5,6 +5,7 @@ import "context"
 // SendResult holds the result of a send operation.
 type SendResult struct {
 	ProviderID string // Provider-assigned message ID
+	StatusCode int    // HTTP status of the provider's response, if any
 }
 
 // Sender defines the interface for sending notifications through a platform.
@@ -14,4 +15,7 @@ type Sender interface {
 
 	// Platform returns the platform name this sender handles.
 	Platform() string
+
+	// Provider returns the name of the upstream provider, used for usage accounting.
+	Provider() string
 }
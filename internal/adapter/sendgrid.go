@@ -7,9 +7,8 @@ import (
 	"fmt"
 	"net/http"
 
-	"github.com/rs/zerolog/log"
-
 	"notification-system/internal/config"
+	"notification-system/pkg/logger"
 )
 
 // SendGridAdapter sends emails via the SendGrid v3 API.
@@ -82,11 +81,11 @@ func (s *SendGridAdapter) Send(ctx context.Context, to, subject, body string) (*
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		log.Error().
+		logger.Ctx(ctx).Error().
 			Str("to", to).
 			Int("status_code", resp.StatusCode).
 			Msg("sendgrid send failed")
-		return nil, fmt.Errorf("sendgrid error: status %d", resp.StatusCode)
+		return nil, &ProviderError{Provider: "sendgrid", StatusCode: resp.StatusCode}
 	}
 
 	// SendGrid returns the message ID in the X-Message-Id header
@@ -95,10 +94,20 @@ func (s *SendGridAdapter) Send(ctx context.Context, to, subject, body string) (*
 		msgID = fmt.Sprintf("sg-%s", to) // fallback
 	}
 
-	return &SendResult{ProviderID: msgID}, nil
+	logger.Ctx(ctx).Debug().
+		Str("to", to).
+		Str("provider_id", msgID).
+		Msg("sendgrid accepted message")
+
+	return &SendResult{ProviderID: msgID, StatusCode: resp.StatusCode}, nil
 }
 
 // Platform returns "email".
 func (s *SendGridAdapter) Platform() string {
 	return "email"
 }
+
+// Provider returns "sendgrid".
+func (s *SendGridAdapter) Provider() string {
+	return "sendgrid"
+}
@@ -0,0 +1,43 @@
+package adapter
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"notification-system/internal/tracing"
+)
+
+// tracedSender wraps a Sender with a client span around each Send.
+type tracedSender struct {
+	Sender
+}
+
+// WithTracing returns s wrapped so every Send is recorded as a span.
+func WithTracing(s Sender) Sender {
+	return &tracedSender{Sender: s}
+}
+
+// Send records a span for the provider call and delegates to the wrapped Sender.
+func (t *tracedSender) Send(ctx context.Context, to, subject, body string) (*SendResult, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "send "+t.Platform(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("notification.platform", t.Platform()),
+			attribute.String("notification.provider", t.Provider()),
+		),
+	)
+	defer span.End()
+
+	result, err := t.Sender.Send(ctx, to, subject, body)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.String("notification.provider_id", result.ProviderID))
+	return result, nil
+}
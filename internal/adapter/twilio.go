@@ -6,11 +6,11 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 
-	"github.com/rs/zerolog/log"
-
 	"notification-system/internal/config"
+	"notification-system/pkg/logger"
 )
 
 // TwilioAdapter sends SMS messages via the Twilio REST API.
@@ -54,8 +54,12 @@ func (t *TwilioAdapter) Send(ctx context.Context, to, subject, body string) (*Se
 	}
 	defer resp.Body.Close()
 
+	// Rejected requests carry code/message; accepted messages that later
+	// fail carry error_code/error_message.
 	var result struct {
 		SID          string `json:"sid"`
+		Code         *int   `json:"code"`
+		Message      string `json:"message"`
 		ErrorCode    *int   `json:"error_code"`
 		ErrorMessage string `json:"error_message"`
 	}
@@ -65,18 +69,37 @@ func (t *TwilioAdapter) Send(ctx context.Context, to, subject, body string) (*Se
 	}
 
 	if resp.StatusCode >= 400 || result.ErrorCode != nil {
-		log.Error().
+		perr := &ProviderError{Provider: "twilio", StatusCode: resp.StatusCode, Message: result.ErrorMessage}
+		if result.ErrorCode != nil {
+			perr.Code = strconv.Itoa(*result.ErrorCode)
+		} else if result.Code != nil {
+			perr.Code = strconv.Itoa(*result.Code)
+			perr.Message = result.Message
+		}
+
+		logger.Ctx(ctx).Error().
 			Str("to", to).
 			Int("status_code", resp.StatusCode).
-			Str("error_message", result.ErrorMessage).
+			Str("error_code", perr.Code).
+			Str("error_message", perr.Message).
 			Msg("twilio send failed")
-		return nil, fmt.Errorf("twilio error: %s", result.ErrorMessage)
+		return nil, perr
 	}
 
-	return &SendResult{ProviderID: result.SID}, nil
+	logger.Ctx(ctx).Debug().
+		Str("to", to).
+		Str("provider_id", result.SID).
+		Msg("twilio accepted message")
+
+	return &SendResult{ProviderID: result.SID, StatusCode: resp.StatusCode}, nil
 }
 
 // Platform returns "sms".
 func (t *TwilioAdapter) Platform() string {
 	return "sms"
 }
+
+// Provider returns "twilio".
+func (t *TwilioAdapter) Provider() string {
+	return "twilio"
+}
@@ -1,13 +1,36 @@
 package auth
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
+	"fmt"
 )
 
+// APIKeyPrefix is prepended to every generated key so keys are recognisable
+// in logs and secret scanners.
+const APIKeyPrefix = "ntf_"
+
+// apiKeyDisplayLen is the number of leading characters stored in clear text
+// so users can tell their keys apart.
+const apiKeyDisplayLen = len(APIKeyPrefix) + 8
+
 // HashAPIKey returns the SHA-256 hex digest of a raw API key.
-// This is used to compare against the api_key_hash column in the DB.
+// This is used to compare against the key_hash column in the DB.
 func HashAPIKey(key string) string {
 	h := sha256.Sum256([]byte(key))
 	return hex.EncodeToString(h[:])
 }
+
+// GenerateAPIKey returns a new random API key and its displayable prefix.
+// The raw key is only ever shown to the caller once; only its hash is stored.
+func GenerateAPIKey() (key, prefix string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	key = APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, key[:apiKeyDisplayLen], nil
+}
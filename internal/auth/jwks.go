@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// jwksMinRefetch limits how often an unknown kid triggers a refetch, so
+// tokens with made-up key IDs can't hammer the identity provider.
+const jwksMinRefetch = 30 * time.Second
+
+// ErrUnknownKey is returned when no key in the set matches a token's kid.
+var ErrUnknownKey = errors.New("no matching key in jwks")
+
+// JWKS is a cached JSON Web Key Set loaded from a URL or a local file.
+// URL-backed sets are refetched every refresh interval, and early when a
+// token references a key ID the set doesn't contain yet.
+type JWKS struct {
+	url     string
+	file    string
+	refresh time.Duration
+	client  *http.Client
+
+	mu          sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// NewJWKS creates a JWKS from url or, if url is empty, from file, and loads it once.
+func NewJWKS(ctx context.Context, url, file string, refresh time.Duration) (*JWKS, error) {
+	if url == "" && file == "" {
+		return nil, errors.New("jwks url or file is required")
+	}
+
+	j := &JWKS{
+		url:     url,
+		file:    file,
+		refresh: refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+	if err := j.load(ctx); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Key returns the public key for kid. An empty kid matches when the set
+// holds exactly one key.
+func (j *JWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	key, stale := j.lookup(kid)
+	if key != nil && !stale {
+		return key, nil
+	}
+
+	if j.shouldReload(key == nil) {
+		if err := j.load(ctx); err != nil {
+			if key != nil {
+				// Keep serving the last good set if the provider is unreachable.
+				return key, nil
+			}
+			return nil, err
+		}
+		key, _ = j.lookup(kid)
+	}
+
+	if key == nil {
+		return nil, fmt.Errorf("%w: kid %q", ErrUnknownKey, kid)
+	}
+	return key, nil
+}
+
+func (j *JWKS) lookup(kid string) (crypto.PublicKey, bool) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	stale := j.url != "" && j.refresh > 0 && time.Since(j.fetchedAt) > j.refresh
+	if kid == "" && len(j.keys) == 1 {
+		for _, k := range j.keys {
+			return k, stale
+		}
+	}
+	return j.keys[kid], stale
+}
+
+func (j *JWKS) shouldReload(missing bool) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.url == "" {
+		return false
+	}
+	if missing && time.Since(j.lastAttempt) < jwksMinRefetch {
+		return false
+	}
+	j.lastAttempt = time.Now()
+	return true
+}
+
+func (j *JWKS) load(ctx context.Context) error {
+	data, err := j.read(ctx)
+	if err != nil {
+		return err
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("failed to decode jwks: %w", err)
+	}
+
+	// Providers publish keys for other purposes and algorithms alongside
+	// their signing keys, so keys that can't verify tokens are skipped
+	// rather than rejecting the whole set.
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			log.Debug().Str("kid", k.Kid).Str("use", k.Use).Msg("skipping jwk not used for signatures")
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			log.Warn().Err(err).Str("kid", k.Kid).Str("kty", k.Kty).Msg("skipping unusable jwk")
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+	if len(keys) == 0 {
+		return errors.New("jwks contains no usable signing keys")
+	}
+
+	j.mu.Lock()
+	j.keys = keys
+	j.fetchedAt = time.Now()
+	j.mu.Unlock()
+	return nil
+}
+
+func (j *JWKS) read(ctx context.Context) ([]byte, error) {
+	if j.url == "" {
+		data, err := os.ReadFile(j.file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read jwks file: %w", err)
+		}
+		return data, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create jwks request: %w", err)
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch jwks: status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid base64url integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
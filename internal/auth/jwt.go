@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"notification-system/internal/config"
+)
+
+// tokenAlgorithms are the signing algorithms accepted for bearer tokens.
+// Symmetric algorithms are deliberately excluded: keys come from a JWKS.
+var tokenAlgorithms = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// TokenClaims are the parts of a verified bearer token the API uses.
+type TokenClaims struct {
+	Subject string
+	// User is the value of the configured user claim, matched against
+	// users.id when it is a UUID and users.email otherwise.
+	User   string
+	Scopes []string
+}
+
+// TokenVerifier validates JWT bearer tokens issued by an external identity
+// provider, typically via the OAuth2 client-credentials flow.
+type TokenVerifier struct {
+	jwks      *JWKS
+	parser    *jwt.Parser
+	userClaim string
+}
+
+// NewTokenVerifier creates a TokenVerifier checking signatures against jwks.
+func NewTokenVerifier(jwks *JWKS, cfg config.JWTConfig) *TokenVerifier {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(tokenAlgorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Leeway),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	userClaim := cfg.UserClaim
+	if userClaim == "" {
+		userClaim = "sub"
+	}
+
+	return &TokenVerifier{
+		jwks:      jwks,
+		parser:    jwt.NewParser(opts...),
+		userClaim: userClaim,
+	}
+}
+
+// Verify checks the token's signature and registered claims and returns its
+// subject, user and scopes.
+func (v *TokenVerifier) Verify(ctx context.Context, raw string) (*TokenClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.jwks.Key(ctx, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sub, _ := claims.GetSubject()
+	user, _ := claims[v.userClaim].(string)
+	if user == "" {
+		return nil, fmt.Errorf("token has no %q claim", v.userClaim)
+	}
+
+	return &TokenClaims{
+		Subject: sub,
+		User:    user,
+		Scopes:  tokenScopes(claims),
+	}, nil
+}
+
+// tokenScopes reads scopes from the space-delimited "scope" claim (RFC 8693)
+// or the "scp" claim, which providers send as a string or an array.
+func tokenScopes(claims jwt.MapClaims) []string {
+	for _, name := range []string{"scope", "scp"} {
+		switch v := claims[name].(type) {
+		case string:
+			return strings.Fields(v)
+		case []interface{}:
+			scopes := make([]string, 0, len(v))
+			for _, s := range v {
+				if str, ok := s.(string); ok {
+					scopes = append(scopes, str)
+				}
+			}
+			return scopes
+		}
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"notification-system/internal/config"
+)
+
+const (
+	testIssuer   = "https://idp.example.com/"
+	testAudience = "notification-api"
+)
+
+// testKeys are signing keys generated for a test.
+type testKeys struct {
+	rsa     *rsa.PrivateKey
+	ecdsa   *ecdsa.PrivateKey
+	ed25519 ed25519.PrivateKey
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ecdsa key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ed25519 key: %v", err)
+	}
+	return &testKeys{rsa: rsaKey, ecdsa: ecKey, ed25519: edKey}
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// set returns a JWKS document with the signing keys under kids "rsa", "ec"
+// and "ed", followed by extra.
+func (k *testKeys) set(extra ...map[string]string) []map[string]string {
+	ecX := make([]byte, 32)
+	ecY := make([]byte, 32)
+	k.ecdsa.X.FillBytes(ecX)
+	k.ecdsa.Y.FillBytes(ecY)
+
+	keys := []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "use": "sig", "n": b64(k.rsa.N.Bytes()), "e": b64(big.NewInt(int64(k.rsa.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": b64(ecX), "y": b64(ecY)},
+		{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": b64(k.ed25519.Public().(ed25519.PublicKey))},
+	}
+	return append(keys, extra...)
+}
+
+// serveJWKS serves keys as a JWKS document for the duration of the test.
+func serveJWKS(t *testing.T, keys []map[string]string) string {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func newTestVerifier(t *testing.T, keys []map[string]string) *TokenVerifier {
+	t.Helper()
+
+	jwks, err := NewJWKS(context.Background(), serveJWKS(t, keys), "", time.Hour)
+	if err != nil {
+		t.Fatalf("NewJWKS: %v", err)
+	}
+	return NewTokenVerifier(jwks, config.JWTConfig{
+		Issuer:   testIssuer,
+		Audience: testAudience,
+	})
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key crypto.PrivateKey, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	raw, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return raw
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"sub":   "client-1",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "messages:read messages:write",
+	}
+}
+
+func TestTokenVerifierVerify(t *testing.T) {
+	keys := newTestKeys(t)
+	v := newTestVerifier(t, keys.set())
+
+	with := func(name string, value interface{}) jwt.MapClaims {
+		c := validClaims()
+		if value == nil {
+			delete(c, name)
+		} else {
+			c[name] = value
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{
+			name:  "valid RS256",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, validClaims()),
+		},
+		{
+			name:  "valid ES256",
+			token: sign(t, jwt.SigningMethodES256, "ec", keys.ecdsa, validClaims()),
+		},
+		{
+			name:  "valid EdDSA",
+			token: sign(t, jwt.SigningMethodEdDSA, "ed", keys.ed25519, validClaims()),
+		},
+		{
+			name:    "expired",
+			token:   sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, with("exp", time.Now().Add(-time.Minute).Unix())),
+			wantErr: jwt.ErrTokenExpired,
+		},
+		{
+			name:    "missing exp",
+			token:   sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, with("exp", nil)),
+			wantErr: jwt.ErrTokenRequiredClaimMissing,
+		},
+		{
+			name:    "wrong audience",
+			token:   sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, with("aud", "another-api")),
+			wantErr: jwt.ErrTokenInvalidAudience,
+		},
+		{
+			name:    "wrong issuer",
+			token:   sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, with("iss", "https://evil.example.com/")),
+			wantErr: jwt.ErrTokenInvalidIssuer,
+		},
+		{
+			name:    "unknown kid",
+			token:   sign(t, jwt.SigningMethodRS256, "rotated-away", keys.rsa, validClaims()),
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name:    "signed by another key",
+			token:   sign(t, jwt.SigningMethodRS256, "rsa", newTestKeys(t).rsa, validClaims()),
+			wantErr: jwt.ErrTokenSignatureInvalid,
+		},
+		{
+			name:    "HS256 with the public key as secret",
+			token:   sign(t, jwt.SigningMethodHS256, "rsa", []byte(b64(keys.rsa.N.Bytes())), validClaims()),
+			wantErr: jwt.ErrTokenSignatureInvalid,
+		},
+		{
+			name:    "alg none",
+			token:   sign(t, jwt.SigningMethodNone, "rsa", jwt.UnsafeAllowNoneSignatureType, validClaims()),
+			wantErr: jwt.ErrTokenSignatureInvalid,
+		},
+		{
+			name:    "alg not matching the key",
+			token:   sign(t, jwt.SigningMethodES256, "rsa", keys.ecdsa, validClaims()),
+			wantErr: jwt.ErrTokenSignatureInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := v.Verify(context.Background(), tt.token)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Verify: got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if claims.Subject != "client-1" || claims.User != "client-1" {
+				t.Errorf("got subject %q and user %q, want client-1", claims.Subject, claims.User)
+			}
+			if len(claims.Scopes) != 2 || claims.Scopes[0] != "messages:read" || claims.Scopes[1] != "messages:write" {
+				t.Errorf("got scopes %v, want [messages:read messages:write]", claims.Scopes)
+			}
+		})
+	}
+}
+
+func TestJWKSSkipsUnusableKeys(t *testing.T) {
+	keys := newTestKeys(t)
+	v := newTestVerifier(t, keys.set(
+		map[string]string{"kty": "RSA", "kid": "enc", "use": "enc", "n": b64(keys.rsa.N.Bytes()), "e": "AQAB"},
+		map[string]string{"kty": "EC", "kid": "k1", "crv": "secp256k1", "x": "AQ", "y": "AQ"},
+		map[string]string{"kty": "OKP", "kid": "x25519", "crv": "X25519", "x": "AQ"},
+		map[string]string{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"},
+	))
+
+	if _, err := v.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, validClaims())); err != nil {
+		t.Fatalf("Verify with a usable key: %v", err)
+	}
+	if _, err := v.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "enc", keys.rsa, validClaims())); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Verify with an encryption key: got error %v, want %v", err, ErrUnknownKey)
+	}
+}
+
+func TestJWKSWithoutUsableKeys(t *testing.T) {
+	url := serveJWKS(t, []map[string]string{
+		{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"},
+		{"kty": "OKP", "kid": "x25519", "crv": "X25519", "x": "AQ"},
+	})
+	if _, err := NewJWKS(context.Background(), url, "", time.Hour); err == nil {
+		t.Fatal("NewJWKS succeeded with no usable keys")
+	}
+}
@@ -0,0 +1,60 @@
+package auth
+
+// API key scopes. A key may only call routes whose scope it has been granted.
+const (
+	ScopeAll            = "*" // grants every scope
+	ScopeMessagesSend   = "messages:send"
+	ScopeMessagesRead   = "messages:read"
+	ScopeMessagesWrite  = "messages:write" // cancel and edit existing messages
+	ScopeKeysRead       = "keys:read"
+	ScopeKeysWrite      = "keys:write"
+	ScopeUsageRead      = "usage:read"
+	ScopeSchedulesRead  = "schedules:read"
+	ScopeSchedulesWrite = "schedules:write"
+	ScopeAdmin          = "admin" // admin API; also requires the admin role
+)
+
+// KnownScopes lists every scope that can be granted to a key.
+var KnownScopes = []string{
+	ScopeAll,
+	ScopeMessagesSend,
+	ScopeMessagesRead,
+	ScopeMessagesWrite,
+	ScopeKeysRead,
+	ScopeKeysWrite,
+	ScopeUsageRead,
+	ScopeSchedulesRead,
+	ScopeSchedulesWrite,
+	ScopeAdmin,
+}
+
+// IsKnownScope reports whether scope is a recognised scope name.
+func IsKnownScope(scope string) bool {
+	for _, s := range KnownScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope reports whether the granted scopes include required.
+func HasScope(granted []string, required string) bool {
+	for _, s := range granted {
+		if s == ScopeAll || s == required {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAllScopes reports whether the granted scopes include every requested scope.
+// It is used to stop a key from minting keys more powerful than itself.
+func HasAllScopes(granted, requested []string) bool {
+	for _, r := range requested {
+		if !HasScope(granted, r) {
+			return false
+		}
+	}
+	return true
+}
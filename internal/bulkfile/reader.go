@@ -0,0 +1,236 @@
+// Package bulkfile reads the rows of bulk send uploads from CSV or NDJSON and
+// renders their message templates.
+package bulkfile
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// ErrInvalidFile is returned when an upload can't be read at all, as opposed
+// to containing some invalid rows.
+var ErrInvalidFile = errors.New("invalid bulk file")
+
+// maxLineBytes bounds a single NDJSON line.
+const maxLineBytes = 1 << 20
+
+// Format is the encoding of an uploaded file.
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+// DetectFormat returns the format of a file from its name or content type,
+// or "" if neither is recognized.
+func DetectFormat(filename, contentType string) Format {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv":
+		return FormatCSV
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON
+	}
+
+	switch strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]) {
+	case "text/csv":
+		return FormatCSV
+	case "application/x-ndjson", "application/jsonl", "application/ndjson":
+		return FormatNDJSON
+	}
+	return ""
+}
+
+// Mapping says which column holds each row's recipient and which column
+// fills each template variable, keyed by variable name. For NDJSON, columns
+// are the keys of each object.
+type Mapping struct {
+	Recipient string            `json:"recipient"`
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// Row is a valid row of an upload. Line is its 1-based line number in the
+// file.
+type Row struct {
+	Line      int
+	Recipient string
+	Vars      map[string]string
+}
+
+// RowError reports an invalid row. Reading can continue past it.
+type RowError struct {
+	Line int
+	Err  error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// Reader reads rows one at a time, so files of any size are processed in
+// constant memory.
+type Reader struct {
+	mapping Mapping
+	next    func() (Row, error)
+}
+
+// NewReader returns a Reader for r. For CSV, the first record is the header
+// and must contain every mapped column.
+func NewReader(r io.Reader, format Format, mapping Mapping) (*Reader, error) {
+	if mapping.Recipient == "" {
+		return nil, fmt.Errorf("%w: mapping has no recipient column", ErrInvalidFile)
+	}
+
+	rd := &Reader{mapping: mapping}
+	switch format {
+	case FormatCSV:
+		next, err := rd.csvRows(r)
+		if err != nil {
+			return nil, err
+		}
+		rd.next = next
+	case FormatNDJSON:
+		rd.next = rd.ndjsonRows(r)
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidFile, format)
+	}
+	return rd, nil
+}
+
+// Next returns the next row. It returns a *RowError for an invalid row,
+// io.EOF after the last row, and any other error when the rest of the file
+// can't be read.
+func (r *Reader) Next() (Row, error) {
+	return r.next()
+}
+
+func (r *Reader) csvRows(src io.Reader) (func() (Row, error), error) {
+	cr := csv.NewReader(src)
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: file is empty", ErrInvalidFile)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff") // spreadsheet byte order mark
+		}
+		columns[strings.TrimSpace(name)] = i
+	}
+
+	recipientCol, ok := columns[r.mapping.Recipient]
+	if !ok {
+		return nil, fmt.Errorf("%w: no %q column", ErrInvalidFile, r.mapping.Recipient)
+	}
+	varCols := make(map[string]int, len(r.mapping.Variables))
+	for name, col := range r.mapping.Variables {
+		i, ok := columns[col]
+		if !ok {
+			return nil, fmt.Errorf("%w: no %q column", ErrInvalidFile, col)
+		}
+		varCols[name] = i
+	}
+
+	return func() (Row, error) {
+		record, err := cr.Read()
+		if err != nil {
+			var perr *csv.ParseError
+			if errors.As(err, &perr) && errors.Is(perr.Err, csv.ErrFieldCount) {
+				return Row{}, &RowError{Line: perr.StartLine, Err: fmt.Errorf("has %d fields, header has %d", len(record), len(header))}
+			}
+			if errors.Is(err, io.EOF) {
+				return Row{}, io.EOF
+			}
+			return Row{}, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		line, _ := cr.FieldPos(0)
+
+		row := Row{
+			Line:      line,
+			Recipient: strings.TrimSpace(record[recipientCol]),
+			Vars:      make(map[string]string, len(varCols)),
+		}
+		for name, i := range varCols {
+			row.Vars[name] = record[i]
+		}
+		if row.Recipient == "" {
+			return Row{}, &RowError{Line: line, Err: errors.New("recipient is empty")}
+		}
+		return row, nil
+	}, nil
+}
+
+func (r *Reader) ndjsonRows(src io.Reader) func() (Row, error) {
+	sc := bufio.NewScanner(src)
+	sc.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+	line := 0
+
+	return func() (Row, error) {
+		for sc.Scan() {
+			line++
+			text := strings.TrimSpace(sc.Text())
+			if text == "" {
+				continue
+			}
+
+			var obj map[string]any
+			if err := json.Unmarshal([]byte(text), &obj); err != nil {
+				return Row{}, &RowError{Line: line, Err: errors.New("not a JSON object")}
+			}
+
+			recipient, ok := field(obj, r.mapping.Recipient)
+			recipient = strings.TrimSpace(recipient)
+			if !ok || recipient == "" {
+				return Row{}, &RowError{Line: line, Err: fmt.Errorf("missing %q", r.mapping.Recipient)}
+			}
+
+			row := Row{Line: line, Recipient: recipient, Vars: make(map[string]string, len(r.mapping.Variables))}
+			for name, key := range r.mapping.Variables {
+				v, ok := field(obj, key)
+				if !ok {
+					return Row{}, &RowError{Line: line, Err: fmt.Errorf("missing %q", key)}
+				}
+				row.Vars[name] = v
+			}
+			return row, nil
+		}
+
+		if err := sc.Err(); err != nil {
+			return Row{}, fmt.Errorf("%w: line %d: %v", ErrInvalidFile, line+1, err)
+		}
+		return Row{}, io.EOF
+	}
+}
+
+// field returns obj[key] as a string. Nested values keep their JSON form.
+func field(obj map[string]any, key string) (string, bool) {
+	v, ok := obj[key]
+	if !ok {
+		return "", false
+	}
+	switch v := v.(type) {
+	case nil:
+		return "", true
+	case string:
+		return v, true
+	default:
+		b, _ := json.Marshal(v)
+		return string(b), true
+	}
+}
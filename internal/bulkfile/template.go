@@ -0,0 +1,41 @@
+package bulkfile
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidTemplate is returned when a template uses a variable that isn't
+// mapped to a column.
+var ErrInvalidTemplate = errors.New("invalid template")
+
+// placeholder matches {{name}}, allowing spaces inside the braces.
+var placeholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+)\s*\}\}`)
+
+// Template is a message subject or body with {{name}} placeholders.
+type Template struct {
+	text string
+}
+
+// ParseTemplate parses text and checks that every placeholder it uses is
+// one of vars.
+func ParseTemplate(text string, vars map[string]string) (*Template, error) {
+	for _, m := range placeholder.FindAllStringSubmatch(text, -1) {
+		if _, ok := vars[m[1]]; !ok {
+			return nil, fmt.Errorf("%w: variable %q is not mapped to a column", ErrInvalidTemplate, m[1])
+		}
+	}
+	return &Template{text: text}, nil
+}
+
+// Render replaces each placeholder with its value in vars.
+func (t *Template) Render(vars map[string]string) string {
+	if !strings.Contains(t.text, "{{") {
+		return t.text
+	}
+	return placeholder.ReplaceAllStringFunc(t.text, func(m string) string {
+		return vars[placeholder.FindStringSubmatch(m)[1]]
+	})
+}
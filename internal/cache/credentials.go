@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"notification-system/internal/config"
+	"notification-system/internal/model"
+)
+
+const (
+	credentialKeyPrefix = "auth:key:"
+	touchKeyPrefix      = "auth:touch:"
+
+	// missingMarker is stored for hashes that don't match any key, so repeated
+	// requests with a bad key don't reach Postgres.
+	missingMarker = "-"
+)
+
+// Credentials is the cached result of resolving an API key hash.
+type Credentials struct {
+	Key  *model.APIKey `json:"key"`
+	User *model.User   `json:"user"`
+}
+
+// CredentialCache is a read-through Redis cache of API key lookups, keyed by
+// key hash. Entries live for a short TTL and are deleted explicitly when a key
+// is revoked or rotated or its owner changes.
+type CredentialCache struct {
+	rdb         *redis.Client
+	ttl         time.Duration
+	negativeTTL time.Duration
+}
+
+// NewCredentialCache creates a CredentialCache. It returns nil when caching is
+// disabled; a nil *CredentialCache is valid and caches nothing.
+func NewCredentialCache(rdb *redis.Client, cfg config.AuthCacheConfig) *CredentialCache {
+	if !cfg.Enabled || rdb == nil {
+		return nil
+	}
+	return &CredentialCache{
+		rdb:         rdb,
+		ttl:         cfg.TTL,
+		negativeTTL: cfg.NegativeTTL,
+	}
+}
+
+// Get looks up hash. found reports whether the cache held an entry; a found
+// entry with nil credentials means the hash is known not to exist.
+func (c *CredentialCache) Get(ctx context.Context, hash string) (creds *Credentials, found bool, err error) {
+	if c == nil {
+		return nil, false, nil
+	}
+
+	val, err := c.rdb.Get(ctx, credentialKeyPrefix+hash).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	if val == missingMarker {
+		return nil, true, nil
+	}
+
+	creds = &Credentials{}
+	if err := json.Unmarshal([]byte(val), creds); err != nil {
+		return nil, false, fmt.Errorf("failed to decode cached credentials: %w", err)
+	}
+	if creds.Key == nil || creds.User == nil {
+		return nil, false, nil
+	}
+	creds.Key.KeyHash = hash
+	return creds, true, nil
+}
+
+// Set caches the credentials resolved for hash.
+func (c *CredentialCache) Set(ctx context.Context, hash string, creds Credentials) error {
+	if c == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(creds)
+	if err != nil {
+		return fmt.Errorf("failed to encode credentials: %w", err)
+	}
+	return c.rdb.Set(ctx, credentialKeyPrefix+hash, data, c.ttl).Err()
+}
+
+// SetMissing records that hash doesn't match any key.
+func (c *CredentialCache) SetMissing(ctx context.Context, hash string) error {
+	if c == nil || c.negativeTTL <= 0 {
+		return nil
+	}
+	return c.rdb.Set(ctx, credentialKeyPrefix+hash, missingMarker, c.negativeTTL).Err()
+}
+
+// Invalidate deletes the cached entries for the given key hashes.
+func (c *CredentialCache) Invalidate(ctx context.Context, hashes ...string) error {
+	if c == nil || len(hashes) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(hashes))
+	for i, h := range hashes {
+		keys[i] = credentialKeyPrefix + h
+	}
+	return c.rdb.Del(ctx, keys...).Err()
+}
+
+// ShouldTouch reports whether last_used_at should be written for keyID. It
+// returns true at most once per interval across all API replicas.
+func (c *CredentialCache) ShouldTouch(ctx context.Context, keyID uuid.UUID, interval time.Duration) (bool, error) {
+	return c.rdb.SetNX(ctx, touchKeyPrefix+keyID.String(), 1, interval).Result()
+}
@@ -10,13 +10,23 @@ import (
 
 // Config holds all configuration for the application.
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	RabbitMQ RabbitMQConfig `mapstructure:"rabbitmq"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	Redis     RedisConfig     `mapstructure:"redis"`
+	RabbitMQ  RabbitMQConfig  `mapstructure:"rabbitmq"`
+	Worker    WorkerConfig    `mapstructure:"worker"`
 	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	Auth      AuthConfig      `mapstructure:"auth"`
 	Platforms PlatformsConfig `mapstructure:"platforms"`
-	Logging  LoggingConfig  `mapstructure:"logging"`
+	SMS       SMSConfig       `mapstructure:"sms"`
+	Usage     UsageConfig     `mapstructure:"usage"`
+	Audit     AuditConfig     `mapstructure:"audit"`
+	Bulk      BulkConfig      `mapstructure:"bulk"`
+	Exports   ExportConfig    `mapstructure:"exports"`
+	Analytics AnalyticsConfig `mapstructure:"analytics"`
+	Tracing   TracingConfig   `mapstructure:"tracing"`
+	Health    HealthConfig    `mapstructure:"health"`
+	Logging   LoggingConfig   `mapstructure:"logging"`
 }
 
 type ServerConfig struct {
@@ -25,6 +35,9 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
 	WriteTimeout time.Duration `mapstructure:"write_timeout"`
 	IdleTimeout  time.Duration `mapstructure:"idle_timeout"`
+	// ShutdownTimeout bounds how long in-flight requests and the scheduler
+	// may take to finish after SIGTERM.
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
 }
 
 type DatabaseConfig struct {
@@ -63,15 +76,68 @@ type RabbitMQConfig struct {
 	PrefetchCount int    `mapstructure:"prefetch_count"`
 }
 
+// WorkerConfig holds settings for the worker process. MetricsAddr serves
+// /metrics, /livez and /readyz; empty disables the listener. DrainTimeout
+// bounds how long in-flight deliveries may take to finish after SIGTERM.
+// Concurrency is the number of deliveries processed at once per queue, and
+// Queues overrides it per platform.
+type WorkerConfig struct {
+	MetricsAddr      string                       `mapstructure:"metrics_addr"`
+	DrainTimeout     time.Duration                `mapstructure:"drain_timeout"`
+	Concurrency      int                          `mapstructure:"concurrency"`
+	OrderByRecipient bool                         `mapstructure:"order_by_recipient"`
+	Queues           map[string]WorkerQueueConfig `mapstructure:"queues"`
+}
+
+type WorkerQueueConfig struct {
+	Concurrency int `mapstructure:"concurrency"`
+}
+
+// QueueConcurrency returns the concurrency for the given platform's queue.
+func (w WorkerConfig) QueueConcurrency(platform string) int {
+	if q, ok := w.Queues[platform]; ok && q.Concurrency > 0 {
+		return q.Concurrency
+	}
+	return w.Concurrency
+}
+
 type RateLimitConfig struct {
-	Enabled bool                       `mapstructure:"enabled"`
-	Tiers   map[string]RateLimitTier   `mapstructure:"tiers"`
+	Enabled bool                     `mapstructure:"enabled"`
+	Tiers   map[string]RateLimitTier `mapstructure:"tiers"`
 }
 
 type RateLimitTier struct {
 	RequestsPerMin int `mapstructure:"requests_per_min"`
 }
 
+// AuthConfig controls how API credentials are verified.
+type AuthConfig struct {
+	Cache AuthCacheConfig `mapstructure:"cache"`
+	JWT   JWTConfig       `mapstructure:"jwt"`
+}
+
+// AuthCacheConfig controls the Redis cache of API key lookups. NegativeTTL
+// applies to keys that don't exist; 0 disables negative caching.
+type AuthCacheConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	TTL         time.Duration `mapstructure:"ttl"`
+	NegativeTTL time.Duration `mapstructure:"negative_ttl"`
+}
+
+// JWTConfig enables Authorization: Bearer tokens from an external identity
+// provider. Signing keys come from JWKSURL, or JWKSFile when no URL is set.
+// UserClaim names the claim holding the user's ID or email.
+type JWTConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	JWKSURL     string        `mapstructure:"jwks_url"`
+	JWKSFile    string        `mapstructure:"jwks_file"`
+	JWKSRefresh time.Duration `mapstructure:"jwks_refresh"`
+	Issuer      string        `mapstructure:"issuer"`
+	Audience    string        `mapstructure:"audience"`
+	UserClaim   string        `mapstructure:"user_claim"`
+	Leeway      time.Duration `mapstructure:"leeway"`
+}
+
 type PlatformsConfig struct {
 	SMS      PlatformConfig `mapstructure:"sms"`
 	WhatsApp PlatformConfig `mapstructure:"whatsapp"`
@@ -85,6 +151,97 @@ type PlatformConfig struct {
 	RateLimit int    `mapstructure:"rate_limit"`
 }
 
+// SMSConfig controls how SMS bodies are encoded and limited.
+// MaxSegments of 0 disables the segment limit.
+type SMSConfig struct {
+	MaxSegments   int  `mapstructure:"max_segments"`
+	Transliterate bool `mapstructure:"transliterate"`
+}
+
+// UsageConfig holds the price table used to estimate the cost of each delivery attempt.
+// Prices are keyed by platform, then provider; a "default" provider entry applies
+// to providers without an explicit price.
+type UsageConfig struct {
+	Currency string                        `mapstructure:"currency"`
+	Prices   map[string]map[string]float64 `mapstructure:"prices"`
+}
+
+// AuditConfig controls how long audit events are kept. A Retention of 0
+// keeps events forever.
+type AuditConfig struct {
+	Retention     time.Duration `mapstructure:"retention"`
+	PurgeInterval time.Duration `mapstructure:"purge_interval"`
+}
+
+// BulkConfig controls the background processing of bulk send jobs.
+// Concurrency is the number of items sent at once per API replica. Each
+// loop processes up to BatchSize items, one transaction each, before
+// checking for shutdown.
+type BulkConfig struct {
+	Concurrency  int           `mapstructure:"concurrency"`
+	BatchSize    int           `mapstructure:"batch_size"`
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+}
+
+// ExportConfig controls message exports. Completed exports can be
+// downloaded for TTL, after which their files are deleted.
+type ExportConfig struct {
+	TTL          time.Duration `mapstructure:"ttl"`
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	Storage      StorageConfig `mapstructure:"storage"`
+}
+
+// StorageConfig selects where files are kept. Driver is "local", writing
+// under Dir, or "s3", writing to an S3-compatible bucket.
+type StorageConfig struct {
+	Driver string   `mapstructure:"driver"`
+	Dir    string   `mapstructure:"dir"`
+	S3     S3Config `mapstructure:"s3"`
+}
+
+// S3Config locates an S3-compatible bucket such as AWS S3 or MinIO.
+// Objects are written under Prefix.
+type S3Config struct {
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	Prefix    string `mapstructure:"prefix"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+	UseSSL    bool   `mapstructure:"use_ssl"`
+}
+
+// AnalyticsConfig controls the background rollups behind delivery
+// analytics. Recipients updated within the last Lag are rolled up on a
+// later run, so analytics trail the present by between Lag and Lag plus
+// RollupInterval. Each run also re-reads the Overlap before the previous
+// run's cutoff, so an update is counted as long as it commits within Lag
+// plus Overlap of setting updated_at.
+type AnalyticsConfig struct {
+	RollupInterval time.Duration `mapstructure:"rollup_interval"`
+	Lag            time.Duration `mapstructure:"lag"`
+	Overlap        time.Duration `mapstructure:"overlap"`
+}
+
+// TracingConfig controls OpenTelemetry trace export. Exporter is "otlp"
+// (OTLP over HTTP to Endpoint) or "stdout". SampleRatio applies to traces
+// started in this process; incoming sampled traces are always continued.
+type TracingConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Exporter    string  `mapstructure:"exporter"`
+	Endpoint    string  `mapstructure:"endpoint"`
+	Insecure    bool    `mapstructure:"insecure"`
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+// HealthConfig controls readiness checks. Each check is bounded by Timeout.
+// Dependencies listed in Optional ("postgres", "redis", "rabbitmq") report
+// the service as degraded rather than not ready when they fail.
+type HealthConfig struct {
+	Timeout  time.Duration `mapstructure:"timeout"`
+	Optional []string      `mapstructure:"optional"`
+}
+
 // Platform credential configs loaded from environment variables.
 type TwilioConfig struct {
 	AccountSID  string
@@ -141,6 +298,8 @@ func Load(path string) (*Config, error) {
 	v.BindEnv("redis.password", "REDIS_PASSWORD")
 	v.BindEnv("rabbitmq.url", "RABBITMQ_URL")
 	v.BindEnv("logging.level", "LOG_LEVEL")
+	v.BindEnv("exports.storage.s3.access_key", "S3_ACCESS_KEY")
+	v.BindEnv("exports.storage.s3.secret_key", "S3_SECRET_KEY")
 
 	// Defaults
 	v.SetDefault("server.host", "0.0.0.0")
@@ -148,12 +307,47 @@ func Load(path string) (*Config, error) {
 	v.SetDefault("server.read_timeout", "15s")
 	v.SetDefault("server.write_timeout", "15s")
 	v.SetDefault("server.idle_timeout", "60s")
+	v.SetDefault("server.shutdown_timeout", "10s")
 	v.SetDefault("database.max_open_conns", 25)
 	v.SetDefault("database.max_idle_conns", 5)
 	v.SetDefault("redis.db", 0)
 	v.SetDefault("redis.pool_size", 10)
 	v.SetDefault("rabbitmq.prefetch_count", 10)
+	v.SetDefault("worker.metrics_addr", ":9091")
+	v.SetDefault("worker.drain_timeout", "30s")
+	v.SetDefault("worker.concurrency", 10)
+	v.SetDefault("worker.order_by_recipient", false)
 	v.SetDefault("rate_limit.enabled", true)
+	v.SetDefault("auth.cache.enabled", true)
+	v.SetDefault("auth.cache.ttl", "30s")
+	v.SetDefault("auth.cache.negative_ttl", "10s")
+	v.SetDefault("auth.jwt.enabled", false)
+	v.SetDefault("auth.jwt.jwks_refresh", "1h")
+	v.SetDefault("auth.jwt.user_claim", "sub")
+	v.SetDefault("auth.jwt.leeway", "30s")
+	v.SetDefault("sms.max_segments", 0)
+	v.SetDefault("sms.transliterate", false)
+	v.SetDefault("usage.currency", "USD")
+	v.SetDefault("audit.retention", "2160h")
+	v.SetDefault("audit.purge_interval", "1h")
+	v.SetDefault("bulk.concurrency", 4)
+	v.SetDefault("bulk.batch_size", 20)
+	v.SetDefault("bulk.poll_interval", "1s")
+	v.SetDefault("exports.ttl", "168h")
+	v.SetDefault("exports.poll_interval", "5s")
+	v.SetDefault("exports.storage.driver", "local")
+	v.SetDefault("exports.storage.dir", "./data/exports")
+	v.SetDefault("exports.storage.s3.region", "us-east-1")
+	v.SetDefault("exports.storage.s3.use_ssl", true)
+	v.SetDefault("analytics.rollup_interval", "1m")
+	v.SetDefault("analytics.lag", "1m")
+	v.SetDefault("analytics.overlap", "2m")
+	v.SetDefault("tracing.enabled", false)
+	v.SetDefault("tracing.exporter", "otlp")
+	v.SetDefault("tracing.endpoint", "localhost:4318")
+	v.SetDefault("tracing.insecure", true)
+	v.SetDefault("tracing.sample_ratio", 1.0)
+	v.SetDefault("health.timeout", "2s")
 	v.SetDefault("logging.level", "info")
 	v.SetDefault("logging.format", "json")
 
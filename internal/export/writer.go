@@ -0,0 +1,189 @@
+// Package export encodes exported messages as CSV, NDJSON or Parquet.
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"notification-system/internal/model"
+)
+
+// parquetRowGroupSize bounds the rows a Parquet writer buffers in memory
+// before flushing a row group.
+const parquetRowGroupSize = 50000
+
+// Record is one exported recipient of a message, flattened into columns.
+// Statuses are written by name rather than number.
+type Record struct {
+	MessageID       string     `json:"message_id" parquet:"message_id"`
+	RequestID       *string    `json:"request_id" parquet:"request_id,optional"`
+	Subject         string     `json:"subject" parquet:"subject"`
+	Body            string     `json:"body" parquet:"body"`
+	Sender          string     `json:"sender" parquet:"sender"`
+	Platform        string     `json:"platform" parquet:"platform"`
+	Priority        int32      `json:"priority" parquet:"priority"`
+	MessageStatus   string     `json:"message_status" parquet:"message_status"`
+	ScheduledAt     *time.Time `json:"scheduled_at" parquet:"scheduled_at,optional"`
+	CreatedAt       time.Time  `json:"created_at" parquet:"created_at"`
+	RecipientID     string     `json:"recipient_id" parquet:"recipient_id"`
+	Recipient       string     `json:"recipient" parquet:"recipient"`
+	RecipientStatus string     `json:"recipient_status" parquet:"recipient_status"`
+	ProviderID      *string    `json:"provider_id" parquet:"provider_id,optional"`
+	ErrorMessage    *string    `json:"error_message" parquet:"error_message,optional"`
+	RetryCount      int32      `json:"retry_count" parquet:"retry_count"`
+	SentAt          *time.Time `json:"sent_at" parquet:"sent_at,optional"`
+	DeliveredAt     *time.Time `json:"delivered_at" parquet:"delivered_at,optional"`
+}
+
+// columns are the CSV header, in the order of Record's fields.
+var columns = []string{
+	"message_id", "request_id", "subject", "body", "sender", "platform", "priority", "message_status",
+	"scheduled_at", "created_at", "recipient_id", "recipient", "recipient_status", "provider_id",
+	"error_message", "retry_count", "sent_at", "delivered_at",
+}
+
+// NewRecord flattens row into a Record.
+func NewRecord(row *model.ExportRow) Record {
+	return Record{
+		MessageID:       row.MessageID.String(),
+		RequestID:       row.RequestID,
+		Subject:         row.Subject,
+		Body:            row.Body,
+		Sender:          row.Sender,
+		Platform:        string(row.Platform),
+		Priority:        int32(row.Priority),
+		MessageStatus:   row.MessageStatus.String(),
+		ScheduledAt:     utc(row.ScheduledAt),
+		CreatedAt:       row.CreatedAt.UTC(),
+		RecipientID:     row.RecipientID.String(),
+		Recipient:       row.Recipient,
+		RecipientStatus: row.RecipientStatus.String(),
+		ProviderID:      row.ProviderID,
+		ErrorMessage:    row.ErrorMessage,
+		RetryCount:      int32(row.RetryCount),
+		SentAt:          utc(row.SentAt),
+		DeliveredAt:     utc(row.DeliveredAt),
+	}
+}
+
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+// Writer encodes rows to an underlying io.Writer. Close must be called to
+// flush buffered output; it doesn't close the underlying writer.
+type Writer interface {
+	Write(row *model.ExportRow) error
+	Close() error
+}
+
+// NewWriter returns a Writer for format.
+func NewWriter(w io.Writer, format model.ExportFormat) (Writer, error) {
+	switch format {
+	case model.ExportCSV:
+		return newCSVWriter(w)
+	case model.ExportNDJSON:
+		bw := bufio.NewWriter(w)
+		return &ndjsonWriter{buf: bw, enc: json.NewEncoder(bw)}, nil
+	case model.ExportParquet:
+		return &parquetWriter{w: parquet.NewGenericWriter[Record](w,
+			parquet.Compression(&parquet.Snappy),
+			parquet.MaxRowsPerRowGroup(parquetRowGroupSize),
+		)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// ContentType returns the MIME type of format.
+func ContentType(format model.ExportFormat) string {
+	switch format {
+	case model.ExportCSV:
+		return "text/csv"
+	case model.ExportNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/vnd.apache.parquet"
+	}
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: cw, record: make([]string, len(columns))}, nil
+}
+
+func (c *csvWriter) Write(row *model.ExportRow) error {
+	r := NewRecord(row)
+	c.record = append(c.record[:0],
+		r.MessageID, str(r.RequestID), r.Subject, r.Body, r.Sender, r.Platform,
+		strconv.Itoa(int(r.Priority)), r.MessageStatus, timestamp(r.ScheduledAt),
+		timestamp(&r.CreatedAt), r.RecipientID, r.Recipient, r.RecipientStatus,
+		str(r.ProviderID), str(r.ErrorMessage), strconv.Itoa(int(r.RetryCount)),
+		timestamp(r.SentAt), timestamp(r.DeliveredAt),
+	)
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func str(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func timestamp(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+type ndjsonWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func (n *ndjsonWriter) Write(row *model.ExportRow) error {
+	return n.enc.Encode(NewRecord(row))
+}
+
+func (n *ndjsonWriter) Close() error {
+	return n.buf.Flush()
+}
+
+type parquetWriter struct {
+	w *parquet.GenericWriter[Record]
+}
+
+func (p *parquetWriter) Write(row *model.ExportRow) error {
+	_, err := p.w.Write([]Record{NewRecord(row)})
+	return err
+}
+
+func (p *parquetWriter) Close() error {
+	return p.w.Close()
+}
@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"notification-system/internal/middleware"
+	"notification-system/internal/model"
+	"notification-system/internal/repository"
+	"notification-system/internal/sendwindow"
+	"notification-system/internal/service"
+	"notification-system/pkg/logger"
+)
+
+// AdminHandler handles HTTP requests for the admin API.
+type AdminHandler struct {
+	userService *service.UserService
+	auditRepo   repository.AuditRepository
+}
+
+// NewAdminHandler creates a new AdminHandler.
+func NewAdminHandler(userService *service.UserService, auditRepo repository.AuditRepository) *AdminHandler {
+	return &AdminHandler{
+		userService: userService,
+		auditRepo:   auditRepo,
+	}
+}
+
+// CreateUser handles POST /api/v1/admin/users
+func (h *AdminHandler) CreateUser(c *gin.Context) {
+	var req model.CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+		})
+		return
+	}
+
+	user, raw, key, err := h.userService.CreateUser(c.Request.Context(), req)
+	if err != nil {
+		respondUserError(c, err, "Failed to create user")
+		return
+	}
+
+	recordAudit(c, h.auditRepo, model.AuditUserCreate, model.AuditTargetUser, user.ID.String(), nil, gin.H{
+		"user":    user,
+		"api_key": key,
+	})
+
+	c.JSON(http.StatusCreated, model.CreateUserResponse{
+		Success: true,
+		User:    *user,
+		Key:     raw,
+		APIKey:  *key,
+	})
+}
+
+// ListUsers handles GET /api/v1/admin/users
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	var query model.ListUsersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+		})
+		return
+	}
+
+	users, total, err := h.userService.ListUsers(c.Request.Context(), query)
+	if err != nil {
+		respondUserError(c, err, "Failed to list users")
+		return
+	}
+	if users == nil {
+		users = []model.UserWithStats{}
+	}
+
+	c.JSON(http.StatusOK, model.ListUsersResponse{
+		Success: true,
+		Users:   users,
+		Pagination: model.Pagination{
+			Page:       query.Page,
+			Limit:      query.Limit,
+			Total:      total,
+			TotalPages: int(math.Ceil(float64(total) / float64(query.Limit))),
+		},
+	})
+}
+
+// GetUser handles GET /api/v1/admin/users/:id
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		respondUserError(c, err, "Failed to get user")
+		return
+	}
+
+	c.JSON(http.StatusOK, model.UserResponse{Success: true, User: *user})
+}
+
+// UpdateUser handles PATCH /api/v1/admin/users/:id
+func (h *AdminHandler) UpdateUser(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var req model.UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+		})
+		return
+	}
+
+	actor := middleware.GetUserFromContext(c)
+	before, after, err := h.userService.UpdateUser(c.Request.Context(), actor.ID, userID, req)
+	if err != nil {
+		respondUserError(c, err, "Failed to update user")
+		return
+	}
+
+	recordAudit(c, h.auditRepo, model.AuditUserUpdate, model.AuditTargetUser, userID.String(), before, after)
+
+	c.JSON(http.StatusOK, model.UserResponse{Success: true, User: *after})
+}
+
+// DeactivateUser handles POST /api/v1/admin/users/:id/deactivate
+func (h *AdminHandler) DeactivateUser(c *gin.Context) {
+	h.setActive(c, false)
+}
+
+// ReactivateUser handles POST /api/v1/admin/users/:id/reactivate
+func (h *AdminHandler) ReactivateUser(c *gin.Context) {
+	h.setActive(c, true)
+}
+
+func (h *AdminHandler) setActive(c *gin.Context, active bool) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	actor := middleware.GetUserFromContext(c)
+	before, after, err := h.userService.SetActive(c.Request.Context(), actor.ID, userID, active)
+	if err != nil {
+		respondUserError(c, err, "Failed to update user")
+		return
+	}
+
+	action := model.AuditUserDeactivate
+	if active {
+		action = model.AuditUserReactivate
+	}
+	recordAudit(c, h.auditRepo, action, model.AuditTargetUser, userID.String(), before, after)
+
+	c.JSON(http.StatusOK, model.UserResponse{Success: true, User: *after})
+}
+
+// ListAudit handles GET /api/v1/admin/audit
+func (h *AdminHandler) ListAudit(c *gin.Context) {
+	var query model.ListAuditQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+		})
+		return
+	}
+
+	var after *repository.Cursor
+	if query.Cursor != "" {
+		cur, err := repository.DecodeCursor(query.Cursor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Success: false,
+				Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "Invalid cursor"},
+			})
+			return
+		}
+		after = &cur
+	}
+
+	events, next, err := h.auditRepo.List(c.Request.Context(), query, after)
+	if err != nil {
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to list audit events")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "Failed to list audit events"},
+		})
+		return
+	}
+	if events == nil {
+		events = []model.AuditEvent{}
+	}
+
+	resp := model.ListAuditResponse{Success: true, Events: events}
+	if next != nil {
+		resp.NextCursor = next.Encode()
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// parseUserID parses the :id path parameter, writing a 400 response if it is invalid.
+func parseUserID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "Invalid user ID format"},
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// respondUserError maps UserService errors to HTTP responses.
+func respondUserError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "NOT_FOUND", Message: "User not found"},
+		})
+	case errors.Is(err, service.ErrUserExists):
+		c.JSON(http.StatusConflict, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "CONFLICT", Message: err.Error()},
+		})
+	case errors.Is(err, service.ErrSelfModification):
+		c.JSON(http.StatusConflict, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "INVALID_STATE", Message: err.Error()},
+		})
+	case errors.Is(err, service.ErrUnknownTier), errors.Is(err, service.ErrUnknownScope),
+		errors.Is(err, sendwindow.ErrInvalidWindow):
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+		})
+	default:
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg(fallback)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: fallback},
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"notification-system/internal/middleware"
+	"notification-system/internal/model"
+	"notification-system/internal/service"
+	"notification-system/pkg/logger"
+)
+
+// maxAnalyticsBuckets bounds the number of periods one analytics request
+// may cover, per platform.
+const maxAnalyticsBuckets = 1000
+
+// analyticsGranularities maps each granularity to its period and the range
+// used when no "from" is given.
+var analyticsGranularities = map[string]struct {
+	period        time.Duration
+	defaultWindow time.Duration
+}{
+	"hour": {time.Hour, 24 * time.Hour},
+	"day":  {24 * time.Hour, 30 * 24 * time.Hour},
+}
+
+// AnalyticsHandler handles HTTP requests for delivery analytics.
+type AnalyticsHandler struct {
+	analyticsService *service.AnalyticsService
+}
+
+// NewAnalyticsHandler creates a new AnalyticsHandler.
+func NewAnalyticsHandler(analyticsService *service.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{analyticsService: analyticsService}
+}
+
+// Deliveries handles GET /api/v1/analytics/deliveries
+// Admins see deliveries for every user; everyone else sees only their own.
+// The range is widened to whole periods of the requested granularity, in UTC.
+func (h *AnalyticsHandler) Deliveries(c *gin.Context) {
+	var query model.DeliveryAnalyticsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+		})
+		return
+	}
+
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "UNAUTHORIZED", Message: "User not found in context"},
+		})
+		return
+	}
+
+	g := analyticsGranularities[query.Granularity]
+	to := time.Now().UTC()
+	if query.To != nil {
+		to = query.To.UTC()
+	}
+	from := to.Add(-g.defaultWindow)
+	if query.From != nil {
+		from = query.From.UTC()
+	}
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "from must be before to"},
+		})
+		return
+	}
+
+	filter := model.DeliveryAnalyticsFilter{
+		Granularity: query.Granularity,
+		From:        from.Truncate(g.period),
+		To:          to.Truncate(g.period),
+		Platform:    query.Platform,
+	}
+	if filter.To.Before(to) {
+		filter.To = filter.To.Add(g.period)
+	}
+	if filter.To.Sub(filter.From) > maxAnalyticsBuckets*g.period {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error: model.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: fmt.Sprintf("range covers more than %d periods of granularity %s", maxAnalyticsBuckets, query.Granularity),
+			},
+		})
+		return
+	}
+	if !middleware.IsAdmin(c) {
+		filter.UserID = &user.ID
+	}
+
+	resp, err := h.analyticsService.Deliveries(c.Request.Context(), filter)
+	if err != nil {
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to build delivery analytics")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "Failed to build delivery analytics"},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
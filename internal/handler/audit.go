@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx/types"
+
+	"notification-system/internal/middleware"
+	"notification-system/internal/model"
+	"notification-system/internal/repository"
+	"notification-system/pkg/logger"
+	"notification-system/pkg/requestid"
+)
+
+// recordAudit writes an audit event for a completed state change, attributed
+// to the request's user, API key, client IP and request ID. before and
+// after are snapshots of the target and may be nil. Failures are logged and
+// never fail the request, since the change itself has already been made.
+func recordAudit(c *gin.Context, repo repository.AuditRepository, action, targetType, targetID string, before, after interface{}) {
+	evt := &model.AuditEvent{
+		ID:         uuid.New(),
+		Action:     action,
+		TargetType: targetType,
+		Before:     auditSnapshot(before),
+		After:      auditSnapshot(after),
+		CreatedAt:  time.Now(),
+	}
+	if targetID != "" {
+		evt.TargetID = &targetID
+	}
+	if user := middleware.GetUserFromContext(c); user != nil {
+		evt.ActorUserID = &user.ID
+	}
+	evt.ActorKeyID = apiKeyID(c)
+	if ip := c.ClientIP(); ip != "" {
+		evt.IP = &ip
+	}
+	if id := requestID(c); id != "" {
+		evt.RequestID = &id
+	}
+
+	if err := repo.Create(c.Request.Context(), evt); err != nil {
+		logger.Ctx(c.Request.Context()).Error().Err(err).
+			Str("action", action).
+			Str("target_id", targetID).
+			Msg("failed to record audit event")
+	}
+}
+
+// auditSnapshot encodes v as JSON, using JSON null for nil or unencodable values.
+func auditSnapshot(v interface{}) types.JSONText {
+	if v == nil {
+		return types.JSONText("null")
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return types.JSONText("null")
+	}
+	return types.JSONText(b)
+}
+
+// requestID returns the ID assigned to the request by middleware.RequestID.
+func requestID(c *gin.Context) string {
+	return requestid.FromContext(c.Request.Context())
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"notification-system/internal/export"
+	"notification-system/internal/middleware"
+	"notification-system/internal/model"
+	"notification-system/internal/repository"
+	"notification-system/internal/service"
+	"notification-system/pkg/logger"
+)
+
+// ExportHandler handles HTTP requests for message exports.
+type ExportHandler struct {
+	exportService *service.ExportService
+	auditRepo     repository.AuditRepository
+}
+
+// NewExportHandler creates a new ExportHandler.
+func NewExportHandler(exportService *service.ExportService, auditRepo repository.AuditRepository) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+		auditRepo:     auditRepo,
+	}
+}
+
+// CreateExport handles POST /api/v1/exports
+// The export is built in the background; its status is available from
+// GET /api/v1/exports/:id.
+func (h *ExportHandler) CreateExport(c *gin.Context) {
+	var req model.CreateExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+		})
+		return
+	}
+	if req.From != nil && req.To != nil && req.From.After(*req.To) {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "from must not be after to"},
+		})
+		return
+	}
+
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "UNAUTHORIZED", Message: "User not found in context"},
+		})
+		return
+	}
+
+	exp, err := h.exportService.Create(c.Request.Context(), user.ID, req)
+	if err != nil {
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to create export")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "Failed to create export"},
+		})
+		return
+	}
+
+	recordAudit(c, h.auditRepo, model.AuditExportCreate, model.AuditTargetExport, exp.ID.String(), nil, exp)
+
+	c.Header("Location", "/api/v1/exports/"+exp.ID.String())
+	c.JSON(http.StatusAccepted, model.ExportResponse{Success: true, Export: *exp})
+}
+
+// GetExport handles GET /api/v1/exports/:id
+func (h *ExportHandler) GetExport(c *gin.Context) {
+	exportID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "Invalid export ID format"},
+		})
+		return
+	}
+
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "UNAUTHORIZED", Message: "User not found in context"},
+		})
+		return
+	}
+
+	exp, err := h.exportService.Get(c.Request.Context(), user.ID, exportID)
+	if err != nil {
+		respondExportError(c, err, "Failed to get export")
+		return
+	}
+
+	resp := model.ExportResponse{Success: true, Export: *exp}
+	if exp.Status == model.ExportCompleted && exp.ExpiresAt != nil && exp.ExpiresAt.After(time.Now()) {
+		resp.DownloadURL = "/api/v1/exports/" + exp.ID.String() + "/download"
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DownloadExport handles GET /api/v1/exports/:id/download
+// The file is streamed from the store, so the server's write timeout is
+// lifted for this response.
+func (h *ExportHandler) DownloadExport(c *gin.Context) {
+	exportID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "Invalid export ID format"},
+		})
+		return
+	}
+
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "UNAUTHORIZED", Message: "User not found in context"},
+		})
+		return
+	}
+
+	exp, file, err := h.exportService.Open(c.Request.Context(), user.ID, exportID)
+	if err != nil {
+		respondExportError(c, err, "Failed to download export")
+		return
+	}
+	defer file.Close()
+
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.DataFromReader(http.StatusOK, exp.SizeBytes, export.ContentType(exp.Format), file, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="messages-%s.%s"`, exp.ID, exp.Format),
+	})
+}
+
+// respondExportError writes the error response for an export service error.
+func respondExportError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrExportNotFound):
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "NOT_FOUND", Message: "Export not found"},
+		})
+	case errors.Is(err, service.ErrExportNotReady):
+		c.JSON(http.StatusConflict, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "INVALID_STATE", Message: err.Error()},
+		})
+	case errors.Is(err, service.ErrExportExpired):
+		c.JSON(http.StatusGone, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "EXPIRED", Message: "Export has expired"},
+		})
+	default:
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg(fallback)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: fallback},
+		})
+	}
+}
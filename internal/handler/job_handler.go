@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"notification-system/internal/middleware"
+	"notification-system/internal/model"
+	"notification-system/internal/service"
+	"notification-system/pkg/logger"
+)
+
+// JobHandler handles HTTP requests for bulk send jobs.
+type JobHandler struct {
+	bulkService *service.BulkService
+}
+
+// NewJobHandler creates a new JobHandler.
+func NewJobHandler(bulkService *service.BulkService) *JobHandler {
+	return &JobHandler{bulkService: bulkService}
+}
+
+// GetJob handles GET /api/v1/jobs/:id
+// It returns the job's progress and a page of its per-message results.
+func (h *JobHandler) GetJob(c *gin.Context) {
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "Invalid job ID format"},
+		})
+		return
+	}
+
+	var query model.GetBulkJobQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+		})
+		return
+	}
+
+	// The cursor is the index of the last result on the previous page.
+	after := -1
+	if query.Cursor != "" {
+		after, err = strconv.Atoi(query.Cursor)
+		if err != nil || after < 0 {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Success: false,
+				Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "Invalid cursor"},
+			})
+			return
+		}
+	}
+
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "UNAUTHORIZED", Message: "User not found in context"},
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	job, progress, err := h.bulkService.Get(ctx, user.ID, jobID)
+	if err != nil {
+		if errors.Is(err, service.ErrBulkJobNotFound) {
+			c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Success: false,
+				Error:   model.ErrorDetail{Code: "NOT_FOUND", Message: "Job not found"},
+			})
+			return
+		}
+		logger.Ctx(ctx).Error().Err(err).Msg("failed to get bulk job")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "Failed to get job"},
+		})
+		return
+	}
+
+	// Fetch one extra item to learn whether there is another page.
+	items, err := h.bulkService.Items(ctx, jobID, model.BulkItemStatus(query.Status), after, query.Limit+1)
+	if err != nil {
+		logger.Ctx(ctx).Error().Err(err).Msg("failed to list bulk job items")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "Failed to get job"},
+		})
+		return
+	}
+
+	resp := model.BulkJobResponse{
+		Success:  true,
+		Job:      *job,
+		Progress: progress,
+	}
+	if len(items) > query.Limit {
+		items = items[:query.Limit]
+		resp.NextCursor = strconv.Itoa(items[len(items)-1].Index)
+	}
+
+	resp.Results = make([]model.BulkMessageResult, len(items))
+	for i, item := range items {
+		result := model.BulkMessageResult{Index: item.Index, Status: item.Status}
+		if item.MessageID != nil {
+			result.MessageID = item.MessageID.String()
+		}
+		if item.Error != nil {
+			result.Error = *item.Error
+		}
+		resp.Results[i] = result
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
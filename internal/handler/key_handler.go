@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"notification-system/internal/middleware"
+	"notification-system/internal/model"
+	"notification-system/internal/repository"
+	"notification-system/internal/service"
+	"notification-system/pkg/logger"
+)
+
+// KeyHandler handles HTTP requests for API key management.
+type KeyHandler struct {
+	service   *service.KeyService
+	auditRepo repository.AuditRepository
+}
+
+// NewKeyHandler creates a new KeyHandler.
+func NewKeyHandler(service *service.KeyService, auditRepo repository.AuditRepository) *KeyHandler {
+	return &KeyHandler{service: service, auditRepo: auditRepo}
+}
+
+// CreateKey handles POST /api/v1/keys
+func (h *KeyHandler) CreateKey(c *gin.Context) {
+	var req model.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+		})
+		return
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "expires_at must be in the future"},
+		})
+		return
+	}
+
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "UNAUTHORIZED", Message: "User not found in context"},
+		})
+		return
+	}
+
+	raw, key, err := h.service.Create(c.Request.Context(), user.ID, middleware.GetScopesFromContext(c), req)
+	if err != nil {
+		respondKeyError(c, err, "Failed to create API key")
+		return
+	}
+
+	recordAudit(c, h.auditRepo, model.AuditKeyCreate, model.AuditTargetAPIKey, key.ID.String(), nil, key)
+
+	c.JSON(http.StatusCreated, model.APIKeyResponse{
+		Success: true,
+		Key:     raw,
+		APIKey:  *key,
+	})
+}
+
+// ListKeys handles GET /api/v1/keys
+func (h *KeyHandler) ListKeys(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "UNAUTHORIZED", Message: "User not found in context"},
+		})
+		return
+	}
+
+	keys, err := h.service.List(c.Request.Context(), user.ID)
+	if err != nil {
+		respondKeyError(c, err, "Failed to list API keys")
+		return
+	}
+	if keys == nil {
+		keys = []model.APIKey{}
+	}
+
+	c.JSON(http.StatusOK, model.ListAPIKeysResponse{
+		Success: true,
+		Keys:    keys,
+	})
+}
+
+// RotateKey handles POST /api/v1/keys/:id/rotate
+func (h *KeyHandler) RotateKey(c *gin.Context) {
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "Invalid key ID format"},
+		})
+		return
+	}
+
+	var req model.RotateAPIKeyRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Success: false,
+				Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+			})
+			return
+		}
+	}
+
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "UNAUTHORIZED", Message: "User not found in context"},
+		})
+		return
+	}
+
+	var grace *time.Duration
+	if req.GracePeriodSeconds != nil {
+		d := time.Duration(*req.GracePeriodSeconds) * time.Second
+		grace = &d
+	}
+
+	raw, key, err := h.service.Rotate(c.Request.Context(), user.ID, keyID, middleware.GetScopesFromContext(c), grace)
+	if err != nil {
+		respondKeyError(c, err, "Failed to rotate API key")
+		return
+	}
+
+	recordAudit(c, h.auditRepo, model.AuditKeyRotate, model.AuditTargetAPIKey, keyID.String(), nil, gin.H{
+		"replacement": key,
+	})
+
+	c.JSON(http.StatusCreated, model.APIKeyResponse{
+		Success: true,
+		Key:     raw,
+		APIKey:  *key,
+	})
+}
+
+// RevokeKey handles DELETE /api/v1/keys/:id
+func (h *KeyHandler) RevokeKey(c *gin.Context) {
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "Invalid key ID format"},
+		})
+		return
+	}
+
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "UNAUTHORIZED", Message: "User not found in context"},
+		})
+		return
+	}
+
+	key, err := h.service.Revoke(c.Request.Context(), user.ID, keyID, middleware.GetScopesFromContext(c))
+	if err != nil {
+		respondKeyError(c, err, "Failed to revoke API key")
+		return
+	}
+
+	revoked := *key
+	now := time.Now()
+	revoked.RevokedAt = &now
+	recordAudit(c, h.auditRepo, model.AuditKeyRevoke, model.AuditTargetAPIKey, keyID.String(), key, revoked)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"key_id":  keyID.String(),
+		"status":  "revoked",
+	})
+}
+
+// respondKeyError maps KeyService errors to HTTP responses.
+func respondKeyError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrKeyNotFound):
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "NOT_FOUND", Message: "API key not found"},
+		})
+	case errors.Is(err, service.ErrKeyInactive):
+		c.JSON(http.StatusConflict, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "INVALID_STATE", Message: err.Error()},
+		})
+	case errors.Is(err, service.ErrUnknownScope):
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+		})
+	case errors.Is(err, service.ErrScopeNotAllowed):
+		c.JSON(http.StatusForbidden, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "FORBIDDEN", Message: err.Error()},
+		})
+	default:
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg(fallback)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: fallback},
+		})
+	}
+}
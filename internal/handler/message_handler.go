@@ -1,16 +1,25 @@
 package handler
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"math"
+	"mime/multipart"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 
+	"notification-system/internal/bulkfile"
 	"notification-system/internal/middleware"
 	"notification-system/internal/model"
 	"notification-system/internal/repository"
+	"notification-system/internal/sendwindow"
 	"notification-system/internal/service"
 	"notification-system/pkg/logger"
 )
@@ -20,7 +29,10 @@ type MessageHandler struct {
 	db            *sqlx.DB
 	messageRepo   repository.MessageRepository
 	recipientRepo repository.RecipientRepository
+	attemptRepo   repository.DeliveryAttemptRepository
+	auditRepo     repository.AuditRepository
 	service       *service.MessageService
+	bulkService   *service.BulkService
 }
 
 // NewMessageHandler creates a new MessageHandler.
@@ -28,13 +40,19 @@ func NewMessageHandler(
 	db *sqlx.DB,
 	messageRepo repository.MessageRepository,
 	recipientRepo repository.RecipientRepository,
+	attemptRepo repository.DeliveryAttemptRepository,
+	auditRepo repository.AuditRepository,
 	service *service.MessageService,
+	bulkService *service.BulkService,
 ) *MessageHandler {
 	return &MessageHandler{
 		db:            db,
 		messageRepo:   messageRepo,
 		recipientRepo: recipientRepo,
+		attemptRepo:   attemptRepo,
+		auditRepo:     auditRepo,
 		service:       service,
+		bulkService:   bulkService,
 	}
 }
 
@@ -61,9 +79,27 @@ func (h *MessageHandler) SendMessage(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.service.SendMessage(c.Request.Context(), user.ID, req)
+	if req.SendWindow == nil {
+		req.SendWindow = user.SendWindow
+	}
+
+	resp, err := h.service.SendMessage(c.Request.Context(), user.ID, apiKeyID(c), req)
 	if err != nil {
-		logger.Get().Error().Err(err).Msg("failed to process message request")
+		if errors.Is(err, service.ErrTooManySegments) {
+			c.JSON(http.StatusUnprocessableEntity, model.ErrorResponse{
+				Success: false,
+				Error:   model.ErrorDetail{Code: "MESSAGE_TOO_LONG", Message: err.Error()},
+			})
+			return
+		}
+		if errors.Is(err, sendwindow.ErrInvalidWindow) {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Success: false,
+				Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+			})
+			return
+		}
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to process message request")
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
 			Success: false,
 			Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "Failed to process request"},
@@ -71,6 +107,8 @@ func (h *MessageHandler) SendMessage(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, h.auditRepo, model.AuditMessageSend, model.AuditTargetMessage, resp.MessageID, nil, resp)
+
 	c.JSON(http.StatusCreated, resp)
 }
 
@@ -86,26 +124,35 @@ func (h *MessageHandler) GetMessageStatus(c *gin.Context) {
 		return
 	}
 
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "UNAUTHORIZED", Message: "User not found in context"},
+		})
+		return
+	}
+
 	msg, err := h.messageRepo.GetByID(c.Request.Context(), msgID)
-	if err != nil {
-		if err == repository.ErrNotFound {
-			c.JSON(http.StatusNotFound, model.ErrorResponse{
-				Success: false,
-				Error:   model.ErrorDetail{Code: "NOT_FOUND", Message: "Message not found"},
-			})
-			return
-		}
-		logger.Get().Error().Err(err).Msg("failed to get message")
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to get message")
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
 			Success: false,
 			Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "Failed to get message"},
 		})
 		return
 	}
+	if err != nil || msg.UserID != user.ID {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "NOT_FOUND", Message: "Message not found"},
+		})
+		return
+	}
 
 	recipients, err := h.recipientRepo.GetByMessageID(c.Request.Context(), msgID)
 	if err != nil {
-		logger.Get().Error().Err(err).Msg("failed to get recipients")
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to get recipients")
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
 			Success: false,
 			Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "Failed to get recipients"},
@@ -117,26 +164,18 @@ func (h *MessageHandler) GetMessageStatus(c *gin.Context) {
 	var summary model.DeliverySummary
 	recipientStatuses := make([]model.RecipientStatus, len(recipients))
 	for i, r := range recipients {
-		switch r.Status {
-		case model.StatusQueued:
-			summary.Queued++
-		case model.StatusProcessing:
-			summary.Processing++
-		case model.StatusSent:
-			summary.Sent++
-		case model.StatusDelivered:
-			summary.Delivered++
-		case model.StatusFailed:
-			summary.Failed++
-		case model.StatusPending:
-			summary.Pending++
-		}
+		summary.Add(r.Status, 1)
 
 		recipientStatuses[i] = model.RecipientStatus{
-			Recipient:   r.Recipient,
-			Status:      int(r.Status),
-			SentAt:      r.SentAt,
-			DeliveredAt: r.DeliveredAt,
+			ID:            r.ID.String(),
+			Recipient:     r.Recipient,
+			Status:        int(r.Status),
+			ProviderID:    r.ProviderID,
+			ErrorMessage:  r.ErrorMessage,
+			RetryCount:    r.RetryCount,
+			SentAt:        r.SentAt,
+			DeliveredAt:   r.DeliveredAt,
+			DeferredUntil: r.DeferredUntil,
 		}
 	}
 
@@ -155,6 +194,90 @@ func (h *MessageHandler) GetMessageStatus(c *gin.Context) {
 	})
 }
 
+// GetRecipient handles GET /api/v1/messages/:id/recipients/:rid
+func (h *MessageHandler) GetRecipient(c *gin.Context) {
+	msgID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "Invalid message ID format"},
+		})
+		return
+	}
+	recipientID, err := uuid.Parse(c.Param("rid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "Invalid recipient ID format"},
+		})
+		return
+	}
+
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "UNAUTHORIZED", Message: "User not found in context"},
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	msg, err := h.messageRepo.GetByID(ctx, msgID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		logger.Ctx(ctx).Error().Err(err).Msg("failed to get message")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "Failed to get message"},
+		})
+		return
+	}
+	if err != nil || msg.UserID != user.ID {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "NOT_FOUND", Message: "Message not found"},
+		})
+		return
+	}
+
+	recipient, err := h.recipientRepo.GetByID(ctx, recipientID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		logger.Ctx(ctx).Error().Err(err).Msg("failed to get recipient")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "Failed to get recipient"},
+		})
+		return
+	}
+	if err != nil || recipient.MessageID != msgID {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "NOT_FOUND", Message: "Recipient not found"},
+		})
+		return
+	}
+
+	attempts, err := h.attemptRepo.ListByRecipientID(ctx, recipientID)
+	if err != nil {
+		logger.Ctx(ctx).Error().Err(err).Msg("failed to list delivery attempts")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "Failed to get recipient"},
+		})
+		return
+	}
+	if attempts == nil {
+		attempts = []model.DeliveryAttempt{}
+	}
+
+	c.JSON(http.StatusOK, model.RecipientDetailResponse{
+		Success:   true,
+		Recipient: *recipient,
+		Attempts:  attempts,
+	})
+}
+
 // ListMessages handles GET /api/v1/messages
 func (h *MessageHandler) ListMessages(c *gin.Context) {
 	var query model.ListMessagesQuery
@@ -166,14 +289,47 @@ func (h *MessageHandler) ListMessages(c *gin.Context) {
 		return
 	}
 
-	// Defaults
-	if query.Page == 0 {
-		query.Page = 1
+	var withSummary, withTotal bool
+	for _, part := range strings.Split(query.Include, ",") {
+		switch strings.TrimSpace(part) {
+		case "":
+		case "summary":
+			withSummary = true
+		case "total":
+			withTotal = true
+		default:
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Success: false,
+				Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: fmt.Sprintf("Unknown include %q", part)},
+			})
+			return
+		}
 	}
-	if query.Limit == 0 {
-		query.Limit = 20
+
+	var after *repository.Cursor
+	if query.Cursor != "" {
+		if query.Page != 0 {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Success: false,
+				Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "cursor and page cannot be combined"},
+			})
+			return
+		}
+		cur, err := repository.DecodeCursor(query.Cursor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Success: false,
+				Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "Invalid cursor"},
+			})
+			return
+		}
+		after = &cur
 	}
 
+	// Offset pagination always reports the total, as it did before cursors.
+	legacy := query.Page != 0
+	withTotal = withTotal || legacy
+
 	user := middleware.GetUserFromContext(c)
 	if user == nil {
 		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
@@ -183,9 +339,10 @@ func (h *MessageHandler) ListMessages(c *gin.Context) {
 		return
 	}
 
-	messages, total, err := h.messageRepo.List(c.Request.Context(), user.ID, query)
+	ctx := c.Request.Context()
+	messages, next, err := h.messageRepo.List(ctx, user.ID, query, after)
 	if err != nil {
-		logger.Get().Error().Err(err).Msg("failed to list messages")
+		logger.Ctx(ctx).Error().Err(err).Msg("failed to list messages")
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
 			Success: false,
 			Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "Failed to list messages"},
@@ -193,21 +350,64 @@ func (h *MessageHandler) ListMessages(c *gin.Context) {
 		return
 	}
 
-	totalPages := int(math.Ceil(float64(total) / float64(query.Limit)))
+	items := make([]model.MessageListItem, len(messages))
+	ids := make([]uuid.UUID, len(messages))
+	for i, msg := range messages {
+		items[i] = model.MessageListItem{Message: msg}
+		ids[i] = msg.ID
+	}
 
-	c.JSON(http.StatusOK, model.ListMessagesResponse{
-		Success:  true,
-		Messages: messages,
-		Pagination: model.Pagination{
-			Page:       query.Page,
-			Limit:      query.Limit,
-			Total:      total,
-			TotalPages: totalPages,
-		},
-	})
+	if withSummary {
+		summaries, err := h.recipientRepo.SummarizeByMessageIDs(ctx, ids)
+		if err != nil {
+			logger.Ctx(ctx).Error().Err(err).Msg("failed to summarize messages")
+			c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Success: false,
+				Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "Failed to list messages"},
+			})
+			return
+		}
+		for i := range items {
+			if summary, ok := summaries[items[i].ID]; ok {
+				items[i].Summary = summary
+			} else {
+				items[i].Summary = &model.DeliverySummary{}
+			}
+		}
+	}
+
+	resp := model.ListMessagesResponse{Success: true, Messages: items}
+	if next != nil {
+		resp.NextCursor = next.Encode()
+	}
+
+	if withTotal {
+		total, err := h.messageRepo.Count(ctx, user.ID, query)
+		if err != nil {
+			logger.Ctx(ctx).Error().Err(err).Msg("failed to count messages")
+			c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Success: false,
+				Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "Failed to list messages"},
+			})
+			return
+		}
+		resp.Total = &total
+		if legacy {
+			resp.Pagination = &model.Pagination{
+				Page:       query.Page,
+				Limit:      query.Limit,
+				Total:      total,
+				TotalPages: int(math.Ceil(float64(total) / float64(query.Limit))),
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
 }
 
 // BulkSend handles POST /api/v1/messages/bulk
+// The messages are accepted as a job and sent in the background; progress is
+// available from GET /api/v1/jobs/:id.
 func (h *MessageHandler) BulkSend(c *gin.Context) {
 	var req model.BulkMessageRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -227,32 +427,232 @@ func (h *MessageHandler) BulkSend(c *gin.Context) {
 		return
 	}
 
-	var results []model.BulkMessageResult
-	for i, msg := range req.Messages {
-		resp, err := h.service.SendMessage(c.Request.Context(), user.ID, msg)
+	// Resolve the user's default window now, so a later change to it doesn't
+	// affect a job that was already accepted.
+	for i := range req.Messages {
+		if req.Messages[i].SendWindow == nil {
+			req.Messages[i].SendWindow = user.SendWindow
+		}
+	}
+
+	job, err := h.bulkService.Submit(c.Request.Context(), user.ID, apiKeyID(c), req.Messages)
+	if err != nil {
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to accept bulk job")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "Failed to process request"},
+		})
+		return
+	}
+
+	resp := model.BulkJobAcceptedResponse{
+		Success: true,
+		JobID:   job.ID.String(),
+		Status:  job.Status,
+		Total:   job.Total,
+	}
+	recordAudit(c, h.auditRepo, model.AuditMessageBulkSend, model.AuditTargetBulkJob, resp.JobID, nil, resp)
+
+	c.Header("Location", "/api/v1/jobs/"+resp.JobID)
+	c.JSON(http.StatusAccepted, resp)
+}
+
+// maxUploadFieldBytes bounds each form field of a bulk upload.
+const maxUploadFieldBytes = 64 << 10
+
+// BulkUpload handles POST /api/v1/messages/bulk/upload
+// The file is read as it arrives rather than buffered, so the form fields
+// must come before it. Each valid row becomes one message of a bulk job, as
+// with BulkSend.
+func (h *MessageHandler) BulkUpload(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "UNAUTHORIZED", Message: "User not found in context"},
+		})
+		return
+	}
+
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "Request must be multipart/form-data"},
+		})
+		return
+	}
+
+	fields := make(map[string][]string)
+	var file *multipart.Part
+	for file == nil {
+		part, err := mr.NextPart()
 		if err != nil {
-			logger.Get().Error().Err(err).Int("index", i).Msg("bulk: failed to send message")
-			results = append(results, model.BulkMessageResult{
-				Index:   i,
+			msg := err.Error()
+			if errors.Is(err, io.EOF) {
+				msg = "Missing file part"
+			}
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
 				Success: false,
-				Error:   err.Error(),
+				Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: msg},
 			})
-			continue
+			return
 		}
-		results = append(results, model.BulkMessageResult{
-			Index:     i,
-			Success:   true,
-			MessageID: resp.MessageID,
+
+		name := part.FormName()
+		if name == "file" {
+			file = part
+			break
+		}
+
+		value, err := io.ReadAll(io.LimitReader(part, maxUploadFieldBytes+1))
+		if err == nil && len(value) > maxUploadFieldBytes {
+			err = fmt.Errorf("field %q is too long", name)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Success: false,
+				Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+			})
+			return
+		}
+		fields[name] = append(fields[name], string(value))
+	}
+
+	var req model.BulkUploadRequest
+	err = binding.MapFormWithTag(&req, fields, "form")
+	if err == nil {
+		err = binding.Validator.ValidateStruct(&req)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
 		})
+		return
 	}
 
-	c.JSON(http.StatusCreated, model.BulkMessageResponse{
-		Success:    true,
-		Total:      len(req.Messages),
-		Successful: countSuccessful(results),
-		Failed:     len(req.Messages) - countSuccessful(results),
-		Results:    results,
-	})
+	var mapping bulkfile.Mapping
+	if err := json.Unmarshal([]byte(req.Mapping), &mapping); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "Invalid mapping: " + err.Error()},
+		})
+		return
+	}
+
+	subject, err := bulkfile.ParseTemplate(req.Subject, mapping.Variables)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "Invalid subject: " + err.Error()},
+		})
+		return
+	}
+	body, err := bulkfile.ParseTemplate(req.Message, mapping.Variables)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "Invalid message: " + err.Error()},
+		})
+		return
+	}
+
+	format := bulkfile.Format(req.Format)
+	if format == "" {
+		format = bulkfile.DetectFormat(file.FileName(), file.Header.Get("Content-Type"))
+	}
+	if format == "" {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "Unknown file format; set format to csv or ndjson"},
+		})
+		return
+	}
+
+	rows, err := bulkfile.NewReader(file, format, mapping)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+		})
+		return
+	}
+
+	next := func() (service.UploadRow, error) {
+		row, err := rows.Next()
+		var rowErr *bulkfile.RowError
+		if errors.As(err, &rowErr) {
+			return service.UploadRow{Line: rowErr.Line, Err: rowErr.Err}, nil
+		}
+		if err != nil {
+			return service.UploadRow{}, err
+		}
+
+		msg := model.CreateMessageRequest{
+			Subject:     subject.Render(row.Vars),
+			Message:     body.Render(row.Vars),
+			From:        req.From,
+			To:          []string{row.Recipient},
+			Platform:    req.Platform,
+			Priority:    req.Priority,
+			ScheduledAt: req.ScheduledAt,
+			SendWindow:  user.SendWindow,
+		}
+		return service.UploadRow{Line: row.Line, Request: msg, Err: binding.Validator.ValidateStruct(&msg)}, nil
+	}
+
+	result, err := h.bulkService.SubmitRows(c.Request.Context(), user.ID, apiKeyID(c), next, req.Partial)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidRows):
+			msg := fmt.Sprintf("%d rows are invalid", result.Rejected)
+			if result.Rejected == 0 {
+				msg = "File has no rows"
+			}
+			lines := make(map[string]string, len(result.Errors))
+			for _, e := range result.Errors {
+				lines[fmt.Sprintf("line %d", e.Line)] = e.Error
+			}
+			c.JSON(http.StatusUnprocessableEntity, model.ErrorResponse{
+				Success: false,
+				Error:   model.ErrorDetail{Code: "INVALID_ROWS", Message: msg, Fields: lines},
+			})
+		case errors.Is(err, bulkfile.ErrInvalidFile):
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Success: false,
+				Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+			})
+		case errors.Is(err, service.ErrTooManyRows):
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Success: false,
+				Error: model.ErrorDetail{
+					Code:    "VALIDATION_ERROR",
+					Message: fmt.Sprintf("File has more than %d rows", service.MaxUploadRows),
+				},
+			})
+		default:
+			logger.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to accept bulk upload")
+			c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Success: false,
+				Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "Failed to process request"},
+			})
+		}
+		return
+	}
+
+	resp := model.BulkUploadResponse{
+		Success:  true,
+		JobID:    result.Job.ID.String(),
+		Status:   result.Job.Status,
+		Total:    result.Job.Total,
+		Rejected: result.Rejected,
+		Errors:   result.Errors,
+	}
+	recordAudit(c, h.auditRepo, model.AuditMessageBulkSend, model.AuditTargetBulkJob, resp.JobID, nil, resp)
+
+	c.Header("Location", "/api/v1/jobs/"+resp.JobID)
+	c.JSON(http.StatusAccepted, resp)
 }
 
 // CancelMessage handles DELETE /api/v1/messages/:id
@@ -267,53 +667,104 @@ func (h *MessageHandler) CancelMessage(c *gin.Context) {
 		return
 	}
 
-	msg, err := h.messageRepo.GetByID(c.Request.Context(), msgID)
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "UNAUTHORIZED", Message: "User not found in context"},
+		})
+		return
+	}
+
+	msg, resp, err := h.service.Cancel(c.Request.Context(), user.ID, msgID)
 	if err != nil {
-		if err == repository.ErrNotFound {
-			c.JSON(http.StatusNotFound, model.ErrorResponse{
-				Success: false,
-				Error:   model.ErrorDetail{Code: "NOT_FOUND", Message: "Message not found"},
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+		respondMessageError(c, err, "Failed to cancel message")
+		return
+	}
+
+	cancelled := *msg
+	cancelled.Status = model.StatusCancelled
+	recordAudit(c, h.auditRepo, model.AuditMessageCancel, model.AuditTargetMessage, msgID.String(), msg, cancelled)
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// UpdateMessage handles PATCH /api/v1/messages/:id
+func (h *MessageHandler) UpdateMessage(c *gin.Context) {
+	msgID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
 			Success: false,
-			Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "Failed to get message"},
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "Invalid message ID format"},
 		})
 		return
 	}
 
-	// Only scheduled messages can be cancelled
-	if msg.Status != model.StatusScheduled {
-		c.JSON(http.StatusConflict, model.ErrorResponse{
+	var req model.UpdateMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
 			Success: false,
-			Error:   model.ErrorDetail{Code: "INVALID_STATE", Message: "Only scheduled messages can be cancelled"},
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
 		})
 		return
 	}
 
-	if err := h.messageRepo.UpdateStatus(c.Request.Context(), msgID, model.StatusCancelled); err != nil {
-		logger.Get().Error().Err(err).Msg("failed to cancel message")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
 			Success: false,
-			Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "Failed to cancel message"},
+			Error:   model.ErrorDetail{Code: "UNAUTHORIZED", Message: "User not found in context"},
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success":    true,
-		"message_id": msgID.String(),
-		"status":     "cancelled",
-	})
+	before, after, err := h.service.UpdateScheduled(c.Request.Context(), user.ID, msgID, req)
+	if err != nil {
+		respondMessageError(c, err, "Failed to update message")
+		return
+	}
+
+	recordAudit(c, h.auditRepo, model.AuditMessageUpdate, model.AuditTargetMessage, msgID.String(), before, after)
+
+	c.JSON(http.StatusOK, model.MessageResponse{Success: true, Message: *after})
 }
 
-func countSuccessful(results []model.BulkMessageResult) int {
-	count := 0
-	for _, r := range results {
-		if r.Success {
-			count++
-		}
+// respondMessageError maps MessageService errors to HTTP responses.
+func respondMessageError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrMessageNotFound):
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "NOT_FOUND", Message: "Message not found"},
+		})
+	case errors.Is(err, service.ErrMessageState):
+		c.JSON(http.StatusConflict, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "INVALID_STATE", Message: err.Error()},
+		})
+	case errors.Is(err, service.ErrInvalidMessage), errors.Is(err, sendwindow.ErrInvalidWindow):
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+		})
+	case errors.Is(err, service.ErrTooManySegments):
+		c.JSON(http.StatusUnprocessableEntity, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "MESSAGE_TOO_LONG", Message: err.Error()},
+		})
+	default:
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg(fallback)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: fallback},
+		})
+	}
+}
+
+// apiKeyID returns the ID of the API key the request was authenticated with, if any.
+func apiKeyID(c *gin.Context) *uuid.UUID {
+	if key := middleware.GetAPIKeyFromContext(c); key != nil {
+		return &key.ID
 	}
-	return count
+	return nil
 }
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"notification-system/internal/middleware"
+	"notification-system/internal/model"
+	"notification-system/internal/repository"
+	"notification-system/pkg/logger"
+)
+
+// RecipientHandler handles HTTP requests for recipient delivery history.
+type RecipientHandler struct {
+	recipientRepo repository.RecipientRepository
+}
+
+// NewRecipientHandler creates a new RecipientHandler.
+func NewRecipientHandler(recipientRepo repository.RecipientRepository) *RecipientHandler {
+	return &RecipientHandler{recipientRepo: recipientRepo}
+}
+
+// ListRecipients handles GET /api/v1/recipients
+// It returns every delivery to an address across the caller's messages.
+func (h *RecipientHandler) ListRecipients(c *gin.Context) {
+	var query model.ListRecipientsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+		})
+		return
+	}
+
+	address := strings.TrimSpace(query.Address)
+	if address == "" {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "address is required"},
+		})
+		return
+	}
+
+	var after *repository.Cursor
+	if query.Cursor != "" {
+		cur, err := repository.DecodeCursor(query.Cursor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Success: false,
+				Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "Invalid cursor"},
+			})
+			return
+		}
+		after = &cur
+	}
+
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "UNAUTHORIZED", Message: "User not found in context"},
+		})
+		return
+	}
+
+	entries, next, err := h.recipientRepo.ListByAddress(c.Request.Context(), user.ID, address, query.Limit, after)
+	if err != nil {
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to list recipient history")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "Failed to list recipient history"},
+		})
+		return
+	}
+	if entries == nil {
+		entries = []model.RecipientHistoryEntry{}
+	}
+
+	resp := model.ListRecipientsResponse{Success: true, Recipients: entries}
+	if next != nil {
+		resp.NextCursor = next.Encode()
+	}
+	c.JSON(http.StatusOK, resp)
+}
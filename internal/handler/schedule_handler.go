@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"notification-system/internal/middleware"
+	"notification-system/internal/model"
+	"notification-system/internal/repository"
+	"notification-system/internal/sendwindow"
+	"notification-system/internal/service"
+	"notification-system/pkg/logger"
+)
+
+// ScheduleHandler handles HTTP requests for recurring schedules.
+type ScheduleHandler struct {
+	service   *service.ScheduleService
+	auditRepo repository.AuditRepository
+}
+
+// NewScheduleHandler creates a new ScheduleHandler.
+func NewScheduleHandler(service *service.ScheduleService, auditRepo repository.AuditRepository) *ScheduleHandler {
+	return &ScheduleHandler{service: service, auditRepo: auditRepo}
+}
+
+// CreateSchedule handles POST /api/v1/schedules
+func (h *ScheduleHandler) CreateSchedule(c *gin.Context) {
+	var req model.CreateScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+		})
+		return
+	}
+
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "UNAUTHORIZED", Message: "User not found in context"},
+		})
+		return
+	}
+
+	if req.SendWindow == nil {
+		req.SendWindow = user.SendWindow
+	}
+
+	sched, err := h.service.Create(c.Request.Context(), user.ID, apiKeyID(c), req)
+	if err != nil {
+		respondScheduleError(c, err, "Failed to create schedule")
+		return
+	}
+
+	recordAudit(c, h.auditRepo, model.AuditScheduleCreate, model.AuditTargetSchedule, sched.ID.String(), nil, sched)
+
+	c.JSON(http.StatusCreated, model.ScheduleResponse{Success: true, Schedule: *sched})
+}
+
+// ListSchedules handles GET /api/v1/schedules
+func (h *ScheduleHandler) ListSchedules(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "UNAUTHORIZED", Message: "User not found in context"},
+		})
+		return
+	}
+
+	schedules, err := h.service.List(c.Request.Context(), user.ID)
+	if err != nil {
+		respondScheduleError(c, err, "Failed to list schedules")
+		return
+	}
+	if schedules == nil {
+		schedules = []model.Schedule{}
+	}
+
+	c.JSON(http.StatusOK, model.ListSchedulesResponse{Success: true, Schedules: schedules})
+}
+
+// GetSchedule handles GET /api/v1/schedules/:id
+func (h *ScheduleHandler) GetSchedule(c *gin.Context) {
+	user, id, ok := scheduleRequest(c)
+	if !ok {
+		return
+	}
+
+	sched, err := h.service.Get(c.Request.Context(), user.ID, id)
+	if err != nil {
+		respondScheduleError(c, err, "Failed to get schedule")
+		return
+	}
+
+	c.JSON(http.StatusOK, model.ScheduleResponse{Success: true, Schedule: *sched})
+}
+
+// PauseSchedule handles POST /api/v1/schedules/:id/pause
+func (h *ScheduleHandler) PauseSchedule(c *gin.Context) {
+	user, id, ok := scheduleRequest(c)
+	if !ok {
+		return
+	}
+
+	before, err := h.service.Get(c.Request.Context(), user.ID, id)
+	if err != nil {
+		respondScheduleError(c, err, "Failed to pause schedule")
+		return
+	}
+
+	sched, err := h.service.Pause(c.Request.Context(), user.ID, id)
+	if err != nil {
+		respondScheduleError(c, err, "Failed to pause schedule")
+		return
+	}
+
+	recordAudit(c, h.auditRepo, model.AuditSchedulePause, model.AuditTargetSchedule, id.String(), before, sched)
+
+	c.JSON(http.StatusOK, model.ScheduleResponse{Success: true, Schedule: *sched})
+}
+
+// ResumeSchedule handles POST /api/v1/schedules/:id/resume
+func (h *ScheduleHandler) ResumeSchedule(c *gin.Context) {
+	user, id, ok := scheduleRequest(c)
+	if !ok {
+		return
+	}
+
+	before, err := h.service.Get(c.Request.Context(), user.ID, id)
+	if err != nil {
+		respondScheduleError(c, err, "Failed to resume schedule")
+		return
+	}
+
+	sched, err := h.service.Resume(c.Request.Context(), user.ID, id)
+	if err != nil {
+		respondScheduleError(c, err, "Failed to resume schedule")
+		return
+	}
+
+	recordAudit(c, h.auditRepo, model.AuditScheduleResume, model.AuditTargetSchedule, id.String(), before, sched)
+
+	c.JSON(http.StatusOK, model.ScheduleResponse{Success: true, Schedule: *sched})
+}
+
+// DeleteSchedule handles DELETE /api/v1/schedules/:id
+func (h *ScheduleHandler) DeleteSchedule(c *gin.Context) {
+	user, id, ok := scheduleRequest(c)
+	if !ok {
+		return
+	}
+
+	sched, err := h.service.Delete(c.Request.Context(), user.ID, id)
+	if err != nil {
+		respondScheduleError(c, err, "Failed to delete schedule")
+		return
+	}
+
+	recordAudit(c, h.auditRepo, model.AuditScheduleDelete, model.AuditTargetSchedule, id.String(), sched, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"schedule_id": id.String(),
+		"status":      "deleted",
+	})
+}
+
+// scheduleRequest extracts the authenticated user and schedule ID, writing
+// an error response and returning false if either is missing.
+func scheduleRequest(c *gin.Context) (*model.User, uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "Invalid schedule ID format"},
+		})
+		return nil, uuid.Nil, false
+	}
+
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "UNAUTHORIZED", Message: "User not found in context"},
+		})
+		return nil, uuid.Nil, false
+	}
+
+	return user, id, true
+}
+
+// respondScheduleError maps ScheduleService errors to HTTP responses.
+func respondScheduleError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrScheduleNotFound):
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "NOT_FOUND", Message: "Schedule not found"},
+		})
+	case errors.Is(err, service.ErrInvalidSchedule), errors.Is(err, sendwindow.ErrInvalidWindow):
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+		})
+	case errors.Is(err, service.ErrTooManySegments):
+		c.JSON(http.StatusUnprocessableEntity, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "MESSAGE_TOO_LONG", Message: err.Error()},
+		})
+	case errors.Is(err, service.ErrScheduleState):
+		c.JSON(http.StatusConflict, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "INVALID_STATE", Message: err.Error()},
+		})
+	default:
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg(fallback)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: fallback},
+		})
+	}
+}
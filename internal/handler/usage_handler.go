@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"notification-system/internal/middleware"
+	"notification-system/internal/model"
+	"notification-system/internal/repository"
+	"notification-system/pkg/logger"
+)
+
+// defaultUsageReportWindow is the report range used when no "from" is given.
+const defaultUsageReportWindow = 30 * 24 * time.Hour
+
+// UsageHandler handles HTTP requests for usage reporting.
+type UsageHandler struct {
+	usageRepo repository.UsageRepository
+}
+
+// NewUsageHandler creates a new UsageHandler.
+func NewUsageHandler(usageRepo repository.UsageRepository) *UsageHandler {
+	return &UsageHandler{usageRepo: usageRepo}
+}
+
+// Report handles GET /api/v1/usage/report
+// Admins see usage for every user; everyone else sees only their own.
+func (h *UsageHandler) Report(c *gin.Context) {
+	var query model.UsageReportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+		})
+		return
+	}
+
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "UNAUTHORIZED", Message: "User not found in context"},
+		})
+		return
+	}
+
+	filter := model.UsageReportFilter{
+		To:       time.Now().UTC(),
+		Platform: query.Platform,
+	}
+	if query.To != nil {
+		filter.To = *query.To
+	}
+	filter.From = filter.To.Add(-defaultUsageReportWindow)
+	if query.From != nil {
+		filter.From = *query.From
+	}
+	if !filter.From.Before(filter.To) {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "from must be before to"},
+		})
+		return
+	}
+	if !middleware.IsAdmin(c) {
+		filter.UserID = &user.ID
+	}
+
+	rows, err := h.usageRepo.Report(c.Request.Context(), filter)
+	if err != nil {
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to build usage report")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "Failed to build usage report"},
+		})
+		return
+	}
+
+	if query.Format == "csv" {
+		writeUsageCSV(c, filter, rows)
+		return
+	}
+
+	if rows == nil {
+		rows = []model.UsageReportRow{}
+	}
+
+	c.JSON(http.StatusOK, model.UsageReportResponse{
+		Success: true,
+		From:    filter.From,
+		To:      filter.To,
+		Rows:    rows,
+	})
+}
+
+// writeUsageCSV streams the usage report as a CSV attachment.
+func writeUsageCSV(c *gin.Context, filter model.UsageReportFilter, rows []model.UsageReportRow) {
+	filename := fmt.Sprintf("usage-%s-%s.csv", filter.From.Format("20060102"), filter.To.Format("20060102"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"day", "platform", "user_id", "email", "api_key_id", "api_key_name", "api_key_prefix", "attempts", "successful", "failed", "segments", "estimated_cost", "currency"})
+	for _, r := range rows {
+		w.Write([]string{
+			r.Day,
+			r.Platform,
+			r.UserID.String(),
+			r.Email,
+			uuidOrEmpty(r.APIKeyID),
+			stringOrEmpty(r.APIKeyName),
+			stringOrEmpty(r.APIKeyPrefix),
+			strconv.Itoa(r.Attempts),
+			strconv.Itoa(r.Successful),
+			strconv.Itoa(r.Failed),
+			strconv.Itoa(r.Segments),
+			strconv.FormatFloat(r.EstimatedCost, 'f', 6, 64),
+			r.Currency,
+		})
+	}
+	w.Flush()
+
+	if err := w.Error(); err != nil {
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to write usage report csv")
+	}
+}
+
+func uuidOrEmpty(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
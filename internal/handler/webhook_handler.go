@@ -45,7 +45,7 @@ func (h *WebhookHandler) TwilioWebhook(c *gin.Context) {
 	internalStatus, ok := twilioStatusMap[strings.ToLower(status)]
 	if !ok {
 		// Unknown status — acknowledge but do nothing
-		logger.Get().Warn().Str("status", status).Str("sid", sid).Msg("unknown twilio status, ignoring")
+		logger.Ctx(c.Request.Context()).Warn().Str("status", status).Str("sid", sid).Msg("unknown twilio status, ignoring")
 		c.Status(http.StatusOK)
 		return
 	}
@@ -53,22 +53,22 @@ func (h *WebhookHandler) TwilioWebhook(c *gin.Context) {
 	recipient, err := h.recipientRepo.GetByProviderID(c.Request.Context(), sid)
 	if err != nil {
 		if err == repository.ErrNotFound {
-			logger.Get().Warn().Str("sid", sid).Msg("twilio webhook: recipient not found for SID")
+			logger.Ctx(c.Request.Context()).Warn().Str("sid", sid).Msg("twilio webhook: recipient not found for SID")
 			c.Status(http.StatusOK) // ack to prevent retries
 			return
 		}
-		logger.Get().Error().Err(err).Str("sid", sid).Msg("twilio webhook: failed to look up recipient")
+		logger.Ctx(c.Request.Context()).Error().Err(err).Str("sid", sid).Msg("twilio webhook: failed to look up recipient")
 		c.Status(http.StatusInternalServerError)
 		return
 	}
 
 	if err := h.recipientRepo.UpdateStatus(c.Request.Context(), recipient.ID, internalStatus, &sid); err != nil {
-		logger.Get().Error().Err(err).Str("sid", sid).Msg("twilio webhook: failed to update recipient status")
+		logger.Ctx(c.Request.Context()).Error().Err(err).Str("sid", sid).Msg("twilio webhook: failed to update recipient status")
 		c.Status(http.StatusInternalServerError)
 		return
 	}
 
-	logger.Get().Info().
+	logger.Ctx(c.Request.Context()).Info().
 		Str("sid", sid).
 		Str("status", status).
 		Str("recipient_id", recipient.ID.String()).
@@ -100,7 +100,7 @@ var sendGridEventMap = map[string]model.MessageStatus{
 func (h *WebhookHandler) SendGridWebhook(c *gin.Context) {
 	var events []sendGridEvent
 	if err := c.ShouldBindJSON(&events); err != nil {
-		logger.Get().Error().Err(err).Msg("sendgrid webhook: failed to parse payload")
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg("sendgrid webhook: failed to parse payload")
 		c.Status(http.StatusBadRequest)
 		return
 	}
@@ -118,19 +118,19 @@ func (h *WebhookHandler) SendGridWebhook(c *gin.Context) {
 		recipient, err := h.recipientRepo.GetByProviderID(c.Request.Context(), msgID)
 		if err != nil {
 			if err == repository.ErrNotFound {
-				logger.Get().Warn().Str("sg_message_id", msgID).Msg("sendgrid webhook: recipient not found")
+				logger.Ctx(c.Request.Context()).Warn().Str("sg_message_id", msgID).Msg("sendgrid webhook: recipient not found")
 				continue
 			}
-			logger.Get().Error().Err(err).Str("sg_message_id", msgID).Msg("sendgrid webhook: lookup failed")
+			logger.Ctx(c.Request.Context()).Error().Err(err).Str("sg_message_id", msgID).Msg("sendgrid webhook: lookup failed")
 			continue
 		}
 
 		if err := h.recipientRepo.UpdateStatus(c.Request.Context(), recipient.ID, internalStatus, &msgID); err != nil {
-			logger.Get().Error().Err(err).Str("sg_message_id", msgID).Msg("sendgrid webhook: failed to update status")
+			logger.Ctx(c.Request.Context()).Error().Err(err).Str("sg_message_id", msgID).Msg("sendgrid webhook: failed to update status")
 			continue
 		}
 
-		logger.Get().Info().
+		logger.Ctx(c.Request.Context()).Info().
 			Str("sg_message_id", msgID).
 			Str("event", evt.Event).
 			Str("recipient_id", recipient.ID.String()).
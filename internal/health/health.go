@@ -0,0 +1,164 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Status values reported by checks and by the overall report.
+const (
+	StatusOK          = "ok"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+	StatusDraining    = "draining"
+	StatusFail        = "fail"
+)
+
+// Check reports whether a dependency is usable. It must honour ctx's deadline.
+type Check func(ctx context.Context) error
+
+// Result is the outcome of a single check.
+type Result struct {
+	Status     string  `json:"status"`
+	Optional   bool    `json:"optional,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// Report is the body returned by the readiness endpoint.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs dependency checks concurrently, each bounded by a timeout.
+// A failing check makes the service unavailable unless its dependency is
+// optional, in which case the service is reported as degraded but ready.
+type Checker struct {
+	timeout  time.Duration
+	optional map[string]bool
+	checks   []namedCheck
+	draining atomic.Bool
+}
+
+// NewChecker creates a Checker. Dependencies named in optional only degrade
+// readiness when they fail.
+func NewChecker(timeout time.Duration, optional []string) *Checker {
+	opt := make(map[string]bool, len(optional))
+	for _, name := range optional {
+		opt[name] = true
+	}
+	return &Checker{timeout: timeout, optional: opt}
+}
+
+// Add registers a check under name.
+func (c *Checker) Add(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Drain marks the service as shutting down. Readiness fails from then on so
+// load balancers stop sending new work while in-flight work completes.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Run executes all checks and aggregates their results.
+func (c *Checker) Run(ctx context.Context) Report {
+	results := make([]Result, len(c.checks))
+
+	var wg sync.WaitGroup
+	for i, nc := range c.checks {
+		wg.Add(1)
+		go func(i int, nc namedCheck) {
+			defer wg.Done()
+			results[i] = c.run(ctx, nc)
+		}(i, nc)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(c.checks))}
+	for i, nc := range c.checks {
+		res := results[i]
+		report.Checks[nc.name] = res
+		if res.Status == StatusOK {
+			continue
+		}
+		if res.Optional {
+			if report.Status == StatusOK {
+				report.Status = StatusDegraded
+			}
+		} else {
+			report.Status = StatusUnavailable
+		}
+	}
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, nc namedCheck) Result {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := nc.check(ctx)
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+
+	res := Result{
+		Status:     StatusOK,
+		Optional:   c.optional[nc.name],
+		DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// ReadyHandler serves the readiness report: 200 when ok or degraded, 503
+// when a required dependency is failing or the service is draining.
+func (c *Checker) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.draining.Load() {
+			writeJSON(w, http.StatusServiceUnavailable, Report{Status: StatusDraining, Checks: map[string]Result{}})
+			return
+		}
+
+		report := c.Run(r.Context())
+
+		code := http.StatusOK
+		if report.Status == StatusUnavailable {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, report)
+	})
+}
+
+// LiveHandler reports that the process is up. It checks no dependencies, so
+// an outage never causes the orchestrator to restart healthy pods.
+func LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": StatusOK})
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
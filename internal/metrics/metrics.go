@@ -41,4 +41,91 @@ var (
 		},
 		[]string{"platform", "result"},
 	)
+
+	// ProviderSendDuration observes how long each provider call takes.
+	ProviderSendDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "provider_send_duration_seconds",
+			Help:    "Provider send latency in seconds.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"platform", "provider", "result"},
+	)
+
+	// MessageQueueLatency observes the time from a recipient being queued by
+	// the API to the provider accepting it.
+	MessageQueueLatency = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "message_queued_to_sent_seconds",
+			Help:    "Time from a message being queued to the provider accepting it, in seconds.",
+			Buckets: prometheus.ExponentialBuckets(0.05, 2, 14),
+		},
+		[]string{"platform"},
+	)
+
+	// MessageRedeliveriesTotal counts deliveries RabbitMQ has handed to a
+	// worker more than once.
+	MessageRedeliveriesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "message_redeliveries_total",
+			Help: "Total number of redelivered queue messages.",
+		},
+		[]string{"queue"},
+	)
+
+	// MessagesInFlight tracks messages currently being processed by the worker.
+	MessagesInFlight = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "messages_in_flight",
+			Help: "Number of messages currently being processed by the worker.",
+		},
+		[]string{"platform"},
+	)
+
+	// ProviderErrorsTotal counts failed provider calls by error code.
+	ProviderErrorsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "provider_errors_total",
+			Help: "Total number of failed provider calls by error code.",
+		},
+		[]string{"platform", "provider", "code"},
+	)
+
+	// RecipientsDeferredTotal counts recipients held back by a send window,
+	// by where the deferral happened: "api", "scheduler" or "worker".
+	RecipientsDeferredTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "recipients_deferred_total",
+			Help: "Total number of recipients deferred to the next send window opening.",
+		},
+		[]string{"platform", "stage"},
+	)
+
+	// BulkItemsProcessedTotal counts bulk job items processed in the
+	// background, by result: "succeeded" or "failed".
+	BulkItemsProcessedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bulk_items_processed_total",
+			Help: "Total number of bulk job items processed.",
+		},
+		[]string{"result"},
+	)
+
+	// ExportsTotal counts message exports built in the background, by format
+	// and result: "completed" or "failed".
+	ExportsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "exports_total",
+			Help: "Total number of message exports built.",
+		},
+		[]string{"format", "result"},
+	)
+
+	// AnalyticsRollupLag is how far the delivery rollups trail the present.
+	AnalyticsRollupLag = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "analytics_rollup_lag_seconds",
+			Help: "Seconds since the last recipient update included in the delivery rollups.",
+		},
+	)
 )
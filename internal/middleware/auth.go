@@ -1,69 +1,232 @@
 package middleware
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 
 	"notification-system/internal/auth"
+	"notification-system/internal/cache"
 	"notification-system/internal/model"
 	"notification-system/internal/repository"
+	"notification-system/pkg/logger"
 )
 
-// ContextKeyUser is the Gin context key for the authenticated user.
-const ContextKeyUser = "user"
+// Gin context keys set by AuthMiddleware.
+const (
+	ContextKeyUser   = "user"
+	ContextKeyAPIKey = "api_key"
+	ContextKeyScopes = "scopes"
+)
+
+// lastUsedResolution limits how often last_used_at is written for a busy key.
+const lastUsedResolution = time.Minute
 
-// AuthMiddleware validates the X-API-Key header and sets the user in context.
-func AuthMiddleware(userRepo repository.UserRepository) gin.HandlerFunc {
+// AuthMiddleware authenticates the request with either an X-API-Key header or
+// an Authorization: Bearer JWT, and sets the user and granted scopes in
+// context. For API keys the key is set as well, and lookups go through
+// credCache when it is non-nil. Bearer tokens are rejected when verifier is nil.
+func AuthMiddleware(keyRepo repository.APIKeyRepository, userRepo repository.UserRepository, credCache *cache.CredentialCache, verifier *auth.TokenVerifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		if header := c.GetHeader("Authorization"); header != "" {
+			scheme, token, _ := strings.Cut(header, " ")
+			if !strings.EqualFold(scheme, "Bearer") || token == "" {
+				abortUnauthorized(c, "Unsupported Authorization scheme")
+				return
+			}
+			if verifier == nil {
+				abortUnauthorized(c, "Bearer tokens are not enabled")
+				return
+			}
+
+			user, scopes, ok := authenticateToken(c, userRepo, verifier, token)
+			if !ok {
+				return
+			}
+			if !user.IsActive {
+				abortDisabled(c)
+				return
+			}
+
+			c.Set(ContextKeyUser, user)
+			c.Set(ContextKeyScopes, scopes)
+			c.Next()
+			return
+		}
+
 		apiKey := c.GetHeader("X-API-Key")
 		if apiKey == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, model.ErrorResponse{
-				Success: false,
-				Error: model.ErrorDetail{
-					Code:    "UNAUTHORIZED",
-					Message: "Missing X-API-Key header",
-				},
-			})
+			abortUnauthorized(c, "Missing X-API-Key or Authorization header")
 			return
 		}
 
-		hash := auth.HashAPIKey(apiKey)
-		user, err := userRepo.GetByAPIKeyHash(c.Request.Context(), hash)
+		creds, err := resolveAPIKey(ctx, keyRepo, userRepo, credCache, auth.HashAPIKey(apiKey))
 		if err != nil {
 			if errors.Is(err, repository.ErrNotFound) {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, model.ErrorResponse{
-					Success: false,
-					Error: model.ErrorDetail{
-						Code:    "UNAUTHORIZED",
-						Message: "Invalid API key",
-					},
-				})
+				abortUnauthorized(c, "Invalid API key")
 				return
 			}
-			c.AbortWithStatusJSON(http.StatusInternalServerError, model.ErrorResponse{
+			abortAuthError(c, err)
+			return
+		}
+		key, user := creds.Key, creds.User
+
+		now := time.Now()
+		if key.IsRevoked() {
+			abortUnauthorized(c, "API key has been revoked")
+			return
+		}
+		if key.IsExpired(now) {
+			abortUnauthorized(c, "API key has expired")
+			return
+		}
+
+		if !user.IsActive {
+			abortDisabled(c)
+			return
+		}
+
+		if shouldTouchKey(ctx, credCache, key, now) {
+			if err := keyRepo.TouchLastUsed(ctx, key.ID, now); err != nil {
+				logger.Ctx(ctx).Error().Err(err).Str("key_id", key.ID.String()).Msg("failed to update api key last_used_at")
+			}
+		}
+
+		c.Set(ContextKeyUser, user)
+		c.Set(ContextKeyAPIKey, key)
+		c.Set(ContextKeyScopes, []string(key.Scopes))
+		c.Next()
+	}
+}
+
+// authenticateToken verifies a bearer token and maps it to a user. The token's
+// user claim is matched against users.id when it is a UUID and users.email
+// otherwise. It aborts the request and returns false on failure.
+func authenticateToken(c *gin.Context, userRepo repository.UserRepository, verifier *auth.TokenVerifier, token string) (*model.User, []string, bool) {
+	ctx := c.Request.Context()
+
+	claims, err := verifier.Verify(ctx, token)
+	if err != nil {
+		logger.Ctx(c.Request.Context()).Debug().Err(err).Msg("bearer token rejected")
+		abortUnauthorized(c, "Invalid bearer token")
+		return nil, nil, false
+	}
+
+	var user *model.User
+	if id, parseErr := uuid.Parse(claims.User); parseErr == nil {
+		user, err = userRepo.GetByID(ctx, id)
+	} else {
+		user, err = userRepo.GetByEmail(ctx, claims.User)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			abortUnauthorized(c, "Token subject is not a registered user")
+			return nil, nil, false
+		}
+		abortAuthError(c, err)
+		return nil, nil, false
+	}
+
+	return user, claims.Scopes, true
+}
+
+// resolveAPIKey returns the key matching hash and its owner, reading through
+// credCache. It returns repository.ErrNotFound for unknown keys.
+func resolveAPIKey(ctx context.Context, keyRepo repository.APIKeyRepository, userRepo repository.UserRepository, credCache *cache.CredentialCache, hash string) (*cache.Credentials, error) {
+	creds, found, err := credCache.Get(ctx, hash)
+	if err != nil {
+		// Fall back to Postgres rather than failing the request.
+		logger.Ctx(ctx).Warn().Err(err).Msg("credential cache read failed")
+	}
+	if found {
+		if creds == nil {
+			return nil, repository.ErrNotFound
+		}
+		return creds, nil
+	}
+
+	key, err := keyRepo.GetByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			if err := credCache.SetMissing(ctx, hash); err != nil {
+				logger.Ctx(ctx).Warn().Err(err).Msg("credential cache write failed")
+			}
+		}
+		return nil, err
+	}
+
+	user, err := userRepo.GetByID(ctx, key.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	creds = &cache.Credentials{Key: key, User: user}
+	if err := credCache.Set(ctx, hash, *creds); err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("credential cache write failed")
+	}
+	return creds, nil
+}
+
+// shouldTouchKey reports whether last_used_at is due for an update. With a
+// cache the key's LastUsedAt may be stale, so a Redis lock additionally limits
+// the write to once per lastUsedResolution across replicas.
+func shouldTouchKey(ctx context.Context, credCache *cache.CredentialCache, key *model.APIKey, now time.Time) bool {
+	if key.LastUsedAt != nil && now.Sub(*key.LastUsedAt) < lastUsedResolution {
+		return false
+	}
+	if credCache == nil {
+		return true
+	}
+
+	ok, err := credCache.ShouldTouch(ctx, key.ID, lastUsedResolution)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("credential cache touch lock failed")
+		return true
+	}
+	return ok
+}
+
+// RequireScope returns a middleware that rejects requests whose credentials
+// were not granted scope. It must run after AuthMiddleware.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !auth.HasScope(GetScopesFromContext(c), scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, model.ErrorResponse{
 				Success: false,
 				Error: model.ErrorDetail{
-					Code:    "INTERNAL_ERROR",
-					Message: "Failed to authenticate",
+					Code:    "FORBIDDEN",
+					Message: fmt.Sprintf("Credentials are missing required scope: %s", scope),
 				},
 			})
 			return
 		}
+		c.Next()
+	}
+}
 
-		if !user.IsActive {
+// RequireRole returns a middleware that rejects requests from users without
+// the given role. It must run after AuthMiddleware.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := GetUserFromContext(c)
+		if user == nil || user.Role != role {
 			c.AbortWithStatusJSON(http.StatusForbidden, model.ErrorResponse{
 				Success: false,
 				Error: model.ErrorDetail{
-					Code:    "UNAUTHORIZED",
-					Message: "Account is disabled",
+					Code:    "FORBIDDEN",
+					Message: fmt.Sprintf("This operation requires the %s role", role),
 				},
 			})
 			return
 		}
-
-		c.Set(ContextKeyUser, user)
 		c.Next()
 	}
 }
@@ -80,3 +243,64 @@ func GetUserFromContext(c *gin.Context) *model.User {
 	}
 	return user
 }
+
+// GetAPIKeyFromContext extracts the API key used to authenticate the request.
+func GetAPIKeyFromContext(c *gin.Context) *model.APIKey {
+	val, exists := c.Get(ContextKeyAPIKey)
+	if !exists {
+		return nil
+	}
+	key, ok := val.(*model.APIKey)
+	if !ok {
+		return nil
+	}
+	return key
+}
+
+// GetScopesFromContext returns the scopes granted to the request's credentials.
+func GetScopesFromContext(c *gin.Context) []string {
+	val, exists := c.Get(ContextKeyScopes)
+	if !exists {
+		return nil
+	}
+	scopes, _ := val.([]string)
+	return scopes
+}
+
+// IsAdmin reports whether the request is made by a user with the admin role
+// using credentials that hold the admin scope, as the /admin routes require.
+func IsAdmin(c *gin.Context) bool {
+	user := GetUserFromContext(c)
+	return user != nil && user.Role == model.RoleAdmin && auth.HasScope(GetScopesFromContext(c), auth.ScopeAdmin)
+}
+
+func abortUnauthorized(c *gin.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, model.ErrorResponse{
+		Success: false,
+		Error: model.ErrorDetail{
+			Code:    "UNAUTHORIZED",
+			Message: message,
+		},
+	})
+}
+
+func abortDisabled(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusForbidden, model.ErrorResponse{
+		Success: false,
+		Error: model.ErrorDetail{
+			Code:    "UNAUTHORIZED",
+			Message: "Account is disabled",
+		},
+	})
+}
+
+func abortAuthError(c *gin.Context, err error) {
+	logger.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to authenticate request")
+	c.AbortWithStatusJSON(http.StatusInternalServerError, model.ErrorResponse{
+		Success: false,
+		Error: model.ErrorDetail{
+			Code:    "INTERNAL_ERROR",
+			Message: "Failed to authenticate",
+		},
+	})
+}
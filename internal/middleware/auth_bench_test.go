@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"notification-system/internal/auth"
+	"notification-system/internal/cache"
+	"notification-system/internal/config"
+	"notification-system/internal/model"
+	"notification-system/internal/repository"
+)
+
+// repoLatency models the Postgres round trip the fake repositories don't
+// make, e.g. go test -bench ResolveAPIKey -repo-latency 500us. Compare
+// lookups/op when it is 0.
+var repoLatency = flag.Duration("repo-latency", 0, "simulated latency of each repository lookup")
+
+// fakeKeyRepo serves API keys from memory and counts lookups, standing in
+// for Postgres on the uncached path.
+type fakeKeyRepo struct {
+	repository.APIKeyRepository
+	keys    map[string]*model.APIKey
+	lookups int
+}
+
+func (r *fakeKeyRepo) GetByHash(_ context.Context, hash string) (*model.APIKey, error) {
+	r.lookups++
+	time.Sleep(*repoLatency)
+	key, ok := r.keys[hash]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	k := *key
+	return &k, nil
+}
+
+type fakeUserRepo struct {
+	repository.UserRepository
+	user    *model.User
+	lookups int
+}
+
+func (r *fakeUserRepo) GetByID(_ context.Context, id uuid.UUID) (*model.User, error) {
+	r.lookups++
+	time.Sleep(*repoLatency)
+	if id != r.user.ID {
+		return nil, repository.ErrNotFound
+	}
+	u := *r.user
+	return &u, nil
+}
+
+type credentialBench struct {
+	keyRepo  *fakeKeyRepo
+	userRepo *fakeUserRepo
+	cache    *cache.CredentialCache
+}
+
+// newCredentialBench sets up n API keys for one user, and a CredentialCache
+// backed by an in-process Redis.
+func newCredentialBench(b *testing.B, n int) (*credentialBench, []string) {
+	b.Helper()
+
+	mr := miniredis.RunT(b)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	b.Cleanup(func() { rdb.Close() })
+
+	user := &model.User{ID: uuid.New(), Email: "bench@example.com", Role: model.RoleUser, IsActive: true}
+	keyRepo := &fakeKeyRepo{keys: make(map[string]*model.APIKey, n)}
+	hashes := make([]string, n)
+	for i := range hashes {
+		hashes[i] = auth.HashAPIKey(fmt.Sprintf("nsk_bench_%d", i))
+		keyRepo.keys[hashes[i]] = &model.APIKey{
+			ID:        uuid.New(),
+			UserID:    user.ID,
+			Name:      "bench",
+			KeyHash:   hashes[i],
+			Scopes:    []string{auth.ScopeAll},
+			CreatedAt: time.Now(),
+		}
+	}
+
+	return &credentialBench{
+		keyRepo:  keyRepo,
+		userRepo: &fakeUserRepo{user: user},
+		cache: cache.NewCredentialCache(rdb, config.AuthCacheConfig{
+			Enabled:     true,
+			TTL:         time.Minute,
+			NegativeTTL: time.Minute,
+		}),
+	}, hashes
+}
+
+// run resolves hashes[i%len(hashes)] b.N times through credCache and
+// reports the repository lookups per resolution.
+func (cb *credentialBench) run(b *testing.B, credCache *cache.CredentialCache, hashes []string, wantErr error) {
+	b.Helper()
+	ctx := context.Background()
+
+	cb.keyRepo.lookups, cb.userRepo.lookups = 0, 0
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := resolveAPIKey(ctx, cb.keyRepo, cb.userRepo, credCache, hashes[i%len(hashes)]); err != wantErr {
+			b.Fatalf("resolveAPIKey: got error %v, want %v", err, wantErr)
+		}
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(cb.keyRepo.lookups+cb.userRepo.lookups)/float64(b.N), "lookups/op")
+}
+
+// BenchmarkResolveAPIKeyUncached is the baseline: every request reads the
+// key and its owner from the repositories.
+func BenchmarkResolveAPIKeyUncached(b *testing.B) {
+	cb, hashes := newCredentialBench(b, 1)
+	cb.run(b, nil, hashes, nil)
+}
+
+// BenchmarkResolveAPIKeyCacheHit resolves a key that is already cached.
+func BenchmarkResolveAPIKeyCacheHit(b *testing.B) {
+	cb, hashes := newCredentialBench(b, 1)
+	cb.run(b, cb.cache, hashes, nil)
+}
+
+// BenchmarkResolveAPIKeyCacheMiss resolves a different uncached key each
+// time, paying for the cache read, the repository lookups and the cache
+// write.
+func BenchmarkResolveAPIKeyCacheMiss(b *testing.B) {
+	cb, hashes := newCredentialBench(b, b.N)
+	cb.run(b, cb.cache, hashes, nil)
+}
+
+// BenchmarkResolveAPIKeyNegativeHit resolves an unknown key that is cached
+// as missing.
+func BenchmarkResolveAPIKeyNegativeHit(b *testing.B) {
+	cb, _ := newCredentialBench(b, 0)
+	cb.run(b, cb.cache, []string{auth.HashAPIKey("nsk_unknown")}, repository.ErrNotFound)
+}
+
+// BenchmarkResolveAPIKeyUncachedUnknown is the baseline for unknown keys.
+func BenchmarkResolveAPIKeyUncachedUnknown(b *testing.B) {
+	cb, _ := newCredentialBench(b, 0)
+	cb.run(b, nil, []string{auth.HashAPIKey("nsk_unknown")}, repository.ErrNotFound)
+}
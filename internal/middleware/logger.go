@@ -9,6 +9,7 @@ import (
 )
 
 // Logger returns a middleware that logs each HTTP request using zerolog.
+// Entries carry the request ID when RequestID runs first.
 func Logger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
@@ -19,7 +20,7 @@ func Logger() gin.HandlerFunc {
 
 		latency := time.Since(start)
 		status := c.Writer.Status()
-		log := logger.Get()
+		log := logger.Ctx(c.Request.Context())
 
 		event := log.Info()
 		if status >= 500 {
@@ -46,7 +46,7 @@ func RateLimitMiddleware(rdb *redis.Client, cfg config.RateLimitConfig) gin.Hand
 		// Increment counter
 		count, err := rdb.Incr(ctx, key).Result()
 		if err != nil {
-			logger.Get().Error().Err(err).Msg("rate limit redis error")
+			logger.Ctx(c.Request.Context()).Error().Err(err).Msg("rate limit redis error")
 			// Fail open: allow request if Redis is down
 			c.Next()
 			return
@@ -16,7 +16,7 @@ func Recovery() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if err := recover(); err != nil {
-				log := logger.Get()
+				log := logger.Ctx(c.Request.Context())
 				log.Error().
 					Interface("error", err).
 					Str("stack", string(debug.Stack())).
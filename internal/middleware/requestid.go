@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"notification-system/pkg/logger"
+	"notification-system/pkg/requestid"
+)
+
+// RequestID returns a middleware that accepts the caller's X-Request-ID, or
+// generates one, and attaches it to the response, the request context and
+// the request-scoped logger.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		c.Header(requestid.Header, id)
+		ctx := requestid.NewContext(c.Request.Context(), id)
+		c.Request = c.Request.WithContext(logger.WithRequestID(ctx, id))
+		c.Next()
+	}
+}
@@ -0,0 +1,113 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeliveryLatencyBounds are the boundaries of the latency histograms kept
+// in the delivery rollups. Slot 0 holds negative latencies (clock skew),
+// slot i holds latencies in [bounds[i-1], bounds[i]) and the last slot
+// holds everything from the final bound up. Changing them invalidates the
+// existing rollups.
+var DeliveryLatencyBounds = []time.Duration{
+	0,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2 * time.Second,
+	3 * time.Second,
+	5 * time.Second,
+	7500 * time.Millisecond,
+	10 * time.Second,
+	15 * time.Second,
+	20 * time.Second,
+	30 * time.Second,
+	45 * time.Second,
+	time.Minute,
+	90 * time.Second,
+	2 * time.Minute,
+	3 * time.Minute,
+	5 * time.Minute,
+	10 * time.Minute,
+	15 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	2 * time.Hour,
+	6 * time.Hour,
+	12 * time.Hour,
+	24 * time.Hour,
+}
+
+// Latency stages of the delivery rollups.
+const (
+	StageSent      = "sent"
+	StageDelivered = "delivered"
+)
+
+// DeliveryAnalyticsQuery represents the query parameters for delivery
+// analytics.
+type DeliveryAnalyticsQuery struct {
+	Granularity string     `form:"granularity,default=hour" binding:"oneof=hour day"`
+	Platform    string     `form:"platform" binding:"omitempty,oneof=sms whatsapp telegram email"`
+	From        *time.Time `form:"from"`
+	To          *time.Time `form:"to"`
+}
+
+// DeliveryAnalyticsFilter narrows the delivery rollups. From and To are
+// aligned to Granularity. A nil UserID aggregates across all users.
+type DeliveryAnalyticsFilter struct {
+	UserID      *uuid.UUID
+	Granularity string
+	From        time.Time
+	To          time.Time
+	Platform    string
+}
+
+// DeliveryStatusRow is the number of recipients in a status for one bucket
+// and platform.
+type DeliveryStatusRow struct {
+	Bucket     time.Time     `db:"bucket"`
+	Platform   string        `db:"platform"`
+	Status     MessageStatus `db:"status"`
+	Recipients int64         `db:"recipients"`
+}
+
+// DeliveryLatencyRow is the number of recipients in one latency histogram
+// slot for one bucket, platform and stage.
+type DeliveryLatencyRow struct {
+	Bucket     time.Time `db:"bucket"`
+	Platform   string    `db:"platform"`
+	Stage      string    `db:"stage"`
+	Slot       int       `db:"slot"`
+	Recipients int64     `db:"recipients"`
+}
+
+// DeliveryErrorCount is the number of failed recipients with an error
+// message.
+type DeliveryErrorCount struct {
+	Platform   string `json:"platform" db:"platform"`
+	Error      string `json:"error" db:"error"`
+	Recipients int64  `json:"recipients" db:"recipients"`
+}
+
+// DeliveryBucket summarizes the recipients of one platform created during
+// one bucket. Counts is keyed by status name.
+type DeliveryBucket struct {
+	Start           time.Time        `json:"start"`
+	Platform        string           `json:"platform"`
+	Total           int64            `json:"total"`
+	Counts          map[string]int64 `json:"counts"`
+	TimeToSent      LatencySummary   `json:"time_to_sent"`
+	TimeToDelivered LatencySummary   `json:"time_to_delivered"`
+}
+
+// LatencySummary holds percentiles estimated from a latency histogram.
+// They are nil when Count is 0.
+type LatencySummary struct {
+	Count int64  `json:"count"`
+	P50Ms *int64 `json:"p50_ms"`
+	P95Ms *int64 `json:"p95_ms"`
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx/types"
+)
+
+// Audit actions.
+const (
+	AuditUserCreate     = "user.create"
+	AuditUserUpdate     = "user.update"
+	AuditUserDeactivate = "user.deactivate"
+	AuditUserReactivate = "user.reactivate"
+
+	AuditMessageSend     = "message.send"
+	AuditMessageBulkSend = "message.bulk_send"
+	AuditMessageCancel   = "message.cancel"
+	AuditMessageUpdate   = "message.update"
+
+	AuditKeyCreate = "api_key.create"
+	AuditKeyRotate = "api_key.rotate"
+	AuditKeyRevoke = "api_key.revoke"
+
+	AuditScheduleCreate = "schedule.create"
+	AuditSchedulePause  = "schedule.pause"
+	AuditScheduleResume = "schedule.resume"
+	AuditScheduleDelete = "schedule.delete"
+
+	AuditExportCreate = "export.create"
+)
+
+// Audit target types.
+const (
+	AuditTargetUser     = "user"
+	AuditTargetMessage  = "message"
+	AuditTargetAPIKey   = "api_key"
+	AuditTargetSchedule = "schedule"
+	AuditTargetBulkJob  = "bulk_job"
+	AuditTargetExport   = "export"
+)
+
+// AuditEvent records a single state-changing operation and who performed it.
+// Before and After hold JSON snapshots of the target; either may be JSON null.
+// ActorKeyID is empty for requests authenticated with a bearer token.
+type AuditEvent struct {
+	ID          uuid.UUID      `json:"id" db:"id"`
+	ActorUserID *uuid.UUID     `json:"actor_user_id,omitempty" db:"actor_user_id"`
+	ActorKeyID  *uuid.UUID     `json:"actor_key_id,omitempty" db:"actor_key_id"`
+	IP          *string        `json:"ip,omitempty" db:"ip"`
+	RequestID   *string        `json:"request_id,omitempty" db:"request_id"`
+	Action      string         `json:"action" db:"action"`
+	TargetType  string         `json:"target_type" db:"target_type"`
+	TargetID    *string        `json:"target_id,omitempty" db:"target_id"`
+	Before      types.JSONText `json:"before" db:"before"`
+	After       types.JSONText `json:"after" db:"after"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+}
+
+// ListAuditQuery represents the query parameters for the admin audit log.
+// Cursor is the opaque next_cursor of the previous page.
+type ListAuditQuery struct {
+	Cursor      string     `form:"cursor"`
+	Limit       int        `form:"limit,default=50" binding:"min=1,max=200"`
+	ActorUserID string     `form:"actor_user_id" binding:"omitempty,uuid"`
+	ActorKeyID  string     `form:"actor_key_id" binding:"omitempty,uuid"`
+	Action      string     `form:"action"`
+	TargetType  string     `form:"target_type"`
+	TargetID    string     `form:"target_id"`
+	RequestID   string     `form:"request_id"`
+	From        *time.Time `form:"from"`
+	To          *time.Time `form:"to"`
+}
+
+// ListAuditResponse is a page of audit events, newest first. NextCursor is
+// empty on the last page.
+type ListAuditResponse struct {
+	Success    bool         `json:"success"`
+	Events     []AuditEvent `json:"events"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
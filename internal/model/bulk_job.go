@@ -0,0 +1,82 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BulkJobStatus is the lifecycle state of a bulk send job.
+type BulkJobStatus string
+
+const (
+	BulkJobPending   BulkJobStatus = "pending"
+	BulkJobRunning   BulkJobStatus = "running"
+	BulkJobCompleted BulkJobStatus = "completed" // every item processed, whether or not it succeeded
+)
+
+// BulkItemStatus is the outcome of one message of a bulk send job.
+type BulkItemStatus string
+
+const (
+	BulkItemPending   BulkItemStatus = "pending"
+	BulkItemSucceeded BulkItemStatus = "succeeded"
+	BulkItemFailed    BulkItemStatus = "failed"
+)
+
+// BulkJob is a bulk send accepted by the API and processed in the
+// background, one BulkJobItem per message.
+type BulkJob struct {
+	ID          uuid.UUID     `json:"id" db:"id"`
+	UserID      uuid.UUID     `json:"user_id" db:"user_id"`
+	APIKeyID    *uuid.UUID    `json:"api_key_id,omitempty" db:"api_key_id"`
+	RequestID   *string       `json:"request_id,omitempty" db:"request_id"`
+	Status      BulkJobStatus `json:"status" db:"status"`
+	Total       int           `json:"total" db:"total"`
+	StartedAt   *time.Time    `json:"started_at,omitempty" db:"started_at"`
+	CompletedAt *time.Time    `json:"completed_at,omitempty" db:"completed_at"`
+	CreatedAt   time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// BulkJobItem is one message of a bulk job: the request to send it and,
+// once processed, the resulting message or error.
+type BulkJobItem struct {
+	JobID       uuid.UUID      `json:"job_id" db:"job_id"`
+	Index       int            `json:"index" db:"idx"`
+	Request     BulkItemBody   `json:"request" db:"request"`
+	Status      BulkItemStatus `json:"status" db:"status"`
+	MessageID   *uuid.UUID     `json:"message_id,omitempty" db:"message_id"`
+	Error       *string        `json:"error,omitempty" db:"error"`
+	ProcessedAt *time.Time     `json:"processed_at,omitempty" db:"processed_at"`
+}
+
+// BulkItemBody stores a CreateMessageRequest as JSON.
+type BulkItemBody CreateMessageRequest
+
+// Value implements driver.Valuer, storing the request as JSON.
+func (b BulkItemBody) Value() (driver.Value, error) {
+	return json.Marshal(b)
+}
+
+// Scan implements sql.Scanner for requests stored as JSON.
+func (b *BulkItemBody) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, b)
+	case string:
+		return json.Unmarshal([]byte(v), b)
+	default:
+		return fmt.Errorf("bulk item: cannot scan %T", src)
+	}
+}
+
+// BulkJobProgress counts a job's items by status.
+type BulkJobProgress struct {
+	Pending   int `json:"pending" db:"pending"`
+	Succeeded int `json:"succeeded" db:"succeeded"`
+	Failed    int `json:"failed" db:"failed"`
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeliveryAttempt records a single call the worker made to a provider
+// adapter for a recipient.
+type DeliveryAttempt struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	RecipientID uuid.UUID `json:"recipient_id" db:"recipient_id"`
+	MessageID   uuid.UUID `json:"message_id" db:"message_id"`
+	// Attempt numbers a recipient's attempts from 1.
+	Attempt    int     `json:"attempt" db:"attempt"`
+	Adapter    string  `json:"adapter" db:"adapter"`
+	Success    bool    `json:"success" db:"success"`
+	ProviderID *string `json:"provider_id,omitempty" db:"provider_id"`
+	// ResponseCode is the provider's HTTP status, unset when the request
+	// never reached it.
+	ResponseCode *int `json:"response_code,omitempty" db:"response_code"`
+	// ErrorCode is the provider's error code or HTTP status, "timeout" or
+	// "transport", as reported in metrics.
+	ErrorCode    *string   `json:"error_code,omitempty" db:"error_code"`
+	ErrorMessage *string   `json:"error_message,omitempty" db:"error_message"`
+	LatencyMs    int       `json:"latency_ms" db:"latency_ms"`
+	StartedAt    time.Time `json:"started_at" db:"started_at"`
+	FinishedAt   time.Time `json:"finished_at" db:"finished_at"`
+}
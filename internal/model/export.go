@@ -0,0 +1,92 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExportFormat is the file format of a message export.
+type ExportFormat string
+
+const (
+	ExportCSV     ExportFormat = "csv"
+	ExportNDJSON  ExportFormat = "ndjson"
+	ExportParquet ExportFormat = "parquet"
+)
+
+// ExportStatus is the lifecycle state of a message export.
+type ExportStatus string
+
+const (
+	ExportPending   ExportStatus = "pending"
+	ExportRunning   ExportStatus = "running"
+	ExportCompleted ExportStatus = "completed"
+	ExportFailed    ExportStatus = "failed"
+	ExportExpired   ExportStatus = "expired" // file deleted after ExpiresAt
+)
+
+// Export is a file of a user's messages and their recipients, written in
+// the background. Once completed it can be downloaded until ExpiresAt.
+type Export struct {
+	ID          uuid.UUID     `json:"id" db:"id"`
+	UserID      uuid.UUID     `json:"user_id" db:"user_id"`
+	Format      ExportFormat  `json:"format" db:"format"`
+	Filters     ExportFilters `json:"filters" db:"filters"`
+	Status      ExportStatus  `json:"status" db:"status"`
+	StorageKey  *string       `json:"-" db:"storage_key"`
+	Rows        int64         `json:"rows" db:"row_count"`
+	SizeBytes   int64         `json:"size_bytes" db:"size_bytes"`
+	Error       *string       `json:"error,omitempty" db:"error"`
+	StartedAt   *time.Time    `json:"started_at,omitempty" db:"started_at"`
+	CompletedAt *time.Time    `json:"completed_at,omitempty" db:"completed_at"`
+	ExpiresAt   *time.Time    `json:"expires_at,omitempty" db:"expires_at"`
+	CreatedAt   time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// ExportFilters stores the MessageFilter of an export as JSON.
+type ExportFilters MessageFilter
+
+// Value implements driver.Valuer, storing the filters as JSON.
+func (f ExportFilters) Value() (driver.Value, error) {
+	return json.Marshal(f)
+}
+
+// Scan implements sql.Scanner for filters stored as JSON.
+func (f *ExportFilters) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	default:
+		return fmt.Errorf("export filters: cannot scan %T", src)
+	}
+}
+
+// ExportRow is one recipient of an exported message, together with the
+// message.
+type ExportRow struct {
+	MessageID       uuid.UUID     `db:"message_id"`
+	RequestID       *string       `db:"request_id"`
+	Subject         string        `db:"subject"`
+	Body            string        `db:"body"`
+	Sender          string        `db:"sender"`
+	Platform        Platform      `db:"platform"`
+	Priority        Priority      `db:"priority"`
+	MessageStatus   MessageStatus `db:"message_status"`
+	ScheduledAt     *time.Time    `db:"scheduled_at"`
+	CreatedAt       time.Time     `db:"created_at"`
+	RecipientID     uuid.UUID     `db:"recipient_id"`
+	Recipient       string        `db:"recipient"`
+	RecipientStatus MessageStatus `db:"recipient_status"`
+	ProviderID      *string       `db:"provider_id"`
+	ErrorMessage    *string       `db:"error_message"`
+	RetryCount      int           `db:"retry_count"`
+	SentAt          *time.Time    `db:"sent_at"`
+	DeliveredAt     *time.Time    `db:"delivered_at"`
+}
@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"notification-system/internal/sendwindow"
 )
 
 // MessageStatus represents the processing state of a message.
@@ -54,6 +56,12 @@ const (
 	PlatformEmail    Platform = "email"
 )
 
+// UsesPhoneNumbers reports whether recipients on p are addressed by phone
+// number, so their timezone can be inferred from the country code.
+func (p Platform) UsesPhoneNumbers() bool {
+	return p == PlatformSMS || p == PlatformWhatsApp
+}
+
 // Priority represents the urgency level of a message.
 type Priority int
 
@@ -67,6 +75,9 @@ const (
 type Message struct {
 	ID          uuid.UUID     `json:"id" db:"id"`
 	UserID      uuid.UUID     `json:"user_id" db:"user_id"`
+	APIKeyID    *uuid.UUID    `json:"api_key_id,omitempty" db:"api_key_id"`
+	RequestID   *string       `json:"request_id,omitempty" db:"request_id"`
+	ScheduleID  *uuid.UUID    `json:"schedule_id,omitempty" db:"schedule_id"`
 	Subject     string        `json:"subject" db:"subject"`
 	Body        string        `json:"body" db:"body"`
 	Sender      string        `json:"sender" db:"sender"`
@@ -74,6 +85,8 @@ type Message struct {
 	Priority    Priority      `json:"priority" db:"priority"`
 	Status      MessageStatus `json:"status" db:"status"`
 	ScheduledAt *time.Time    `json:"scheduled_at,omitempty" db:"scheduled_at"`
-	CreatedAt   time.Time     `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time     `json:"updated_at" db:"updated_at"`
+	// SendWindow, if set, defers recipients outside a daily local-time window.
+	SendWindow *sendwindow.Window `json:"send_window,omitempty" db:"send_window"`
+	CreatedAt  time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at" db:"updated_at"`
 }
@@ -17,6 +17,17 @@ type Recipient struct {
 	RetryCount   int           `json:"retry_count" db:"retry_count"`
 	SentAt       *time.Time    `json:"sent_at,omitempty" db:"sent_at"`
 	DeliveredAt  *time.Time    `json:"delivered_at,omitempty" db:"delivered_at"`
-	CreatedAt    time.Time     `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time     `json:"updated_at" db:"updated_at"`
+	// DeferredUntil is when a recipient held back by the message's send
+	// window becomes due; it is set while Status is StatusScheduled.
+	DeferredUntil *time.Time `json:"deferred_until,omitempty" db:"deferred_until"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// RecipientHistoryEntry is a recipient row together with the message it
+// belongs to, as returned when searching by address.
+type RecipientHistoryEntry struct {
+	Recipient
+	Subject  string   `json:"subject" db:"subject"`
+	Platform Platform `json:"platform" db:"platform"`
 }
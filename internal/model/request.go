@@ -1,6 +1,10 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"notification-system/internal/sendwindow"
+)
 
 // CreateMessageRequest is the API request body for sending a message.
 type CreateMessageRequest struct {
@@ -11,19 +15,149 @@ type CreateMessageRequest struct {
 	Platform    string     `json:"platform" binding:"required,oneof=sms whatsapp telegram email"`
 	Priority    *int       `json:"priority,omitempty" binding:"omitempty,oneof=0 1 2"`
 	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+	// SendWindow overrides the user's default send window for this message.
+	SendWindow *sendwindow.Window `json:"send_window,omitempty"`
+}
+
+// UpdateMessageRequest is the API request body for editing a scheduled
+// message. Only fields that are present are changed; To replaces the whole
+// recipient list and may not be empty.
+type UpdateMessageRequest struct {
+	Subject     *string    `json:"subject,omitempty" binding:"omitempty,max=200"`
+	Message     *string    `json:"message,omitempty" binding:"omitempty,min=1,max=5000"`
+	To          []string   `json:"to,omitempty" binding:"omitempty,min=1,max=1000,dive,required"`
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+}
+
+// CreateScheduleRequest is the API request body for creating a recurring
+// schedule. Exactly one of Cron and RRule must be set; Timezone is an IANA
+// name and defaults to UTC.
+type CreateScheduleRequest struct {
+	Name     string     `json:"name" binding:"required,max=100"`
+	Cron     string     `json:"cron" binding:"omitempty,max=100"`
+	RRule    string     `json:"rrule" binding:"omitempty,max=1000"`
+	Timezone string     `json:"timezone" binding:"omitempty,max=64"`
+	Subject  string     `json:"subject" binding:"required,max=200"`
+	Message  string     `json:"message" binding:"required,max=5000"`
+	From     string     `json:"from" binding:"required,max=100"`
+	To       []string   `json:"to" binding:"required,min=1,max=1000,dive,required"`
+	Platform string     `json:"platform" binding:"required,oneof=sms whatsapp telegram email"`
+	Priority *int       `json:"priority,omitempty" binding:"omitempty,oneof=0 1 2"`
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+	// SendWindow overrides the user's default send window for this schedule.
+	SendWindow *sendwindow.Window `json:"send_window,omitempty"`
 }
 
 // BulkMessageRequest is the API request body for sending multiple messages.
 type BulkMessageRequest struct {
-	Messages []CreateMessageRequest `json:"messages" binding:"required,min=1,dive"`
+	Messages []CreateMessageRequest `json:"messages" binding:"required,min=1,max=10000,dive"`
+}
+
+// BulkUploadRequest represents the form fields of a bulk send upload, which
+// must come before the file part. Subject and Message may use {{name}}
+// placeholders for the variables in Mapping, a JSON object naming the
+// recipient column and the column of each variable. Format is detected from
+// the file when omitted.
+type BulkUploadRequest struct {
+	Subject     string     `form:"subject" binding:"required,max=200"`
+	Message     string     `form:"message" binding:"required,max=5000"`
+	From        string     `form:"from" binding:"required,max=100"`
+	Platform    string     `form:"platform" binding:"required,oneof=sms whatsapp telegram email"`
+	Priority    *int       `form:"priority" binding:"omitempty,oneof=0 1 2"`
+	ScheduledAt *time.Time `form:"scheduled_at" time_format:"2006-01-02T15:04:05Z07:00"`
+	Mapping     string     `form:"mapping" binding:"required"`
+	Format      string     `form:"format" binding:"omitempty,oneof=csv ndjson"`
+	Partial     bool       `form:"partial"`
+}
+
+// GetBulkJobQuery represents the query parameters for a bulk job's results.
+// Cursor is the opaque next_cursor of the previous page.
+type GetBulkJobQuery struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending succeeded failed"`
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit,default=100" binding:"min=1,max=1000"`
+}
+
+// MessageFilter selects a user's messages. It is shared by listing and
+// exporting messages; From and To bound the creation time.
+type MessageFilter struct {
+	Platform  string     `json:"platform,omitempty" form:"platform" binding:"omitempty,oneof=sms whatsapp telegram email"`
+	Status    *int       `json:"status,omitempty" form:"status" binding:"omitempty,min=0,max=7"`
+	Priority  *int       `json:"priority,omitempty" form:"priority" binding:"omitempty,oneof=0 1 2"`
+	Recipient string     `json:"recipient,omitempty" form:"recipient" binding:"omitempty,max=255"`
+	Q         string     `json:"q,omitempty" form:"q" binding:"omitempty,max=200"`
+	Scheduled *bool      `json:"scheduled,omitempty" form:"scheduled"`
+	From      *time.Time `json:"from,omitempty" form:"from"`
+	To        *time.Time `json:"to,omitempty" form:"to"`
 }
 
 // ListMessagesQuery represents the query parameters for listing messages.
+// Cursor is the opaque next_cursor of the previous page. Page selects a page
+// by offset instead and is kept for older clients. Include is a
+// comma-separated list of "summary" and "total".
 type ListMessagesQuery struct {
-	Page     int        `form:"page,default=1" binding:"min=1"`
-	Limit    int        `form:"limit,default=20" binding:"min=1,max=100"`
-	Platform string     `form:"platform" binding:"omitempty,oneof=sms whatsapp telegram email"`
-	Status   *int       `form:"status" binding:"omitempty,min=0,max=6"`
-	From     *time.Time `form:"from"`
-	To       *time.Time `form:"to"`
+	Cursor string `form:"cursor"`
+	Page   int    `form:"page" binding:"omitempty,min=1"`
+	Limit  int    `form:"limit,default=20" binding:"min=1,max=100"`
+	MessageFilter
+	Include string `form:"include"`
+}
+
+// CreateExportRequest is the API request body for exporting messages. The
+// filters are those of ListMessagesQuery.
+type CreateExportRequest struct {
+	Format string `json:"format" binding:"required,oneof=csv ndjson parquet"`
+	MessageFilter
+}
+
+// ListRecipientsQuery represents the query parameters for searching the
+// delivery history of a recipient address.
+type ListRecipientsQuery struct {
+	Address string `form:"address" binding:"required,max=255"`
+	Cursor  string `form:"cursor"`
+	Limit   int    `form:"limit,default=20" binding:"min=1,max=100"`
+}
+
+// CreateAPIKeyRequest is the API request body for creating an API key.
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,required"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// RotateAPIKeyRequest is the API request body for rotating an API key.
+// The old key keeps working for GracePeriodSeconds so clients can switch over.
+type RotateAPIKeyRequest struct {
+	GracePeriodSeconds *int `json:"grace_period_seconds,omitempty" binding:"omitempty,min=0,max=2592000"`
+}
+
+// CreateUserRequest is the admin API request body for creating a user.
+// The user's first API key is issued in the same operation.
+type CreateUserRequest struct {
+	Email         string   `json:"email" binding:"required,email,max=255"`
+	Role          string   `json:"role" binding:"omitempty,oneof=user admin"`
+	RateLimitTier string   `json:"rate_limit_tier" binding:"omitempty,max=50"`
+	KeyName       string   `json:"key_name" binding:"omitempty,max=100"`
+	KeyScopes     []string `json:"key_scopes" binding:"omitempty,dive,required"`
+}
+
+// UpdateUserRequest is the admin API request body for changing a user.
+// Only fields that are present are changed.
+type UpdateUserRequest struct {
+	Role          *string `json:"role,omitempty" binding:"omitempty,oneof=user admin"`
+	RateLimitTier *string `json:"rate_limit_tier,omitempty" binding:"omitempty,max=50"`
+	// SendWindow sets the user's default send window; an empty object clears it.
+	SendWindow *sendwindow.Window `json:"send_window,omitempty"`
+}
+
+// ListUsersQuery represents the query parameters for the admin user listing.
+type ListUsersQuery struct {
+	Page          int    `form:"page,default=1" binding:"min=1"`
+	Limit         int    `form:"limit,default=20" binding:"min=1,max=100"`
+	Email         string `form:"email"`
+	Role          string `form:"role" binding:"omitempty,oneof=user admin"`
+	RateLimitTier string `form:"rate_limit_tier"`
+	IsActive      *bool  `form:"is_active"`
+	StatsDays     int    `form:"stats_days,default=30" binding:"min=1,max=365"`
 }
@@ -9,6 +9,12 @@ type SendMessageResponse struct {
 	RecipientsCount   int       `json:"recipients_count"`
 	EstimatedDelivery time.Time `json:"estimated_delivery"`
 	RequestID         string    `json:"request_id"`
+	Encoding          string    `json:"encoding,omitempty"`       // SMS only: GSM-7 or UCS-2
+	Segments          int       `json:"segments,omitempty"`       // SMS only: segments per recipient
+	TotalSegments     int       `json:"total_segments,omitempty"` // SMS only: segments across all recipients
+	// DeferredRecipients is how many recipients are outside the send window
+	// and will be sent when it next opens.
+	DeferredRecipients int `json:"deferred_recipients,omitempty"`
 }
 
 // MessageStatusResponse is returned when querying the status of a message.
@@ -37,21 +43,100 @@ type DeliverySummary struct {
 	Delivered  int `json:"delivered"`
 	Failed     int `json:"failed"`
 	Pending    int `json:"pending"`
+	Scheduled  int `json:"scheduled"`
+	Cancelled  int `json:"cancelled"`
+}
+
+// Add counts n recipients in status.
+func (s *DeliverySummary) Add(status MessageStatus, n int) {
+	switch status {
+	case StatusQueued:
+		s.Queued += n
+	case StatusProcessing:
+		s.Processing += n
+	case StatusSent:
+		s.Sent += n
+	case StatusDelivered:
+		s.Delivered += n
+	case StatusFailed:
+		s.Failed += n
+	case StatusPending:
+		s.Pending += n
+	case StatusScheduled:
+		s.Scheduled += n
+	case StatusCancelled:
+		s.Cancelled += n
+	}
 }
 
 // RecipientStatus is the per-recipient delivery status in a status response.
 type RecipientStatus struct {
-	Recipient   string     `json:"recipient"`
-	Status      int        `json:"status"`
-	SentAt      *time.Time `json:"sent_at,omitempty"`
-	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	ID            string     `json:"id"`
+	Recipient     string     `json:"recipient"`
+	Status        int        `json:"status"`
+	ProviderID    *string    `json:"provider_id,omitempty"`
+	ErrorMessage  *string    `json:"error_message,omitempty"`
+	RetryCount    int        `json:"retry_count"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	DeferredUntil *time.Time `json:"deferred_until,omitempty"`
+}
+
+// RecipientDetailResponse is a single recipient of a message with the
+// history of its delivery attempts, oldest first.
+type RecipientDetailResponse struct {
+	Success   bool              `json:"success"`
+	Recipient Recipient         `json:"recipient"`
+	Attempts  []DeliveryAttempt `json:"attempts"`
+}
+
+// MessageWithRecipients is a message together with its recipient addresses.
+type MessageWithRecipients struct {
+	Message
+	Recipients []string `json:"recipients"`
+}
+
+// MessageResponse wraps a single message with its recipients.
+type MessageResponse struct {
+	Success bool                  `json:"success"`
+	Message MessageWithRecipients `json:"message"`
+}
+
+// CancelMessageResponse reports the outcome of cancelling a message.
+// RecipientsAlreadySent counts recipients that were in flight or already
+// processed when the message was cancelled, so could not be stopped.
+type CancelMessageResponse struct {
+	Success               bool   `json:"success"`
+	MessageID             string `json:"message_id"`
+	Status                string `json:"status"`
+	RecipientsCancelled   int    `json:"recipients_cancelled"`
+	RecipientsAlreadySent int    `json:"recipients_already_sent"`
 }
 
-// ListMessagesResponse is the paginated list of messages.
+// ListMessagesResponse is a page of messages, newest first. NextCursor is
+// empty on the last page. Total is set when requested with include=total, and
+// Pagination only for requests using the page parameter.
 type ListMessagesResponse struct {
-	Success    bool       `json:"success"`
-	Messages   []Message  `json:"messages"`
-	Pagination Pagination `json:"pagination"`
+	Success    bool              `json:"success"`
+	Messages   []MessageListItem `json:"messages"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	Total      *int              `json:"total,omitempty"`
+	Pagination *Pagination       `json:"pagination,omitempty"`
+}
+
+// MessageListItem is a message in a listing, with its delivery counts when
+// requested with include=summary.
+type MessageListItem struct {
+	Message
+	Summary *DeliverySummary `json:"summary,omitempty"`
+}
+
+// ListRecipientsResponse is a page of deliveries to one address, newest
+// first. NextCursor is empty on the last page.
+type ListRecipientsResponse struct {
+	Success    bool                    `json:"success"`
+	Recipients []RecipientHistoryEntry `json:"recipients"`
+	NextCursor string                  `json:"next_cursor,omitempty"`
 }
 
 // Pagination holds pagination metadata.
@@ -75,19 +160,125 @@ type ErrorDetail struct {
 	Fields  map[string]string `json:"fields,omitempty"`
 }
 
-// BulkMessageResponse is returned after a bulk send operation.
-type BulkMessageResponse struct {
+// BulkJobAcceptedResponse is returned when a bulk send is accepted for
+// background processing.
+type BulkJobAcceptedResponse struct {
+	Success bool          `json:"success"`
+	JobID   string        `json:"job_id"`
+	Status  BulkJobStatus `json:"status"`
+	Total   int           `json:"total"`
+}
+
+// BulkUploadResponse is returned when an uploaded bulk send is accepted.
+// Rejected counts the invalid rows that were skipped; Errors lists the
+// first of them.
+type BulkUploadResponse struct {
+	Success  bool           `json:"success"`
+	JobID    string         `json:"job_id"`
+	Status   BulkJobStatus  `json:"status"`
+	Total    int            `json:"total"`
+	Rejected int            `json:"rejected"`
+	Errors   []BulkRowError `json:"errors,omitempty"`
+}
+
+// BulkRowError reports an invalid row of an uploaded file by its 1-based
+// line number.
+type BulkRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// BulkJobResponse is a bulk job with its progress and a page of per-message
+// results in index order. NextCursor is empty on the last page.
+type BulkJobResponse struct {
 	Success    bool                `json:"success"`
-	Total      int                 `json:"total"`
-	Successful int                 `json:"successful"`
-	Failed     int                 `json:"failed"`
+	Job        BulkJob             `json:"job"`
+	Progress   BulkJobProgress     `json:"progress"`
 	Results    []BulkMessageResult `json:"results"`
+	NextCursor string              `json:"next_cursor,omitempty"`
 }
 
-// BulkMessageResult is the per-message result in a bulk send response.
+// ExportResponse is a message export. DownloadURL is set once it has
+// completed, until it expires.
+type ExportResponse struct {
+	Success     bool   `json:"success"`
+	Export      Export `json:"export"`
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+// BulkMessageResult is the result of one message of a bulk job.
 type BulkMessageResult struct {
-	Index     int    `json:"index"`
-	Success   bool   `json:"success"`
-	MessageID string `json:"message_id,omitempty"`
-	Error     string `json:"error,omitempty"`
+	Index     int            `json:"index"`
+	Status    BulkItemStatus `json:"status"`
+	MessageID string         `json:"message_id,omitempty"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// UsageReportResponse is the aggregated usage ledger for a date range.
+type UsageReportResponse struct {
+	Success bool             `json:"success"`
+	From    time.Time        `json:"from"`
+	To      time.Time        `json:"to"`
+	Rows    []UsageReportRow `json:"rows"`
+}
+
+// DeliveryAnalyticsResponse is delivery analytics for a time range, read
+// from the hourly rollups. UpdatedAt is when the rollups were last brought
+// up to date; recipients changed since then aren't counted yet.
+type DeliveryAnalyticsResponse struct {
+	Success     bool                 `json:"success"`
+	Granularity string               `json:"granularity"`
+	From        time.Time            `json:"from"`
+	To          time.Time            `json:"to"`
+	UpdatedAt   *time.Time           `json:"updated_at"`
+	Buckets     []DeliveryBucket     `json:"buckets"`
+	TopErrors   []DeliveryErrorCount `json:"top_errors"`
+}
+
+// APIKeyResponse is returned when a key is created or rotated.
+// Key holds the raw secret and is only ever returned once.
+type APIKeyResponse struct {
+	Success bool   `json:"success"`
+	Key     string `json:"key"`
+	APIKey  APIKey `json:"api_key"`
+}
+
+// ListAPIKeysResponse is the list of a user's API keys.
+type ListAPIKeysResponse struct {
+	Success bool     `json:"success"`
+	Keys    []APIKey `json:"keys"`
+}
+
+// UserResponse wraps a single user.
+type UserResponse struct {
+	Success bool `json:"success"`
+	User    User `json:"user"`
+}
+
+// CreateUserResponse is returned when an admin creates a user.
+// Key holds the raw first API key and is only ever returned once.
+type CreateUserResponse struct {
+	Success bool   `json:"success"`
+	User    User   `json:"user"`
+	Key     string `json:"key"`
+	APIKey  APIKey `json:"api_key"`
+}
+
+// ListUsersResponse is the paginated admin user listing.
+type ListUsersResponse struct {
+	Success    bool            `json:"success"`
+	Users      []UserWithStats `json:"users"`
+	Pagination Pagination      `json:"pagination"`
+}
+
+// ScheduleResponse wraps a single recurring schedule.
+type ScheduleResponse struct {
+	Success  bool     `json:"success"`
+	Schedule Schedule `json:"schedule"`
+}
+
+// ListSchedulesResponse is the list of a user's recurring schedules.
+type ListSchedulesResponse struct {
+	Success   bool       `json:"success"`
+	Schedules []Schedule `json:"schedules"`
 }
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"notification-system/internal/sendwindow"
+)
+
+// ScheduleStatus is the lifecycle state of a recurring schedule.
+type ScheduleStatus string
+
+const (
+	ScheduleActive    ScheduleStatus = "active"
+	SchedulePaused    ScheduleStatus = "paused"
+	ScheduleCompleted ScheduleStatus = "completed" // past its end date or last occurrence
+)
+
+// Schedule is a recurring notification. Exactly one of CronExpr and RRule is
+// set; occurrences are computed in Timezone. Each occurrence is materialized
+// as a scheduled Message with ScheduleID pointing back here.
+type Schedule struct {
+	ID         uuid.UUID          `json:"id" db:"id"`
+	UserID     uuid.UUID          `json:"user_id" db:"user_id"`
+	APIKeyID   *uuid.UUID         `json:"api_key_id,omitempty" db:"api_key_id"`
+	Name       string             `json:"name" db:"name"`
+	CronExpr   *string            `json:"cron,omitempty" db:"cron_expr"`
+	RRule      *string            `json:"rrule,omitempty" db:"rrule"`
+	Timezone   string             `json:"timezone" db:"timezone"`
+	Subject    string             `json:"subject" db:"subject"`
+	Body       string             `json:"body" db:"body"`
+	Sender     string             `json:"sender" db:"sender"`
+	Recipients pq.StringArray     `json:"recipients" db:"recipients"`
+	Platform   Platform           `json:"platform" db:"platform"`
+	Priority   Priority           `json:"priority" db:"priority"`
+	Status     ScheduleStatus     `json:"status" db:"status"`
+	StartsAt   time.Time          `json:"starts_at" db:"starts_at"`
+	EndsAt     *time.Time         `json:"ends_at,omitempty" db:"ends_at"`
+	SendWindow *sendwindow.Window `json:"send_window,omitempty" db:"send_window"`
+	NextRunAt  *time.Time         `json:"next_run_at,omitempty" db:"next_run_at"`
+	LastRunAt  *time.Time         `json:"last_run_at,omitempty" db:"last_run_at"`
+	CreatedAt  time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UsageRecord is a single entry in the usage ledger. One record is written
+// for every delivery attempt made by the worker.
+type UsageRecord struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	UserID        uuid.UUID  `json:"user_id" db:"user_id"`
+	APIKeyID      *uuid.UUID `json:"api_key_id,omitempty" db:"api_key_id"`
+	MessageID     uuid.UUID  `json:"message_id" db:"message_id"`
+	RecipientID   uuid.UUID  `json:"recipient_id" db:"recipient_id"`
+	Platform      Platform   `json:"platform" db:"platform"`
+	Provider      string     `json:"provider" db:"provider"`
+	Encoding      *string    `json:"encoding,omitempty" db:"encoding"`
+	Segments      int        `json:"segments" db:"segments"`
+	Success       bool       `json:"success" db:"success"`
+	EstimatedCost float64    `json:"estimated_cost" db:"estimated_cost"`
+	Currency      string     `json:"currency" db:"currency"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+// UsageReportQuery represents the query parameters for the usage report.
+type UsageReportQuery struct {
+	From     *time.Time `form:"from"`
+	To       *time.Time `form:"to"`
+	Platform string     `form:"platform" binding:"omitempty,oneof=sms whatsapp telegram email"`
+	Format   string     `form:"format" binding:"omitempty,oneof=json csv"`
+}
+
+// UsageReportFilter narrows the usage ledger aggregation.
+// A nil UserID aggregates across all users.
+type UsageReportFilter struct {
+	UserID   *uuid.UUID
+	From     time.Time
+	To       time.Time
+	Platform string
+}
+
+// UsageReportRow is one aggregated row of the usage report,
+// grouped by day, platform and API key.
+type UsageReportRow struct {
+	Day           string     `json:"day" db:"day"`
+	Platform      string     `json:"platform" db:"platform"`
+	UserID        uuid.UUID  `json:"user_id" db:"user_id"`
+	Email         string     `json:"email" db:"email"`
+	APIKeyID      *uuid.UUID `json:"api_key_id" db:"api_key_id"`
+	APIKeyName    *string    `json:"api_key_name" db:"api_key_name"`
+	APIKeyPrefix  *string    `json:"api_key_prefix" db:"api_key_prefix"`
+	Attempts      int        `json:"attempts" db:"attempts"`
+	Successful    int        `json:"successful" db:"successful"`
+	Failed        int        `json:"failed" db:"failed"`
+	Segments      int        `json:"segments" db:"segments"`
+	EstimatedCost float64    `json:"estimated_cost" db:"estimated_cost"`
+	Currency      string     `json:"currency" db:"currency"`
+}
@@ -4,16 +4,33 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"notification-system/internal/sendwindow"
 )
 
 // User represents a registered API user.
 type User struct {
 	ID            uuid.UUID `json:"id" db:"id"`
 	Email         string    `json:"email" db:"email"`
-	APIKeyHash    string    `json:"-" db:"api_key_hash"`
 	Role          string    `json:"role" db:"role"`
 	RateLimitTier string    `json:"rate_limit_tier" db:"rate_limit_tier"`
 	IsActive      bool      `json:"is_active" db:"is_active"`
-	CreatedAt     time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+	// SendWindow is the default send window for the user's messages and schedules.
+	SendWindow *sendwindow.Window `json:"send_window,omitempty" db:"send_window"`
+	CreatedAt  time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// User roles.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// UserWithStats is a user together with activity figures for the admin listing.
+type UserWithStats struct {
+	User
+	ActiveKeys    int     `json:"active_keys" db:"active_keys"`
+	MessagesSent  int     `json:"messages_sent" db:"messages_sent"`
+	EstimatedCost float64 `json:"estimated_cost" db:"estimated_cost"`
 }
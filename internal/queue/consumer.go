@@ -2,27 +2,56 @@ package queue
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"time"
 
+	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"notification-system/internal/metrics"
+	"notification-system/internal/tracing"
+	"notification-system/pkg/requestid"
 )
 
 // Consumer handles consuming messages from RabbitMQ.
 type Consumer struct {
-	rmq *RabbitMQ
+	rmq          *RabbitMQ
+	drainTimeout time.Duration
 }
 
-// NewConsumer creates a new Consumer.
-func NewConsumer(rmq *RabbitMQ) *Consumer {
-	return &Consumer{rmq: rmq}
+// NewConsumer creates a new Consumer. drainTimeout bounds how long a
+// delivery that is already being handled may take to finish once consuming
+// is stopped.
+func NewConsumer(rmq *RabbitMQ, drainTimeout time.Duration) *Consumer {
+	if drainTimeout == 0 {
+		drainTimeout = 30 * time.Second
+	}
+	return &Consumer{rmq: rmq, drainTimeout: drainTimeout}
 }
 
-// HandlerFunc is the function signature for processing messages.
+// HandlerFunc is the function signature for processing messages. ctx carries
+// the publisher's request ID when the delivery has one, and a span continuing
+// the publisher's trace.
 type HandlerFunc func(ctx context.Context, body []byte) error
 
-// Consume starts consuming messages from the specified queue.
-// This is a blocking call.
-func (c *Consumer) Consume(ctx context.Context, queueName, routingKey string, handler HandlerFunc) error {
+// ErrRequeue can be wrapped in a HandlerFunc's error to have the delivery
+// requeued instead of dropped. A delivery is only requeued once; if it fails
+// the same way when redelivered, it is dropped.
+var ErrRequeue = errors.New("requeue delivery")
+
+// Consume starts consuming messages from the specified queue, handling up to
+// opts.Concurrency deliveries at once. This is a blocking call. When ctx is
+// cancelled the subscription is cancelled with basic.cancel, prefetched
+// deliveries that were never started are requeued, and Consume returns once
+// in-flight deliveries have been handled and acked. Handlers run with a
+// context that outlives ctx by at most the drain timeout.
+func (c *Consumer) Consume(ctx context.Context, queueName, routingKey string, handler HandlerFunc, opts ConsumeOptions) error {
 	ch := c.rmq.Channel()
 
 	// Declare queue
@@ -50,9 +79,10 @@ func (c *Consumer) Consume(ctx context.Context, queueName, routingKey string, ha
 	}
 
 	// Start consuming
+	tag := consumerTag(queueName)
 	msgs, err := ch.Consume(
 		q.Name, // queue
-		"",     // consumer tag (auto-generated)
+		tag,    // consumer tag
 		false,  // auto-ack (we will manual ack)
 		false,  // exclusive
 		false,  // no-local
@@ -63,30 +93,126 @@ func (c *Consumer) Consume(ctx context.Context, queueName, routingKey string, ha
 		return fmt.Errorf("failed to start consuming: %w", err)
 	}
 
-	log.Info().Str("queue", queueName).Msg("started consuming messages")
+	log.Info().
+		Str("queue", queueName).
+		Str("consumer_tag", tag).
+		Int("concurrency", opts.Concurrency).
+		Bool("ordered", opts.OrderingKey != nil).
+		Msg("started consuming messages")
+
+	// Deliveries that have started are finished even after ctx is cancelled,
+	// so a message sent to the provider is acked rather than redelivered.
+	handlerCtx, cancelHandlers := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelHandlers()
+	go c.enforceDrainTimeout(ctx, handlerCtx, cancelHandlers, queueName)
 
-	// Process messages
+	pool := newDeliveryPool(handlerCtx, opts, func(hctx context.Context, d amqp.Delivery) {
+		c.handle(hctx, queueName, d, handler)
+	})
+	defer pool.close()
+
+	// Dispatch messages
 	for {
 		select {
 		case <-ctx.Done():
-			log.Info().Str("queue", queueName).Msg("stopping consumer")
+			c.stop(ch, tag, queueName, msgs)
 			return nil
 		case d, ok := <-msgs:
 			if !ok {
 				return fmt.Errorf("channel closed")
 			}
-
-			// Process message
-			if err := handler(ctx, d.Body); err != nil {
-				log.Error().Err(err).Str("message_id", d.MessageId).Msg("failed to process message")
-				// Nack (requeue or dead-letter)
-				// For now, let's Nack with requeue=false (assuming DLQ configured or just drop)
-				// Or requeue=true if transient?
-				// Simple strategy: Nack(false)
-				d.Nack(false, false)
-			} else {
-				d.Ack(false)
+			if ctx.Err() != nil || !pool.submit(ctx, d) {
+				d.Nack(false, true)
 			}
 		}
 	}
 }
+
+// stop cancels the subscription so the broker sends nothing more, then
+// requeues deliveries that were prefetched but never handled.
+func (c *Consumer) stop(ch *amqp.Channel, tag, queueName string, msgs <-chan amqp.Delivery) {
+	log.Info().Str("queue", queueName).Msg("stopping consumer")
+
+	if err := ch.Cancel(tag, false); err != nil {
+		// The broker requeues unacked deliveries when the channel closes.
+		log.Error().Err(err).Str("queue", queueName).Msg("failed to cancel consumer")
+		return
+	}
+
+	requeued := 0
+	for d := range msgs {
+		d.Nack(false, true)
+		requeued++
+	}
+
+	log.Info().Str("queue", queueName).Int("requeued", requeued).Msg("consumer cancelled")
+}
+
+// enforceDrainTimeout cancels handlerCtx if handlers are still running
+// drainTimeout after ctx is cancelled.
+func (c *Consumer) enforceDrainTimeout(ctx, handlerCtx context.Context, cancelHandlers context.CancelFunc, queueName string) {
+	select {
+	case <-ctx.Done():
+	case <-handlerCtx.Done():
+		return
+	}
+
+	timer := time.NewTimer(c.drainTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		log.Warn().Str("queue", queueName).Dur("drain_timeout", c.drainTimeout).Msg("drain timeout reached, aborting in-flight delivery")
+		cancelHandlers()
+	case <-handlerCtx.Done():
+	}
+}
+
+// consumerTag returns a tag identifying this process's subscription to
+// queueName, so it can be cancelled and recognised in the management UI.
+func consumerTag(queueName string) string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "worker"
+	}
+	return fmt.Sprintf("%s.%s.%d", queueName, host, os.Getpid())
+}
+
+// handle runs handler for a single delivery inside a consumer span and acks
+// or nacks it depending on the result. It is called concurrently from the
+// delivery pool; acks are per delivery tag, so their order doesn't matter.
+func (c *Consumer) handle(ctx context.Context, queueName string, d amqp.Delivery, handler HandlerFunc) {
+	if d.Headers != nil {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, amqpHeaderCarrier(d.Headers))
+	}
+	ctx, span := tracing.Tracer().Start(ctx, "process "+queueName,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemRabbitMQ,
+			semconv.MessagingOperationTypeProcess,
+			semconv.MessagingDestinationName(d.Exchange),
+			semconv.MessagingRabbitMQDestinationRoutingKey(d.RoutingKey),
+			semconv.MessagingConsumerGroupName(queueName),
+		),
+	)
+	defer span.End()
+
+	if d.Redelivered {
+		metrics.MessageRedeliveriesTotal.WithLabelValues(queueName).Inc()
+	}
+
+	reqID, _ := d.Headers[requestid.AMQPHeader].(string)
+	if reqID != "" {
+		ctx = requestid.NewContext(ctx, reqID)
+	}
+	if err := handler(ctx, d.Body); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		requeue := errors.Is(err, ErrRequeue) && !d.Redelivered
+		log.Error().Err(err).Str("request_id", reqID).Str("message_id", d.MessageId).Bool("requeue", requeue).Msg("failed to process message")
+		// Dropped deliveries go to the dead-letter exchange if one is configured
+		d.Nack(false, requeue)
+	} else {
+		d.Ack(false)
+	}
+}
@@ -0,0 +1,91 @@
+package queue
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ConsumeOptions controls how a queue's deliveries are processed.
+type ConsumeOptions struct {
+	// Concurrency is the number of deliveries handled at once. Values below
+	// 1 mean 1. It is capped in practice by the channel's prefetch count.
+	Concurrency int
+
+	// OrderingKey, when set, returns a key for a delivery body. Deliveries
+	// with the same key are handled one at a time, in delivery order.
+	OrderingKey func(body []byte) string
+}
+
+// deliveryPool hands deliveries to a fixed set of goroutines over unbuffered
+// lanes, so at most one delivery per goroutine is held outside the broker's
+// prefetch window. Without an ordering key all goroutines share one lane;
+// with one, each goroutine owns a lane and keys are hashed onto lanes.
+type deliveryPool struct {
+	lanes []chan amqp.Delivery
+	key   func(body []byte) string
+	wg    sync.WaitGroup
+}
+
+func newDeliveryPool(ctx context.Context, opts ConsumeOptions, handle func(context.Context, amqp.Delivery)) *deliveryPool {
+	size := opts.Concurrency
+	if size < 1 {
+		size = 1
+	}
+
+	lanes, perLane := 1, size
+	if opts.OrderingKey != nil {
+		lanes, perLane = size, 1
+	}
+
+	p := &deliveryPool{
+		lanes: make([]chan amqp.Delivery, lanes),
+		key:   opts.OrderingKey,
+	}
+	for i := range p.lanes {
+		lane := make(chan amqp.Delivery)
+		p.lanes[i] = lane
+		for j := 0; j < perLane; j++ {
+			p.wg.Add(1)
+			go func() {
+				defer p.wg.Done()
+				for d := range lane {
+					handle(ctx, d)
+				}
+			}()
+		}
+	}
+
+	return p
+}
+
+// submit blocks until a goroutine takes d, and reports false if ctx was
+// cancelled first.
+func (p *deliveryPool) submit(ctx context.Context, d amqp.Delivery) bool {
+	select {
+	case p.lanes[p.lane(d)] <- d:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// lane returns the index of the lane d is handled on.
+func (p *deliveryPool) lane(d amqp.Delivery) int {
+	if len(p.lanes) == 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	h.Write([]byte(p.key(d.Body)))
+	return int(h.Sum32() % uint32(len(p.lanes)))
+}
+
+// close stops the pool and waits for running handlers to return.
+func (p *deliveryPool) close() {
+	for _, lane := range p.lanes {
+		close(lane)
+	}
+	p.wg.Wait()
+}
@@ -0,0 +1,252 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// toKey orders deliveries by their "to" field, like worker.RecipientKey.
+func toKey(body []byte) string {
+	var event struct {
+		To string `json:"to"`
+	}
+	json.Unmarshal(body, &event)
+	return event.To
+}
+
+func delivery(tag uint64, to string) amqp.Delivery {
+	body, _ := json.Marshal(map[string]string{"to": to})
+	return amqp.Delivery{DeliveryTag: tag, Body: body}
+}
+
+// gauge tracks how many handlers are running and the most seen at once.
+type gauge struct {
+	cur, max atomic.Int32
+}
+
+func (g *gauge) enter() {
+	n := g.cur.Add(1)
+	for {
+		m := g.max.Load()
+		if n <= m || g.max.CompareAndSwap(m, n) {
+			return
+		}
+	}
+}
+
+func (g *gauge) leave() {
+	g.cur.Add(-1)
+}
+
+func TestDeliveryPoolOrdersByKey(t *testing.T) {
+	const recipients, perRecipient, concurrency = 8, 50, 4
+
+	var (
+		mu       sync.Mutex
+		handled  = make(map[string][]uint64)
+		inFlight = make(map[string]bool)
+		overlaps int
+		running  gauge
+	)
+	pool := newDeliveryPool(context.Background(), ConsumeOptions{Concurrency: concurrency, OrderingKey: toKey}, func(_ context.Context, d amqp.Delivery) {
+		running.enter()
+		defer running.leave()
+
+		key := toKey(d.Body)
+		mu.Lock()
+		if inFlight[key] {
+			overlaps++
+		}
+		inFlight[key] = true
+		mu.Unlock()
+
+		time.Sleep(time.Duration(d.DeliveryTag%3) * 100 * time.Microsecond)
+
+		mu.Lock()
+		inFlight[key] = false
+		handled[key] = append(handled[key], d.DeliveryTag)
+		mu.Unlock()
+	})
+
+	// Interleave recipients the way a busy queue would.
+	var tag uint64
+	for i := 0; i < perRecipient; i++ {
+		for r := 0; r < recipients; r++ {
+			tag++
+			if !pool.submit(context.Background(), delivery(tag, fmt.Sprintf("+1555010%d", r))) {
+				t.Fatalf("submit %d was rejected", tag)
+			}
+		}
+	}
+	pool.close()
+
+	if overlaps > 0 {
+		t.Errorf("%d deliveries ran alongside another for the same recipient", overlaps)
+	}
+	if got := running.max.Load(); got > concurrency {
+		t.Errorf("%d deliveries ran at once, want at most %d", got, concurrency)
+	}
+	if len(handled) != recipients {
+		t.Fatalf("handled deliveries for %d recipients, want %d", len(handled), recipients)
+	}
+	for key, tags := range handled {
+		if len(tags) != perRecipient {
+			t.Errorf("%s: handled %d deliveries, want %d", key, len(tags), perRecipient)
+		}
+		for i := 1; i < len(tags); i++ {
+			if tags[i] < tags[i-1] {
+				t.Errorf("%s: delivery %d handled after %d", key, tags[i], tags[i-1])
+				break
+			}
+		}
+	}
+}
+
+func TestDeliveryPoolBoundsConcurrency(t *testing.T) {
+	for _, opts := range []ConsumeOptions{
+		{Concurrency: 3},
+		{Concurrency: 3, OrderingKey: toKey},
+	} {
+		t.Run(fmt.Sprintf("ordered=%t", opts.OrderingKey != nil), func(t *testing.T) {
+			release := make(chan struct{})
+			var running gauge
+			pool := newDeliveryPool(context.Background(), opts, func(_ context.Context, d amqp.Delivery) {
+				running.enter()
+				defer running.leave()
+				<-release
+			})
+
+			// Distinct recipients that land on distinct lanes, so every
+			// goroutine can be kept busy.
+			lanes := make(map[int]bool)
+			var tag uint64
+			for len(lanes) < opts.Concurrency {
+				tag++
+				d := delivery(tag, fmt.Sprintf("+1555%04d", tag))
+				lane := len(lanes)
+				if opts.OrderingKey != nil {
+					lane = pool.lane(d)
+				}
+				if lanes[lane] {
+					continue
+				}
+				lanes[lane] = true
+				if !pool.submit(context.Background(), d) {
+					t.Fatalf("submit %d was rejected", tag)
+				}
+			}
+
+			// Every goroutine is busy, so the next delivery isn't taken.
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			if pool.submit(ctx, delivery(tag+1, fmt.Sprintf("+1555%04d", tag+1))) {
+				t.Error("submit succeeded while every handler was busy")
+			}
+
+			close(release)
+			pool.close()
+			if got := running.max.Load(); got != int32(opts.Concurrency) {
+				t.Errorf("%d deliveries ran at once, want %d", got, opts.Concurrency)
+			}
+		})
+	}
+}
+
+func TestDeliveryPoolDrainsOnCancel(t *testing.T) {
+	handlerCtx, cancelHandlers := context.WithCancel(context.Background())
+	defer cancelHandlers()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var finished atomic.Bool
+	pool := newDeliveryPool(handlerCtx, ConsumeOptions{Concurrency: 1}, func(ctx context.Context, d amqp.Delivery) {
+		close(started)
+		<-release
+		if ctx.Err() != nil {
+			t.Errorf("handler context cancelled while draining: %v", ctx.Err())
+		}
+		finished.Store(true)
+	})
+
+	consumeCtx, stopConsuming := context.WithCancel(context.Background())
+	if !pool.submit(consumeCtx, delivery(1, "+15550100")) {
+		t.Fatal("first submit was rejected")
+	}
+	<-started
+
+	// Once consuming stops, new deliveries are refused rather than queued
+	// behind the one in flight.
+	stopConsuming()
+	if pool.submit(consumeCtx, delivery(2, "+15550101")) {
+		t.Error("submit succeeded after the consume context was cancelled")
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		pool.close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+		t.Fatal("close returned while a delivery was still being handled")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("close didn't return after the in-flight delivery finished")
+	}
+	if !finished.Load() {
+		t.Error("in-flight delivery didn't finish")
+	}
+}
+
+func TestConsumerDrainTimeout(t *testing.T) {
+	c := &Consumer{drainTimeout: 20 * time.Millisecond}
+
+	t.Run("aborts handlers that overrun", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		handlerCtx, cancelHandlers := context.WithCancel(context.WithoutCancel(ctx))
+		defer cancelHandlers()
+		go c.enforceDrainTimeout(ctx, handlerCtx, cancelHandlers, "test")
+
+		cancel()
+		if handlerCtx.Err() != nil {
+			t.Fatal("handler context cancelled before the drain timeout")
+		}
+		select {
+		case <-handlerCtx.Done():
+		case <-time.After(time.Second):
+			t.Fatal("handler context not cancelled after the drain timeout")
+		}
+	})
+
+	t.Run("returns once handlers finish", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		handlerCtx, cancelHandlers := context.WithCancel(context.WithoutCancel(ctx))
+
+		done := make(chan struct{})
+		go func() {
+			c.enforceDrainTimeout(ctx, handlerCtx, cancelHandlers, "test")
+			close(done)
+		}()
+
+		cancelHandlers()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("enforceDrainTimeout didn't return after handlers finished")
+		}
+	})
+}
@@ -8,47 +8,88 @@ import (
 
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"notification-system/internal/metrics"
+	"notification-system/internal/tracing"
+	"notification-system/pkg/requestid"
 )
 
+// publishChannel is the part of *amqp.Channel the Publisher uses.
+type publishChannel interface {
+	PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+}
+
 // Publisher handles publishing messages to RabbitMQ.
 type Publisher struct {
-	rmq *RabbitMQ
+	ch publishChannel
 }
 
 // NewPublisher creates a new Publisher.
 func NewPublisher(rmq *RabbitMQ) *Publisher {
-	return &Publisher{rmq: rmq}
+	return &Publisher{ch: rmq.Channel()}
 }
 
 // Publish publishes a message to the specified exchange and routing key.
+// The request ID and trace context carried by ctx, if any, are sent as AMQP
+// headers.
 func (p *Publisher) Publish(ctx context.Context, exchange, routingKey string, body interface{}) error {
 	bytes, err := json.Marshal(body)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 
-	err = p.rmq.Channel().PublishWithContext(ctx,
+	ctx, span := tracing.Tracer().Start(ctx, "publish "+routingKey,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemRabbitMQ,
+			semconv.MessagingOperationTypeSend,
+			semconv.MessagingDestinationName(exchange),
+			semconv.MessagingRabbitMQDestinationRoutingKey(routingKey),
+		),
+	)
+	defer span.End()
+
+	reqID := requestid.FromContext(ctx)
+	headers := amqp.Table{}
+	if reqID != "" {
+		headers[requestid.AMQPHeader] = reqID
+	}
+	otel.GetTextMapPropagator().Inject(ctx, amqpHeaderCarrier(headers))
+
+	err = p.ch.PublishWithContext(ctx,
 		exchange,   // exchange
 		routingKey, // routing key
 		false,      // mandatory
 		false,      // immediate
 		amqp.Publishing{
-			ContentType:  "application/json",
-			Body:         bytes,
-			DeliveryMode: amqp.Persistent,
-			Timestamp:    time.Now(),
+			ContentType:   "application/json",
+			Body:          bytes,
+			DeliveryMode:  amqp.Persistent,
+			Timestamp:     time.Now(),
+			Headers:       headers,
+			CorrelationId: reqID,
 		},
 	)
 
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		log.Error().Err(err).
+			Str("request_id", reqID).
 			Str("exchange", exchange).
 			Str("routing_key", routingKey).
 			Msg("failed to publish message")
 		return fmt.Errorf("failed to publish message: %w", err)
 	}
 
+	metrics.MessagesPublishedTotal.Inc()
+
 	log.Debug().
+		Str("request_id", reqID).
 		Str("exchange", exchange).
 		Str("routing_key", routingKey).
 		Msg("published message to queue")
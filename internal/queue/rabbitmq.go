@@ -1,6 +1,8 @@
 package queue
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	amqp "github.com/rabbitmq/amqp091-go"
@@ -48,6 +50,18 @@ func (r *RabbitMQ) Close() {
 	}
 }
 
+// Check reports whether the connection and channel are still open, for use
+// as a readiness check.
+func (r *RabbitMQ) Check(ctx context.Context) error {
+	if r.conn == nil || r.conn.IsClosed() {
+		return errors.New("connection closed")
+	}
+	if r.channel == nil || r.channel.IsClosed() {
+		return errors.New("channel closed")
+	}
+	return nil
+}
+
 // DeclareExchange declares a topic exchange.
 func (r *RabbitMQ) DeclareExchange(name string) error {
 	return r.channel.ExchangeDeclare(
@@ -0,0 +1,103 @@
+package queue
+
+import (
+	"context"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"notification-system/pkg/requestid"
+)
+
+// captureChannel records what the Publisher sends instead of talking to a
+// broker.
+type captureChannel struct {
+	published []amqp.Delivery
+}
+
+func (c *captureChannel) PublishWithContext(_ context.Context, exchange, key string, _, _ bool, msg amqp.Publishing) error {
+	c.published = append(c.published, amqp.Delivery{
+		Exchange:      exchange,
+		RoutingKey:    key,
+		Headers:       msg.Headers,
+		Body:          msg.Body,
+		CorrelationId: msg.CorrelationId,
+	})
+	return nil
+}
+
+// recordSpans installs a tracer provider that records ended spans, and the
+// propagator tracing.Init installs, for the duration of the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return recorder
+}
+
+func TestTraceContextCrossesQueue(t *testing.T) {
+	recorder := recordSpans(t)
+	ch := &captureChannel{}
+	publisher := &Publisher{ch: ch}
+
+	ctx := requestid.NewContext(context.Background(), "req-1")
+	event := MessageQueuedEvent{MessageID: "m-1", RecipientID: "r-1", To: "+15550100", Body: "hi", Platform: "sms"}
+	if err := publisher.Publish(ctx, ExchangeName, RoutingKeySMS, event); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if len(ch.published) != 1 {
+		t.Fatalf("published %d messages, want 1", len(ch.published))
+	}
+
+	var handlerSpan trace.SpanContext
+	var handlerReqID string
+	consumer := &Consumer{}
+	consumer.handle(context.Background(), "sms_queue", ch.published[0], func(ctx context.Context, body []byte) error {
+		handlerSpan = trace.SpanContextFromContext(ctx)
+		handlerReqID = requestid.FromContext(ctx)
+		return nil
+	})
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+	publish, process := spans[0], spans[1]
+
+	if publish.SpanKind() != trace.SpanKindProducer || publish.Name() != "publish "+RoutingKeySMS {
+		t.Errorf("first span is %s %q, want producer %q", publish.SpanKind(), publish.Name(), "publish "+RoutingKeySMS)
+	}
+	if process.SpanKind() != trace.SpanKindConsumer || process.Name() != "process sms_queue" {
+		t.Errorf("second span is %s %q, want consumer %q", process.SpanKind(), process.Name(), "process sms_queue")
+	}
+
+	parent := process.Parent()
+	if !parent.IsRemote() {
+		t.Error("consumer span's parent is not remote; it wasn't extracted from the headers")
+	}
+	if parent.TraceID() != publish.SpanContext().TraceID() || parent.SpanID() != publish.SpanContext().SpanID() {
+		t.Errorf("consumer span's parent is %s/%s, want the publisher span %s/%s",
+			parent.TraceID(), parent.SpanID(), publish.SpanContext().TraceID(), publish.SpanContext().SpanID())
+	}
+	if !handlerSpan.Equal(process.SpanContext()) {
+		t.Error("handler context doesn't carry the consumer span")
+	}
+	if handlerReqID != "req-1" {
+		t.Errorf("handler request ID is %q, want req-1", handlerReqID)
+	}
+}
@@ -1,6 +1,10 @@
 package queue
 
-import "time"
+import (
+	"time"
+
+	"notification-system/internal/sendwindow"
+)
 
 // Exchange and Routing Keys
 const (
@@ -16,10 +20,15 @@ const (
 type MessageQueuedEvent struct {
 	MessageID   string            `json:"message_id"`
 	RecipientID string            `json:"recipient_id"`
+	UserID      string            `json:"user_id,omitempty"`
+	APIKeyID    string            `json:"api_key_id,omitempty"`
 	To          string            `json:"to"`
 	Body        string            `json:"body"`
 	Subject     string            `json:"subject,omitempty"`
 	Platform    string            `json:"platform"`
 	Metadata    map[string]string `json:"metadata,omitempty"`
-	Timestamp   time.Time         `json:"timestamp"`
+	// SendWindow is set when the recipient must only be contacted inside it;
+	// a worker that picks the event up outside the window defers it again.
+	SendWindow *sendwindow.Window `json:"send_window,omitempty"`
+	Timestamp  time.Time          `json:"timestamp"`
 }
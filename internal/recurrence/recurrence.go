@@ -0,0 +1,191 @@
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/teambition/rrule-go"
+)
+
+// MinInterval is the shortest allowed gap between two occurrences.
+const MinInterval = time.Minute
+
+var (
+	// ErrInvalidRule is returned when a cron expression or RRULE can't be parsed.
+	ErrInvalidRule = errors.New("invalid recurrence rule")
+	// ErrInvalidTimezone is returned for an unknown IANA timezone name.
+	ErrInvalidTimezone = errors.New("invalid timezone")
+	// ErrTooFrequent is returned when occurrences are closer than MinInterval.
+	ErrTooFrequent = errors.New("recurrence is too frequent")
+)
+
+// Rule yields the occurrences of a recurring schedule.
+type Rule interface {
+	// Next returns the first occurrence strictly after t, or the zero time
+	// when there are no more.
+	Next(t time.Time) time.Time
+}
+
+// Parse builds a Rule from exactly one of a standard five-field cron
+// expression (or descriptor such as "@daily") and an iCalendar RRULE.
+// Occurrences are wall-clock times in the named IANA timezone, so "08:00
+// every day" stays at 08:00 local time across DST changes:
+//
+//   - a wall time skipped by a spring-forward transition fires at the
+//     equivalent instant after the jump (02:30 becomes 03:30);
+//   - a wall time repeated by a fall-back transition fires once, at its
+//     first occurrence.
+//
+// start anchors RRULEs that don't carry their own DTSTART.
+func Parse(cronExpr, rule, timezone string, start time.Time) (Rule, error) {
+	loc, err := LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	var w wallRule
+	switch {
+	case cronExpr != "" && rule != "":
+		return nil, fmt.Errorf("%w: set either cron or rrule, not both", ErrInvalidRule)
+	case cronExpr != "":
+		sched, err := cron.ParseStandard(cronExpr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+		}
+		w = cronRule{sched: sched}
+	case rule != "":
+		w, err = parseRRule(rule, toWall(start, loc))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: cron or rrule is required", ErrInvalidRule)
+	}
+
+	r := zonedRule{wall: w, loc: loc}
+	if err := checkInterval(r, start); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// LoadLocation resolves an IANA timezone name; empty means UTC.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTimezone, name)
+	}
+	return loc, nil
+}
+
+// wallRule yields occurrences as wall-clock times. Wall times are carried in
+// time.UTC, used as a floating timezone with no transitions.
+type wallRule interface {
+	next(w time.Time) time.Time
+}
+
+type cronRule struct {
+	sched cron.Schedule
+}
+
+func (c cronRule) next(w time.Time) time.Time {
+	return c.sched.Next(w)
+}
+
+type rruleRule struct {
+	rule *rrule.RRule
+}
+
+func (r rruleRule) next(w time.Time) time.Time {
+	return r.rule.After(w, false)
+}
+
+func parseRRule(s string, start time.Time) (wallRule, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "RRULE:")
+
+	opt, err := rrule.StrToROptionInLocation(s, time.UTC)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+	if opt.Dtstart.IsZero() {
+		opt.Dtstart = start
+	} else {
+		opt.Dtstart = toWall(opt.Dtstart, opt.Dtstart.Location())
+	}
+
+	r, err := rrule.NewRRule(*opt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+	return rruleRule{rule: r}, nil
+}
+
+// zonedRule maps a wallRule's occurrences onto instants in loc.
+type zonedRule struct {
+	wall wallRule
+	loc  *time.Location
+}
+
+func (z zonedRule) Next(t time.Time) time.Time {
+	w := toWall(t, z.loc)
+	for {
+		w = z.wall.next(w)
+		if w.IsZero() {
+			return time.Time{}
+		}
+		// During a repeated hour, wall times already passed on the first
+		// pass map to instants before t and are skipped.
+		if at := fromWall(w, z.loc); at.After(t) {
+			return at
+		}
+	}
+}
+
+// toWall returns t's wall-clock time in loc as a floating (UTC) time.
+func toWall(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// fromWall returns the instant at which loc's clocks show wall time w,
+// choosing the earlier instant when w occurs twice and the instant after the
+// jump when w is skipped.
+func fromWall(w time.Time, loc *time.Location) time.Time {
+	// Offsets either side of any transition near w; no zone changes offset
+	// twice within a day.
+	_, before := w.Add(-26 * time.Hour).In(loc).Zone()
+	_, after := w.Add(26 * time.Hour).In(loc).Zone()
+
+	var at time.Time
+	for _, off := range []int{before, after} {
+		candidate := w.Add(-time.Duration(off) * time.Second)
+		if toWall(candidate, loc).Equal(w) && (at.IsZero() || candidate.Before(at)) {
+			at = candidate
+		}
+	}
+	if at.IsZero() {
+		at = w.Add(-time.Duration(before) * time.Second)
+	}
+	return at.In(loc)
+}
+
+// checkInterval rejects rules whose first two occurrences after start are
+// closer together than MinInterval.
+func checkInterval(r Rule, start time.Time) error {
+	first := r.Next(start)
+	if first.IsZero() {
+		return nil
+	}
+	second := r.Next(first)
+	if !second.IsZero() && second.Sub(first) < MinInterval {
+		return fmt.Errorf("%w: occurrences must be at least %s apart", ErrTooFrequent, MinInterval)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package recurrence
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load %s: %v", name, err)
+	}
+	return loc
+}
+
+func utc(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestRuleOccurrences(t *testing.T) {
+	tests := []struct {
+		name     string
+		cron     string
+		rrule    string
+		timezone string
+		from     time.Time
+		want     []time.Time // zero marks the end of the rule
+	}{
+		{
+			name:     "cron keeps local time across spring forward",
+			cron:     "0 8 * * *",
+			timezone: "America/New_York",
+			from:     utc("2025-03-08T00:00:00Z"),
+			want:     []time.Time{utc("2025-03-08T13:00:00Z"), utc("2025-03-09T12:00:00Z"), utc("2025-03-10T12:00:00Z")},
+		},
+		{
+			name:     "time skipped by spring forward fires after the jump",
+			cron:     "30 2 * * *",
+			timezone: "America/New_York",
+			from:     utc("2025-03-08T05:00:00Z"),
+			// 02:30 EST, 03:30 EDT (02:30 doesn't exist), 02:30 EDT.
+			want: []time.Time{utc("2025-03-08T07:30:00Z"), utc("2025-03-09T07:30:00Z"), utc("2025-03-10T06:30:00Z")},
+		},
+		{
+			name:     "time repeated by fall back fires once",
+			cron:     "30 1 * * *",
+			timezone: "America/New_York",
+			from:     utc("2025-11-01T12:00:00Z"),
+			// 01:30 EDT, then not again at 01:30 EST, then 01:30 EST the next day.
+			want: []time.Time{utc("2025-11-02T05:30:00Z"), utc("2025-11-03T06:30:00Z")},
+		},
+		{
+			name:     "hourly cron skips the repeated hour",
+			cron:     "0 * * * *",
+			timezone: "America/New_York",
+			from:     utc("2025-11-02T04:30:00Z"),
+			// 01:00 EDT, 02:00 EST; 01:00 EST is the repeated hour.
+			want: []time.Time{utc("2025-11-02T05:00:00Z"), utc("2025-11-02T07:00:00Z"), utc("2025-11-02T08:00:00Z")},
+		},
+		{
+			name:     "cron descriptor",
+			cron:     "@daily",
+			timezone: "Asia/Tokyo",
+			from:     utc("2025-01-01T00:00:00Z"),
+			want:     []time.Time{utc("2025-01-01T15:00:00Z"), utc("2025-01-02T15:00:00Z")},
+		},
+		{
+			name:     "rrule keeps local time across spring forward",
+			rrule:    "FREQ=DAILY;BYHOUR=8;BYMINUTE=0;BYSECOND=0",
+			timezone: "Europe/Berlin",
+			from:     utc("2025-03-28T23:00:00Z"),
+			want:     []time.Time{utc("2025-03-29T07:00:00Z"), utc("2025-03-30T06:00:00Z"), utc("2025-03-31T06:00:00Z")},
+		},
+		{
+			name:     "rrule time repeated by fall back fires once",
+			rrule:    "RRULE:FREQ=DAILY;BYHOUR=2;BYMINUTE=30;BYSECOND=0",
+			timezone: "Europe/Berlin",
+			from:     utc("2025-10-25T12:00:00Z"),
+			// 02:30 CEST, then not again at 02:30 CET, then 02:30 CET.
+			want: []time.Time{utc("2025-10-26T00:30:00Z"), utc("2025-10-27T01:30:00Z")},
+		},
+		{
+			name:     "rrule weekly by day",
+			rrule:    "FREQ=WEEKLY;BYDAY=MO,FR;BYHOUR=9;BYMINUTE=0;BYSECOND=0",
+			timezone: "UTC",
+			from:     utc("2025-01-01T00:00:00Z"), // a Wednesday
+			want:     []time.Time{utc("2025-01-03T09:00:00Z"), utc("2025-01-06T09:00:00Z"), utc("2025-01-10T09:00:00Z")},
+		},
+		{
+			name:     "rrule until is a local time",
+			rrule:    "FREQ=DAILY;BYHOUR=9;BYMINUTE=0;BYSECOND=0;UNTIL=20250102T235959Z",
+			timezone: "Asia/Tokyo",
+			from:     utc("2024-12-31T15:00:00Z"),
+			want:     []time.Time{utc("2025-01-01T00:00:00Z"), utc("2025-01-02T00:00:00Z"), {}},
+		},
+		{
+			name:     "rrule count",
+			rrule:    "FREQ=DAILY;COUNT=2;BYHOUR=9;BYMINUTE=0;BYSECOND=0",
+			timezone: "UTC",
+			from:     utc("2025-01-01T00:00:00Z"),
+			want:     []time.Time{utc("2025-01-01T09:00:00Z"), utc("2025-01-02T09:00:00Z"), {}},
+		},
+		{
+			name:     "rrule dtstart overrides start",
+			rrule:    "DTSTART=20250105T090000Z;FREQ=DAILY;COUNT=2",
+			timezone: "Asia/Tokyo",
+			from:     utc("2025-01-01T00:00:00Z"),
+			want:     []time.Time{utc("2025-01-05T00:00:00Z"), utc("2025-01-06T00:00:00Z"), {}},
+		},
+		{
+			name: "empty timezone is UTC",
+			cron: "0 9 * * *",
+			from: utc("2025-06-01T10:00:00Z"),
+			want: []time.Time{utc("2025-06-02T09:00:00Z")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.cron, tt.rrule, tt.timezone, tt.from)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+
+			at := tt.from
+			for i, want := range tt.want {
+				got := rule.Next(at)
+				if !got.Equal(want) {
+					t.Fatalf("occurrence %d: got %s, want %s", i, got.UTC(), want.UTC())
+				}
+				if got.IsZero() {
+					return
+				}
+				at = got
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	start := utc("2025-01-01T00:00:00Z")
+
+	tests := []struct {
+		name     string
+		cron     string
+		rrule    string
+		timezone string
+		wantErr  error
+	}{
+		{name: "neither cron nor rrule", wantErr: ErrInvalidRule},
+		{name: "both cron and rrule", cron: "0 8 * * *", rrule: "FREQ=DAILY", wantErr: ErrInvalidRule},
+		{name: "malformed cron", cron: "0 25 * * *", wantErr: ErrInvalidRule},
+		{name: "six-field cron", cron: "0 0 8 * * *", wantErr: ErrInvalidRule},
+		{name: "malformed rrule", rrule: "FREQ=FORTNIGHTLY", wantErr: ErrInvalidRule},
+		{name: "unknown timezone", cron: "0 8 * * *", timezone: "Mars/Olympus_Mons", wantErr: ErrInvalidTimezone},
+		{name: "local timezone", cron: "0 8 * * *", timezone: "Local", wantErr: ErrInvalidTimezone},
+		{name: "too frequent", rrule: "FREQ=SECONDLY;INTERVAL=30", wantErr: ErrTooFrequent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.cron, tt.rrule, tt.timezone, start); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse: got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFromWall(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+
+	tests := []struct {
+		name string
+		wall time.Time
+		want time.Time
+	}{
+		{"ordinary", time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC), utc("2025-06-01T12:00:00Z")},
+		{"skipped", time.Date(2025, 3, 9, 2, 30, 0, 0, time.UTC), utc("2025-03-09T07:30:00Z")},
+		{"repeated", time.Date(2025, 11, 2, 1, 30, 0, 0, time.UTC), utc("2025-11-02T05:30:00Z")},
+		{"just after fall back", time.Date(2025, 11, 2, 2, 0, 0, 0, time.UTC), utc("2025-11-02T07:00:00Z")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fromWall(tt.wall, ny)
+			if !got.Equal(tt.want) {
+				t.Errorf("fromWall(%s): got %s, want %s", tt.wall.Format("2006-01-02 15:04"), got.UTC(), tt.want.UTC())
+			}
+			if got.Location() != ny {
+				t.Errorf("fromWall returned a time in %s, want %s", got.Location(), ny)
+			}
+		})
+	}
+}
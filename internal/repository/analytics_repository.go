@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"notification-system/internal/model"
+)
+
+// deliveriesRollup names the delivery rollups in analytics_rollup_state.
+const deliveriesRollup = "deliveries"
+
+// AnalyticsRepository defines data access operations for the hourly
+// delivery rollups.
+type AnalyticsRepository interface {
+	// LockWatermark locks the delivery rollups for the rest of tx and
+	// returns their watermark, nil if nothing has been rolled up yet. It
+	// returns ErrNotFound if another transaction holds the lock.
+	LockWatermark(ctx context.Context, tx *sqlx.Tx) (*time.Time, error)
+	// SetWatermark records that the rollups are up to date for recipients
+	// updated at or before t.
+	SetWatermark(ctx context.Context, tx *sqlx.Tx, t time.Time) error
+	// Watermark returns the current watermark without locking.
+	Watermark(ctx context.Context) (*time.Time, error)
+	// FirstUpdate returns the earliest recipient updated_at, or nil if
+	// there are no recipients.
+	FirstUpdate(ctx context.Context, tx *sqlx.Tx) (*time.Time, error)
+	// ChangedBuckets returns the hours in which the recipients updated or
+	// deleted in (after, upTo] were created.
+	ChangedBuckets(ctx context.Context, tx *sqlx.Tx, after, upTo time.Time) ([]time.Time, error)
+	// PruneDeletions removes the deleted hours recorded at or before t.
+	PruneDeletions(ctx context.Context, tx *sqlx.Tx, t time.Time) error
+	// Refresh recomputes the rollups of the given hours from
+	// message_recipients.
+	Refresh(ctx context.Context, tx *sqlx.Tx, buckets []time.Time) error
+	StatusCounts(ctx context.Context, f model.DeliveryAnalyticsFilter) ([]model.DeliveryStatusRow, error)
+	Latencies(ctx context.Context, f model.DeliveryAnalyticsFilter) ([]model.DeliveryLatencyRow, error)
+	TopErrors(ctx context.Context, f model.DeliveryAnalyticsFilter, limit int) ([]model.DeliveryErrorCount, error)
+}
+
+type analyticsRepository struct {
+	db *sqlx.DB
+}
+
+// NewAnalyticsRepository creates a new AnalyticsRepository backed by sqlx.
+func NewAnalyticsRepository(db *sqlx.DB) AnalyticsRepository {
+	return &analyticsRepository{db: db}
+}
+
+func (r *analyticsRepository) LockWatermark(ctx context.Context, tx *sqlx.Tx) (*time.Time, error) {
+	var watermark *time.Time
+	query := `SELECT watermark FROM analytics_rollup_state WHERE name = $1 FOR UPDATE SKIP LOCKED`
+
+	if err := tx.GetContext(ctx, &watermark, query, deliveriesRollup); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return watermark, nil
+}
+
+func (r *analyticsRepository) SetWatermark(ctx context.Context, tx *sqlx.Tx, t time.Time) error {
+	query := `UPDATE analytics_rollup_state SET watermark = $1, updated_at = $2 WHERE name = $3`
+	result, err := tx.ExecContext(ctx, query, t, time.Now(), deliveriesRollup)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(result)
+}
+
+func (r *analyticsRepository) Watermark(ctx context.Context) (*time.Time, error) {
+	var watermark *time.Time
+	query := `SELECT watermark FROM analytics_rollup_state WHERE name = $1`
+
+	if err := r.db.GetContext(ctx, &watermark, query, deliveriesRollup); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return watermark, nil
+}
+
+func (r *analyticsRepository) FirstUpdate(ctx context.Context, tx *sqlx.Tx) (*time.Time, error) {
+	var first *time.Time
+	if err := tx.GetContext(ctx, &first, `SELECT MIN(updated_at) FROM message_recipients`); err != nil {
+		return nil, err
+	}
+	return first, nil
+}
+
+func (r *analyticsRepository) ChangedBuckets(ctx context.Context, tx *sqlx.Tx, after, upTo time.Time) ([]time.Time, error) {
+	query := `SELECT DISTINCT date_trunc('hour', created_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'
+	           FROM message_recipients
+	           WHERE updated_at > $1 AND updated_at <= $2
+	           UNION
+	           SELECT bucket FROM delivery_rollup_deletions
+	           WHERE deleted_at > $1 AND deleted_at <= $2`
+
+	var buckets []time.Time
+	if err := tx.SelectContext(ctx, &buckets, query, after, upTo); err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+func (r *analyticsRepository) PruneDeletions(ctx context.Context, tx *sqlx.Tx, t time.Time) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM delivery_rollup_deletions WHERE deleted_at <= $1`, t)
+	return err
+}
+
+// bucketRecipients joins each hour in $1 to the recipients created in it
+// and their messages. It is shared by the statements of Refresh.
+const bucketRecipients = `unnest($1::timestamptz[]) AS b(bucket)
+	JOIN message_recipients mr ON mr.created_at >= b.bucket AND mr.created_at < b.bucket + interval '1 hour'
+	JOIN messages m ON m.id = mr.message_id`
+
+func (r *analyticsRepository) Refresh(ctx context.Context, tx *sqlx.Tx, buckets []time.Time) error {
+	hours := pq.Array(buckets)
+
+	for _, table := range []string{"delivery_stats_hourly", "delivery_latency_hourly", "delivery_errors_hourly"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE bucket = ANY($1::timestamptz[])`, hours); err != nil {
+			return fmt.Errorf("clear %s: %w", table, err)
+		}
+	}
+
+	query := `INSERT INTO delivery_stats_hourly (bucket, user_id, platform, status, recipients)
+	           SELECT b.bucket, m.user_id, m.platform, mr.status, COUNT(*)
+	           FROM ` + bucketRecipients + `
+	           GROUP BY b.bucket, m.user_id, m.platform, mr.status`
+	if _, err := tx.ExecContext(ctx, query, hours); err != nil {
+		return fmt.Errorf("roll up statuses: %w", err)
+	}
+
+	// Latency is measured from when the recipient became due: its creation,
+	// or the message's scheduled time if later. GREATEST ignores NULLs.
+	bounds := make([]float64, len(model.DeliveryLatencyBounds))
+	for i, d := range model.DeliveryLatencyBounds {
+		bounds[i] = float64(d.Milliseconds())
+	}
+	query = `INSERT INTO delivery_latency_hourly (bucket, user_id, platform, stage, slot, recipients)
+	          SELECT b.bucket, m.user_id, m.platform, s.stage,
+	                 width_bucket((EXTRACT(EPOCH FROM s.at - GREATEST(mr.created_at, m.scheduled_at)) * 1000)::float8, $2::float8[]) AS slot,
+	                 COUNT(*)
+	          FROM ` + bucketRecipients + `
+	          CROSS JOIN LATERAL (VALUES ('sent', mr.sent_at), ('delivered', mr.delivered_at)) AS s(stage, at)
+	          WHERE s.at IS NOT NULL
+	          GROUP BY b.bucket, m.user_id, m.platform, s.stage, slot`
+	if _, err := tx.ExecContext(ctx, query, hours, pq.Array(bounds)); err != nil {
+		return fmt.Errorf("roll up latencies: %w", err)
+	}
+
+	query = `INSERT INTO delivery_errors_hourly (bucket, user_id, platform, error, recipients)
+	          SELECT b.bucket, m.user_id, m.platform, LEFT(mr.error_message, 200), COUNT(*)
+	          FROM ` + bucketRecipients + `
+	          WHERE mr.status = $2 AND mr.error_message <> ''
+	          GROUP BY b.bucket, m.user_id, m.platform, LEFT(mr.error_message, 200)`
+	if _, err := tx.ExecContext(ctx, query, hours, model.StatusFailed); err != nil {
+		return fmt.Errorf("roll up errors: %w", err)
+	}
+
+	return nil
+}
+
+// rollupConditions returns the WHERE conditions and named parameters that
+// apply f to a rollup table aliased d.
+func rollupConditions(f model.DeliveryAnalyticsFilter) (string, map[string]interface{}) {
+	conditions := []string{"d.bucket >= :from_date", "d.bucket < :to_date"}
+	params := map[string]interface{}{
+		"granularity": f.Granularity,
+		"from_date":   f.From,
+		"to_date":     f.To,
+	}
+
+	if f.UserID != nil {
+		conditions = append(conditions, "d.user_id = :user_id")
+		params["user_id"] = *f.UserID
+	}
+	if f.Platform != "" {
+		conditions = append(conditions, "d.platform = :platform")
+		params["platform"] = f.Platform
+	}
+
+	return strings.Join(conditions, " AND "), params
+}
+
+func (r *analyticsRepository) StatusCounts(ctx context.Context, f model.DeliveryAnalyticsFilter) ([]model.DeliveryStatusRow, error) {
+	where, params := rollupConditions(f)
+	query := fmt.Sprintf(
+		`SELECT date_trunc(:granularity, d.bucket AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS bucket,
+		        d.platform, d.status, SUM(d.recipients) AS recipients
+		 FROM delivery_stats_hourly d
+		 WHERE %s
+		 GROUP BY 1, d.platform, d.status
+		 ORDER BY 1, d.platform, d.status`, where)
+
+	var rows []model.DeliveryStatusRow
+	if err := r.selectNamed(ctx, &rows, query, params); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (r *analyticsRepository) Latencies(ctx context.Context, f model.DeliveryAnalyticsFilter) ([]model.DeliveryLatencyRow, error) {
+	where, params := rollupConditions(f)
+	query := fmt.Sprintf(
+		`SELECT date_trunc(:granularity, d.bucket AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS bucket,
+		        d.platform, d.stage, d.slot, SUM(d.recipients) AS recipients
+		 FROM delivery_latency_hourly d
+		 WHERE %s
+		 GROUP BY 1, d.platform, d.stage, d.slot
+		 ORDER BY 1, d.platform, d.stage, d.slot`, where)
+
+	var rows []model.DeliveryLatencyRow
+	if err := r.selectNamed(ctx, &rows, query, params); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (r *analyticsRepository) TopErrors(ctx context.Context, f model.DeliveryAnalyticsFilter, limit int) ([]model.DeliveryErrorCount, error) {
+	where, params := rollupConditions(f)
+	params["limit"] = limit
+	query := fmt.Sprintf(
+		`SELECT d.platform, d.error, SUM(d.recipients) AS recipients
+		 FROM delivery_errors_hourly d
+		 WHERE %s
+		 GROUP BY d.platform, d.error
+		 ORDER BY recipients DESC, d.platform, d.error
+		 LIMIT :limit`, where)
+
+	var rows []model.DeliveryErrorCount
+	if err := r.selectNamed(ctx, &rows, query, params); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (r *analyticsRepository) selectNamed(ctx context.Context, dest interface{}, query string, params map[string]interface{}) error {
+	query, args, err := sqlx.Named(query, params)
+	if err != nil {
+		return err
+	}
+	return r.db.SelectContext(ctx, dest, r.db.Rebind(query), args...)
+}
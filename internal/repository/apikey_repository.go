@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"notification-system/internal/model"
+)
+
+// APIKeyRepository defines data access operations for API keys.
+type APIKeyRepository interface {
+	Create(ctx context.Context, tx *sqlx.Tx, key *model.APIKey) error
+	GetByHash(ctx context.Context, hash string) (*model.APIKey, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*model.APIKey, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]model.APIKey, error)
+	Revoke(ctx context.Context, id uuid.UUID) error
+	SetExpiry(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, expiresAt time.Time) error
+	TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *sqlx.DB
+}
+
+// NewAPIKeyRepository creates a new APIKeyRepository backed by sqlx.
+func NewAPIKeyRepository(db *sqlx.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at`
+
+func (r *apiKeyRepository) Create(ctx context.Context, tx *sqlx.Tx, key *model.APIKey) error {
+	query := `INSERT INTO api_keys (id, user_id, name, prefix, key_hash, scopes, expires_at, created_at)
+	           VALUES (:id, :user_id, :name, :prefix, :key_hash, :scopes, :expires_at, :created_at)`
+
+	_, err := tx.NamedExecContext(ctx, query, key)
+	return err
+}
+
+func (r *apiKeyRepository) GetByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	var key model.APIKey
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1`
+
+	if err := r.db.GetContext(ctx, &key, query, hash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (r *apiKeyRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.APIKey, error) {
+	var key model.APIKey
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = $1`
+
+	if err := r.db.GetContext(ctx, &key, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (r *apiKeyRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`
+
+	if err := r.db.SelectContext(ctx, &keys, query, userID); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE api_keys SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(result)
+}
+
+// SetExpiry moves a key's expiry earlier; it never extends an existing expiry.
+func (r *apiKeyRepository) SetExpiry(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, expiresAt time.Time) error {
+	query := `UPDATE api_keys
+	           SET expires_at = LEAST(COALESCE(expires_at, $1), $1)
+	           WHERE id = $2 AND revoked_at IS NULL`
+	result, err := tx.ExecContext(ctx, query, expiresAt, id)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(result)
+}
+
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = $1 WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, at, id)
+	return err
+}
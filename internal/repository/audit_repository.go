@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"notification-system/internal/model"
+)
+
+// auditPurgeBatch bounds how many rows one purge statement deletes, so the
+// retention job never holds long locks on a large table.
+const auditPurgeBatch = 5000
+
+// AuditRepository defines data access operations for the audit trail.
+type AuditRepository interface {
+	Create(ctx context.Context, evt *model.AuditEvent) error
+	List(ctx context.Context, q model.ListAuditQuery, after *Cursor) ([]model.AuditEvent, *Cursor, error)
+	DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+type auditRepository struct {
+	db *sqlx.DB
+}
+
+// NewAuditRepository creates a new AuditRepository backed by sqlx.
+func NewAuditRepository(db *sqlx.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Create(ctx context.Context, evt *model.AuditEvent) error {
+	query := `INSERT INTO audit_events (id, actor_user_id, actor_key_id, ip, request_id, action, target_type, target_id, before, after, created_at)
+	           VALUES (:id, :actor_user_id, :actor_key_id, :ip, :request_id, :action, :target_type, :target_id, :before, :after, :created_at)`
+
+	_, err := r.db.NamedExecContext(ctx, query, evt)
+	return err
+}
+
+// List returns events matching q, newest first, starting after the given
+// cursor. The returned cursor is nil when there are no more events.
+func (r *auditRepository) List(ctx context.Context, q model.ListAuditQuery, after *Cursor) ([]model.AuditEvent, *Cursor, error) {
+	conditions := []string{"1=1"}
+	params := map[string]interface{}{
+		"limit": q.Limit + 1,
+	}
+
+	if q.ActorUserID != "" {
+		conditions = append(conditions, "actor_user_id = :actor_user_id")
+		params["actor_user_id"] = q.ActorUserID
+	}
+	if q.ActorKeyID != "" {
+		conditions = append(conditions, "actor_key_id = :actor_key_id")
+		params["actor_key_id"] = q.ActorKeyID
+	}
+	if q.Action != "" {
+		conditions = append(conditions, "action = :action")
+		params["action"] = q.Action
+	}
+	if q.TargetType != "" {
+		conditions = append(conditions, "target_type = :target_type")
+		params["target_type"] = q.TargetType
+	}
+	if q.TargetID != "" {
+		conditions = append(conditions, "target_id = :target_id")
+		params["target_id"] = q.TargetID
+	}
+	if q.RequestID != "" {
+		conditions = append(conditions, "request_id = :request_id")
+		params["request_id"] = q.RequestID
+	}
+	if q.From != nil {
+		conditions = append(conditions, "created_at >= :from_date")
+		params["from_date"] = *q.From
+	}
+	if q.To != nil {
+		conditions = append(conditions, "created_at <= :to_date")
+		params["to_date"] = *q.To
+	}
+	if after != nil {
+		conditions = append(conditions, "(created_at, id) < (:cursor_created_at, :cursor_id)")
+		params["cursor_created_at"] = after.CreatedAt
+		params["cursor_id"] = after.ID
+	}
+
+	query := fmt.Sprintf(
+		`SELECT id, actor_user_id, actor_key_id, ip, request_id, action, target_type, target_id, before, after, created_at
+		 FROM audit_events
+		 WHERE %s
+		 ORDER BY created_at DESC, id DESC
+		 LIMIT :limit`, strings.Join(conditions, " AND "))
+
+	query, args, err := sqlx.Named(query, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	query = r.db.Rebind(query)
+
+	var events []model.AuditEvent
+	if err := r.db.SelectContext(ctx, &events, query, args...); err != nil {
+		return nil, nil, err
+	}
+
+	var next *Cursor
+	if len(events) > q.Limit {
+		events = events[:q.Limit]
+		last := events[len(events)-1]
+		next = &Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	return events, next, nil
+}
+
+// DeleteBefore removes events older than cutoff in batches and returns the
+// number of rows deleted.
+func (r *auditRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	query := `DELETE FROM audit_events
+	           WHERE id IN (SELECT id FROM audit_events WHERE created_at < $1 LIMIT $2)`
+
+	var total int64
+	for {
+		result, err := r.db.ExecContext(ctx, query, cutoff, auditPurgeBatch)
+		if err != nil {
+			return total, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += n
+		if n < auditPurgeBatch {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"notification-system/internal/model"
+)
+
+// BulkJobRepository defines data access operations for bulk send jobs and their items.
+type BulkJobRepository interface {
+	// Create stores a job within tx.
+	Create(ctx context.Context, tx *sqlx.Tx, job *model.BulkJob) error
+	// AddItems stores items of a job within tx.
+	AddItems(ctx context.Context, tx *sqlx.Tx, items []model.BulkJobItem) error
+	// SetTotal records a job's item count within tx, for jobs whose items
+	// are added in several batches.
+	SetTotal(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, total int) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.BulkJob, error)
+	// Progress counts a job's items by status.
+	Progress(ctx context.Context, jobID uuid.UUID) (model.BulkJobProgress, error)
+	// ListItems returns up to limit of a job's items with an index above
+	// after, in index order, optionally only those in status.
+	ListItems(ctx context.Context, jobID uuid.UUID, status model.BulkItemStatus, after, limit int) ([]model.BulkJobItem, error)
+	// ClaimPending locks up to limit pending items within tx, oldest job
+	// first, skipping rows locked by another transaction.
+	ClaimPending(ctx context.Context, tx *sqlx.Tx, limit int) ([]model.BulkJobItem, error)
+	// SetItemResult records a claimed item's outcome within tx.
+	SetItemResult(ctx context.Context, tx *sqlx.Tx, item *model.BulkJobItem) error
+	// RefreshStatus moves the given jobs to running, or to completed once
+	// none of their items are pending, within tx.
+	RefreshStatus(ctx context.Context, tx *sqlx.Tx, jobIDs []uuid.UUID) error
+}
+
+type bulkJobRepository struct {
+	db *sqlx.DB
+}
+
+// NewBulkJobRepository creates a new BulkJobRepository backed by sqlx.
+func NewBulkJobRepository(db *sqlx.DB) BulkJobRepository {
+	return &bulkJobRepository{db: db}
+}
+
+const bulkJobColumns = `id, user_id, api_key_id, request_id, status, total, started_at, completed_at, created_at, updated_at`
+
+const bulkJobItemColumns = `job_id, idx, request, status, message_id, error, processed_at`
+
+func (r *bulkJobRepository) Create(ctx context.Context, tx *sqlx.Tx, job *model.BulkJob) error {
+	query := `INSERT INTO bulk_jobs (` + bulkJobColumns + `)
+	           VALUES (:id, :user_id, :api_key_id, :request_id, :status, :total, :started_at, :completed_at,
+	                   :created_at, :updated_at)`
+	_, err := tx.NamedExecContext(ctx, query, job)
+	return err
+}
+
+func (r *bulkJobRepository) AddItems(ctx context.Context, tx *sqlx.Tx, items []model.BulkJobItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	query := `INSERT INTO bulk_job_items (job_id, idx, request, status)
+	           VALUES (:job_id, :idx, :request, :status)`
+	_, err := tx.NamedExecContext(ctx, query, items)
+	return err
+}
+
+func (r *bulkJobRepository) SetTotal(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, total int) error {
+	query := `UPDATE bulk_jobs SET total = $1, updated_at = $2 WHERE id = $3`
+	result, err := tx.ExecContext(ctx, query, total, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(result)
+}
+
+func (r *bulkJobRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.BulkJob, error) {
+	var job model.BulkJob
+	query := `SELECT ` + bulkJobColumns + ` FROM bulk_jobs WHERE id = $1`
+
+	if err := r.db.GetContext(ctx, &job, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (r *bulkJobRepository) Progress(ctx context.Context, jobID uuid.UUID) (model.BulkJobProgress, error) {
+	var progress model.BulkJobProgress
+	query := `SELECT COUNT(*) FILTER (WHERE status = $2) AS pending,
+	                 COUNT(*) FILTER (WHERE status = $3) AS succeeded,
+	                 COUNT(*) FILTER (WHERE status = $4) AS failed
+	           FROM bulk_job_items WHERE job_id = $1`
+
+	err := r.db.GetContext(ctx, &progress, query,
+		jobID, model.BulkItemPending, model.BulkItemSucceeded, model.BulkItemFailed)
+	return progress, err
+}
+
+func (r *bulkJobRepository) ListItems(ctx context.Context, jobID uuid.UUID, status model.BulkItemStatus, after, limit int) ([]model.BulkJobItem, error) {
+	query := `SELECT ` + bulkJobItemColumns + `
+	           FROM bulk_job_items
+	           WHERE job_id = $1 AND idx > $2 AND ($3 = '' OR status = $3)
+	           ORDER BY idx
+	           LIMIT $4`
+
+	var items []model.BulkJobItem
+	if err := r.db.SelectContext(ctx, &items, query, jobID, after, string(status), limit); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *bulkJobRepository) ClaimPending(ctx context.Context, tx *sqlx.Tx, limit int) ([]model.BulkJobItem, error) {
+	query := `SELECT i.job_id, i.idx, i.request, i.status, i.message_id, i.error, i.processed_at
+	           FROM bulk_job_items i
+	           JOIN bulk_jobs j ON j.id = i.job_id
+	           WHERE i.status = $1
+	           ORDER BY j.created_at, i.idx
+	           LIMIT $2
+	           FOR UPDATE OF i SKIP LOCKED`
+
+	var items []model.BulkJobItem
+	if err := tx.SelectContext(ctx, &items, query, model.BulkItemPending, limit); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *bulkJobRepository) SetItemResult(ctx context.Context, tx *sqlx.Tx, item *model.BulkJobItem) error {
+	query := `UPDATE bulk_job_items SET status = $1, message_id = $2, error = $3, processed_at = $4
+	           WHERE job_id = $5 AND idx = $6`
+	result, err := tx.ExecContext(ctx, query,
+		item.Status, item.MessageID, item.Error, item.ProcessedAt, item.JobID, item.Index)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(result)
+}
+
+func (r *bulkJobRepository) RefreshStatus(ctx context.Context, tx *sqlx.Tx, jobIDs []uuid.UUID) error {
+	if len(jobIDs) == 0 {
+		return nil
+	}
+
+	// Lock the jobs in a fixed order so concurrent batches can't deadlock.
+	// Once a batch holds the lock, every other batch that touched the job
+	// has either committed or not yet checked, so the last one to check
+	// sees all items processed.
+	ids := pq.Array(jobIDs)
+	if _, err := tx.ExecContext(ctx,
+		`SELECT id FROM bulk_jobs WHERE id = ANY($1) ORDER BY id FOR UPDATE`, ids); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	query := `UPDATE bulk_jobs j
+	           SET status = CASE WHEN p.pending THEN $2 ELSE $3 END,
+	               started_at = COALESCE(j.started_at, $4),
+	               completed_at = CASE WHEN p.pending THEN NULL ELSE $4 END,
+	               updated_at = $4
+	           FROM (SELECT id, EXISTS (SELECT 1 FROM bulk_job_items i WHERE i.job_id = b.id AND i.status = $5) AS pending
+	                 FROM bulk_jobs b WHERE b.id = ANY($1)) p
+	           WHERE j.id = p.id AND j.status <> $3`
+	_, err := tx.ExecContext(ctx, query,
+		ids, model.BulkJobRunning, model.BulkJobCompleted, now, model.BulkItemPending)
+	return err
+}
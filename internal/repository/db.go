@@ -3,19 +3,34 @@ package repository
 import (
 	"fmt"
 
+	"github.com/XSAM/otelsql"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
 
 	"notification-system/internal/config"
 )
 
-// NewDB opens a PostgreSQL connection and configures pool settings.
+// NewDB opens a PostgreSQL connection and configures pool settings. Queries
+// are recorded as spans on the global tracer provider.
 func NewDB(cfg config.DatabaseConfig) (*sqlx.DB, error) {
-	db, err := sqlx.Connect("postgres", cfg.DSN())
+	sqlDB, err := otelsql.Open("postgres", cfg.DSN(),
+		otelsql.WithAttributes(semconv.DBSystemNamePostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+		}),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	db := sqlx.NewDb(sqlDB, "postgres")
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
 	db.SetMaxOpenConns(cfg.MaxOpenConns)
 	db.SetMaxIdleConns(cfg.MaxIdleConns)
 
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"notification-system/internal/model"
+)
+
+// DeliveryAttemptRepository defines data access operations for delivery attempts.
+type DeliveryAttemptRepository interface {
+	// Create stores an attempt, numbering it after the recipient's previous
+	// attempts and setting its Attempt field accordingly.
+	Create(ctx context.Context, attempt *model.DeliveryAttempt) error
+	// ListByRecipientID returns a recipient's attempts, oldest first.
+	ListByRecipientID(ctx context.Context, recipientID uuid.UUID) ([]model.DeliveryAttempt, error)
+}
+
+type deliveryAttemptRepository struct {
+	db *sqlx.DB
+}
+
+// NewDeliveryAttemptRepository creates a new DeliveryAttemptRepository backed by sqlx.
+func NewDeliveryAttemptRepository(db *sqlx.DB) DeliveryAttemptRepository {
+	return &deliveryAttemptRepository{db: db}
+}
+
+const deliveryAttemptColumns = `id, recipient_id, message_id, attempt, adapter, success, provider_id, response_code,
+	                  error_code, error_message, latency_ms, started_at, finished_at`
+
+func (r *deliveryAttemptRepository) Create(ctx context.Context, attempt *model.DeliveryAttempt) error {
+	query := `INSERT INTO delivery_attempts (` + deliveryAttemptColumns + `)
+	           VALUES ($1, $2, $3,
+	                   (SELECT COUNT(*) + 1 FROM delivery_attempts WHERE recipient_id = $2),
+	                   $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	           RETURNING attempt`
+
+	return r.db.GetContext(ctx, &attempt.Attempt, query,
+		attempt.ID, attempt.RecipientID, attempt.MessageID, attempt.Adapter, attempt.Success, attempt.ProviderID,
+		attempt.ResponseCode, attempt.ErrorCode, attempt.ErrorMessage, attempt.LatencyMs, attempt.StartedAt,
+		attempt.FinishedAt)
+}
+
+func (r *deliveryAttemptRepository) ListByRecipientID(ctx context.Context, recipientID uuid.UUID) ([]model.DeliveryAttempt, error) {
+	var attempts []model.DeliveryAttempt
+	query := `SELECT ` + deliveryAttemptColumns + `
+	           FROM delivery_attempts WHERE recipient_id = $1 ORDER BY attempt`
+
+	if err := r.db.SelectContext(ctx, &attempts, query, recipientID); err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"notification-system/internal/model"
+)
+
+// ExportRepository defines data access operations for message exports.
+type ExportRepository interface {
+	Create(ctx context.Context, exp *model.Export) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Export, error)
+	// ClaimNext marks the oldest pending export running and returns it, or
+	// ErrNotFound if there is none. A running export not touched since
+	// before staleBefore is assumed abandoned and claimed again.
+	ClaimNext(ctx context.Context, staleBefore time.Time) (*model.Export, error)
+	// Touch records that a claimed export is still being built. It returns
+	// ErrNotFound if the export has since been claimed again.
+	Touch(ctx context.Context, exp *model.Export) error
+	// Complete records the file of a claimed export. It matches the claim's
+	// started_at, and returns ErrNotFound if the export has since been
+	// claimed again.
+	Complete(ctx context.Context, exp *model.Export) error
+	// Fail marks a claimed export failed. It returns ErrNotFound if the
+	// export has since been claimed again.
+	Fail(ctx context.Context, exp *model.Export, errMsg string) error
+	// ListExpired returns up to limit completed exports that expired before
+	// the given time.
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]model.Export, error)
+	// MarkExpired records that an export's file has been deleted.
+	MarkExpired(ctx context.Context, id uuid.UUID) error
+}
+
+type exportRepository struct {
+	db *sqlx.DB
+}
+
+// NewExportRepository creates a new ExportRepository backed by sqlx.
+func NewExportRepository(db *sqlx.DB) ExportRepository {
+	return &exportRepository{db: db}
+}
+
+const exportColumns = `id, user_id, format, filters, status, storage_key, row_count, size_bytes, error, started_at, completed_at, expires_at, created_at, updated_at`
+
+func (r *exportRepository) Create(ctx context.Context, exp *model.Export) error {
+	query := `INSERT INTO exports (` + exportColumns + `)
+	           VALUES (:id, :user_id, :format, :filters, :status, :storage_key, :row_count, :size_bytes, :error,
+	                   :started_at, :completed_at, :expires_at, :created_at, :updated_at)`
+	_, err := r.db.NamedExecContext(ctx, query, exp)
+	return err
+}
+
+func (r *exportRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Export, error) {
+	var exp model.Export
+	query := `SELECT ` + exportColumns + ` FROM exports WHERE id = $1`
+
+	if err := r.db.GetContext(ctx, &exp, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &exp, nil
+}
+
+func (r *exportRepository) ClaimNext(ctx context.Context, staleBefore time.Time) (*model.Export, error) {
+	query := `UPDATE exports SET status = $1, started_at = $2, updated_at = $2
+	           WHERE id = (SELECT id FROM exports
+	                       WHERE status = $3 OR (status = $1 AND updated_at < $4)
+	                       ORDER BY created_at
+	                       LIMIT 1
+	                       FOR UPDATE SKIP LOCKED)
+	           RETURNING ` + exportColumns
+
+	var exp model.Export
+	err := r.db.GetContext(ctx, &exp, query, model.ExportRunning, time.Now(), model.ExportPending, staleBefore)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &exp, nil
+}
+
+func (r *exportRepository) Touch(ctx context.Context, exp *model.Export) error {
+	query := `UPDATE exports SET updated_at = $1 WHERE id = $2 AND status = $3 AND started_at = $4`
+	result, err := r.db.ExecContext(ctx, query, time.Now(), exp.ID, model.ExportRunning, exp.StartedAt)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(result)
+}
+
+func (r *exportRepository) Complete(ctx context.Context, exp *model.Export) error {
+	query := `UPDATE exports
+	           SET status = $1, storage_key = $2, row_count = $3, size_bytes = $4,
+	               completed_at = $5, expires_at = $6, updated_at = $5
+	           WHERE id = $7 AND status = $8 AND started_at = $9`
+	result, err := r.db.ExecContext(ctx, query,
+		model.ExportCompleted, exp.StorageKey, exp.Rows, exp.SizeBytes, exp.CompletedAt, exp.ExpiresAt,
+		exp.ID, model.ExportRunning, exp.StartedAt)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(result)
+}
+
+func (r *exportRepository) Fail(ctx context.Context, exp *model.Export, errMsg string) error {
+	now := time.Now()
+	query := `UPDATE exports SET status = $1, error = $2, completed_at = $3, updated_at = $3
+	           WHERE id = $4 AND status = $5 AND started_at = $6`
+	result, err := r.db.ExecContext(ctx, query,
+		model.ExportFailed, errMsg, now, exp.ID, model.ExportRunning, exp.StartedAt)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(result)
+}
+
+func (r *exportRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]model.Export, error) {
+	query := `SELECT ` + exportColumns + `
+	           FROM exports
+	           WHERE status = $1 AND expires_at < $2
+	           ORDER BY expires_at
+	           LIMIT $3`
+
+	var exports []model.Export
+	if err := r.db.SelectContext(ctx, &exports, query, model.ExportCompleted, before, limit); err != nil {
+		return nil, err
+	}
+
+	return exports, nil
+}
+
+func (r *exportRepository) MarkExpired(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE exports SET status = $1, updated_at = $2 WHERE id = $3 AND status = $4`
+	result, err := r.db.ExecContext(ctx, query, model.ExportExpired, time.Now(), id, model.ExportCompleted)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(result)
+}
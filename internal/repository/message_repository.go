@@ -19,8 +19,30 @@ type MessageRepository interface {
 	Create(ctx context.Context, tx *sqlx.Tx, msg *model.Message) error
 	GetByID(ctx context.Context, id uuid.UUID) (*model.Message, error)
 	UpdateStatus(ctx context.Context, id uuid.UUID, status model.MessageStatus) error
-	List(ctx context.Context, userID uuid.UUID, q model.ListMessagesQuery) ([]model.Message, int, error)
-	GetScheduledMessages(ctx context.Context, before time.Time, limit int) ([]model.Message, error)
+	List(ctx context.Context, userID uuid.UUID, q model.ListMessagesQuery, after *Cursor) ([]model.Message, *Cursor, error)
+	Count(ctx context.Context, userID uuid.UUID, q model.ListMessagesQuery) (int, error)
+	// Export calls fn with each recipient of the messages of userID matching
+	// f, oldest message first. Rows are streamed rather than loaded at once;
+	// an error from fn stops the export and is returned.
+	Export(ctx context.Context, userID uuid.UUID, f model.MessageFilter, fn func(*model.ExportRow) error) error
+	// ClaimScheduled locks up to limit scheduled messages due before the
+	// given time within tx. Rows locked by another transaction are skipped,
+	// so concurrent schedulers never claim the same message, as are messages
+	// waiting to retry a failed dispatch.
+	ClaimScheduled(ctx context.Context, tx *sqlx.Tx, before time.Time, limit int) ([]model.Message, error)
+	// RecordDispatchFailure counts a failed dispatch of a claimed scheduled
+	// message within tx, holding it back until retryAt. Once maxAttempts have
+	// failed the message is moved to StatusFailed instead, and failed is true.
+	RecordDispatchFailure(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, retryAt time.Time, maxAttempts int) (failed bool, err error)
+	// TransitionStatus moves a message from one status to another within tx.
+	// It returns ErrNotFound if the message is not in the from status.
+	TransitionStatus(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, from, to model.MessageStatus) error
+	// GetForUpdate returns a message and locks it until tx ends, so its
+	// status can't change underneath the caller.
+	GetForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*model.Message, error)
+	// UpdateScheduled persists the editable fields of a scheduled message
+	// within tx. It returns ErrNotFound if the message is no longer scheduled.
+	UpdateScheduled(ctx context.Context, tx *sqlx.Tx, msg *model.Message) error
 }
 
 type messageRepository struct {
@@ -32,9 +54,11 @@ func NewMessageRepository(db *sqlx.DB) MessageRepository {
 	return &messageRepository{db: db}
 }
 
+const messageColumns = `id, user_id, api_key_id, request_id, schedule_id, subject, body, sender, platform, priority, status, scheduled_at, send_window, created_at, updated_at`
+
 func (r *messageRepository) Create(ctx context.Context, tx *sqlx.Tx, msg *model.Message) error {
-	query := `INSERT INTO messages (id, user_id, subject, body, sender, platform, priority, status, scheduled_at, created_at, updated_at)
-	           VALUES (:id, :user_id, :subject, :body, :sender, :platform, :priority, :status, :scheduled_at, :created_at, :updated_at)`
+	query := `INSERT INTO messages (id, user_id, api_key_id, request_id, schedule_id, subject, body, sender, platform, priority, status, scheduled_at, send_window, created_at, updated_at)
+	           VALUES (:id, :user_id, :api_key_id, :request_id, :schedule_id, :subject, :body, :sender, :platform, :priority, :status, :scheduled_at, :send_window, :created_at, :updated_at)`
 
 	_, err := tx.NamedExecContext(ctx, query, msg)
 	return err
@@ -42,8 +66,7 @@ func (r *messageRepository) Create(ctx context.Context, tx *sqlx.Tx, msg *model.
 
 func (r *messageRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Message, error) {
 	var msg model.Message
-	query := `SELECT id, user_id, subject, body, sender, platform, priority, status, scheduled_at, created_at, updated_at
-	           FROM messages WHERE id = $1`
+	query := `SELECT ` + messageColumns + ` FROM messages WHERE id = $1`
 
 	if err := r.db.GetContext(ctx, &msg, query, id); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -73,8 +96,111 @@ func (r *messageRepository) UpdateStatus(ctx context.Context, id uuid.UUID, stat
 	return nil
 }
 
-func (r *messageRepository) List(ctx context.Context, userID uuid.UUID, q model.ListMessagesQuery) ([]model.Message, int, error) {
-	// Build dynamic WHERE clause
+// List returns messages of userID matching q, newest first, starting after
+// the given cursor, or at the offset of q.Page when it is set. The returned
+// cursor is nil when there are no more messages.
+func (r *messageRepository) List(ctx context.Context, userID uuid.UUID, q model.ListMessagesQuery, after *Cursor) ([]model.Message, *Cursor, error) {
+	conditions, params := messageFilter(userID, q.MessageFilter)
+	params["limit"] = q.Limit + 1
+
+	if after != nil {
+		conditions = append(conditions, "(created_at, id) < (:cursor_created_at, :cursor_id)")
+		params["cursor_created_at"] = after.CreatedAt
+		params["cursor_id"] = after.ID
+	}
+
+	offset := ""
+	if q.Page > 1 {
+		offset = " OFFSET :offset"
+		params["offset"] = (q.Page - 1) * q.Limit
+	}
+
+	query := fmt.Sprintf(
+		`SELECT %s FROM messages WHERE %s ORDER BY created_at DESC, id DESC LIMIT :limit%s`,
+		messageColumns, strings.Join(conditions, " AND "), offset)
+
+	query, args, err := sqlx.Named(query, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	query = r.db.Rebind(query)
+
+	var messages []model.Message
+	if err := r.db.SelectContext(ctx, &messages, query, args...); err != nil {
+		return nil, nil, err
+	}
+
+	var next *Cursor
+	if len(messages) > q.Limit {
+		messages = messages[:q.Limit]
+		last := messages[len(messages)-1]
+		next = &Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	return messages, next, nil
+}
+
+// Count returns the number of messages of userID matching q's filters.
+func (r *messageRepository) Count(ctx context.Context, userID uuid.UUID, q model.ListMessagesQuery) (int, error) {
+	conditions, params := messageFilter(userID, q.MessageFilter)
+
+	query, args, err := sqlx.Named(
+		fmt.Sprintf("SELECT COUNT(*) FROM messages WHERE %s", strings.Join(conditions, " AND ")), params)
+	if err != nil {
+		return 0, err
+	}
+	query = r.db.Rebind(query)
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, query, args...); err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (r *messageRepository) Export(ctx context.Context, userID uuid.UUID, f model.MessageFilter, fn func(*model.ExportRow) error) error {
+	conditions, params := messageFilter(userID, f)
+
+	// The filter is applied in a subquery because its columns are
+	// unqualified and several also exist on message_recipients.
+	query, args, err := sqlx.Named(fmt.Sprintf(
+		`SELECT m.id AS message_id, m.request_id, m.subject, m.body, m.sender, m.platform, m.priority,
+		        m.status AS message_status, m.scheduled_at, m.created_at,
+		        mr.id AS recipient_id, mr.recipient, mr.status AS recipient_status, mr.provider_id,
+		        mr.error_message, mr.retry_count, mr.sent_at, mr.delivered_at
+		 FROM (SELECT id, request_id, subject, body, sender, platform, priority, status, scheduled_at, created_at
+		       FROM messages WHERE %s) m
+		 JOIN message_recipients mr ON mr.message_id = m.id
+		 ORDER BY m.created_at, m.id, mr.created_at, mr.id`,
+		strings.Join(conditions, " AND ")), params)
+	if err != nil {
+		return err
+	}
+	query = r.db.Rebind(query)
+
+	rows, err := r.db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var row model.ExportRow
+	for rows.Next() {
+		if err := rows.StructScan(&row); err != nil {
+			return err
+		}
+		if err := fn(&row); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// messageFilter builds the WHERE conditions and named parameters for q on
+// the messages of userID.
+func messageFilter(userID uuid.UUID, q model.MessageFilter) ([]string, map[string]interface{}) {
 	conditions := []string{"user_id = :user_id"}
 	params := map[string]interface{}{
 		"user_id": userID,
@@ -88,6 +214,27 @@ func (r *messageRepository) List(ctx context.Context, userID uuid.UUID, q model.
 		conditions = append(conditions, "status = :status")
 		params["status"] = *q.Status
 	}
+	if q.Priority != nil {
+		conditions = append(conditions, "priority = :priority")
+		params["priority"] = *q.Priority
+	}
+	if q.Recipient != "" {
+		conditions = append(conditions,
+			"EXISTS (SELECT 1 FROM message_recipients mr WHERE mr.message_id = messages.id AND mr.recipient = :recipient)")
+		params["recipient"] = q.Recipient
+	}
+	if q.Q != "" {
+		// Must match the expression of idx_messages_subject_fts.
+		conditions = append(conditions, "to_tsvector('simple', subject) @@ plainto_tsquery('simple', :q)")
+		params["q"] = q.Q
+	}
+	if q.Scheduled != nil {
+		if *q.Scheduled {
+			conditions = append(conditions, "scheduled_at IS NOT NULL")
+		} else {
+			conditions = append(conditions, "scheduled_at IS NULL")
+		}
+	}
 	if q.From != nil {
 		conditions = append(conditions, "created_at >= :from_date")
 		params["from_date"] = *q.From
@@ -97,55 +244,82 @@ func (r *messageRepository) List(ctx context.Context, userID uuid.UUID, q model.
 		params["to_date"] = *q.To
 	}
 
-	where := strings.Join(conditions, " AND ")
+	return conditions, params
+}
 
-	// Count total matching rows
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM messages WHERE %s", where)
-	countQuery, countArgs, err := sqlx.Named(countQuery, params)
-	if err != nil {
-		return nil, 0, err
-	}
-	countQuery = r.db.Rebind(countQuery)
+func (r *messageRepository) ClaimScheduled(ctx context.Context, tx *sqlx.Tx, before time.Time, limit int) ([]model.Message, error) {
+	query := `SELECT ` + messageColumns + `
+	           FROM messages
+	           WHERE status = $1 AND scheduled_at <= $2
+	             AND (dispatch_retry_at IS NULL OR dispatch_retry_at <= $2)
+	           ORDER BY scheduled_at ASC
+	           LIMIT $3
+	           FOR UPDATE SKIP LOCKED`
 
-	var total int
-	if err := r.db.GetContext(ctx, &total, countQuery, countArgs...); err != nil {
-		return nil, 0, err
+	var messages []model.Message
+	if err := tx.SelectContext(ctx, &messages, query, model.StatusScheduled, before, limit); err != nil {
+		return nil, err
 	}
 
-	// Fetch paginated results
-	offset := (q.Page - 1) * q.Limit
-	params["limit"] = q.Limit
-	params["offset"] = offset
+	return messages, nil
+}
 
-	dataQuery := fmt.Sprintf(
-		`SELECT id, user_id, subject, body, sender, platform, priority, status, scheduled_at, created_at, updated_at
-		 FROM messages WHERE %s ORDER BY created_at DESC LIMIT :limit OFFSET :offset`, where)
+func (r *messageRepository) RecordDispatchFailure(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, retryAt time.Time, maxAttempts int) (bool, error) {
+	// The CASE sees dispatch_attempts before this update.
+	query := `UPDATE messages
+	           SET dispatch_attempts = dispatch_attempts + 1,
+	               dispatch_retry_at = $1,
+	               status = CASE WHEN dispatch_attempts + 1 >= $2 THEN $3 ELSE status END,
+	               updated_at = $4
+	           WHERE id = $5 AND status = $6
+	           RETURNING status`
 
-	dataQuery, dataArgs, err := sqlx.Named(dataQuery, params)
+	var status model.MessageStatus
+	err := tx.GetContext(ctx, &status, query, retryAt, maxAttempts, model.StatusFailed, time.Now(), id, model.StatusScheduled)
 	if err != nil {
-		return nil, 0, err
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, ErrNotFound
+		}
+		return false, err
 	}
-	dataQuery = r.db.Rebind(dataQuery)
 
-	var messages []model.Message
-	if err := r.db.SelectContext(ctx, &messages, dataQuery, dataArgs...); err != nil {
-		return nil, 0, err
+	return status == model.StatusFailed, nil
+}
+
+func (r *messageRepository) TransitionStatus(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, from, to model.MessageStatus) error {
+	query := `UPDATE messages SET status = $1, updated_at = $2 WHERE id = $3 AND status = $4`
+	result, err := tx.ExecContext(ctx, query, to, time.Now(), id, from)
+	if err != nil {
+		return err
 	}
 
-	return messages, total, nil
+	return checkRowsAffected(result)
 }
 
-func (r *messageRepository) GetScheduledMessages(ctx context.Context, before time.Time, limit int) ([]model.Message, error) {
-	query := `SELECT id, user_id, subject, body, sender, platform, priority, status, scheduled_at, created_at, updated_at
-	           FROM messages
-	           WHERE status = $1 AND scheduled_at <= $2
-	           ORDER BY scheduled_at ASC
-	           LIMIT $3`
+func (r *messageRepository) GetForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*model.Message, error) {
+	var msg model.Message
+	query := `SELECT ` + messageColumns + ` FROM messages WHERE id = $1 FOR UPDATE`
 
-	var messages []model.Message
-	if err := r.db.SelectContext(ctx, &messages, query, model.StatusScheduled, before, limit); err != nil {
+	if err := tx.GetContext(ctx, &msg, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
 		return nil, err
 	}
 
-	return messages, nil
+	return &msg, nil
+}
+
+func (r *messageRepository) UpdateScheduled(ctx context.Context, tx *sqlx.Tx, msg *model.Message) error {
+	msg.UpdatedAt = time.Now()
+	query := `UPDATE messages
+	           SET subject = $1, body = $2, scheduled_at = $3, updated_at = $4
+	           WHERE id = $5 AND status = $6`
+
+	result, err := tx.ExecContext(ctx, query, msg.Subject, msg.Body, msg.ScheduledAt, msg.UpdatedAt, msg.ID, model.StatusScheduled)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(result)
 }
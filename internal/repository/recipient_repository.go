@@ -4,20 +4,80 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 
 	"notification-system/internal/model"
 )
 
+var (
+	// ErrCancelled is returned when a recipient can't be updated because it
+	// has been cancelled.
+	ErrCancelled = errors.New("recipient cancelled")
+	// ErrNotPending is returned when a recipient can't be claimed for sending
+	// because it isn't waiting to be sent: it is deferred, or is being or has
+	// been sent by another delivery of the same event.
+	ErrNotPending = errors.New("recipient not pending")
+)
+
 // RecipientRepository defines data access operations for message recipients.
 type RecipientRepository interface {
 	BatchCreate(ctx context.Context, tx *sqlx.Tx, recipients []model.Recipient) error
 	UpdateStatus(ctx context.Context, id uuid.UUID, status model.MessageStatus, providerID *string) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Recipient, error)
 	GetByMessageID(ctx context.Context, messageID uuid.UUID) ([]model.Recipient, error)
 	GetByProviderID(ctx context.Context, providerID string) (*model.Recipient, error)
+	// Fail moves a recipient to StatusFailed, recording why.
+	Fail(ctx context.Context, id uuid.UUID, errMsg string) error
+	// Defer holds a recipient back until the given time within tx, moving it
+	// to StatusScheduled for the scheduler to publish later.
+	Defer(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, until time.Time) error
+	// Reschedule is Defer for a recipient that has already been published and
+	// is being handed back to the scheduler by the worker. Like
+	// StartProcessing, it only applies to a pending or queued recipient.
+	Reschedule(ctx context.Context, id uuid.UUID, until time.Time) error
+	// StartProcessing moves a pending or queued recipient to
+	// StatusProcessing. It returns ErrCancelled if the recipient has been
+	// cancelled, ErrNotPending if it is in any other status, and ErrNotFound
+	// if it doesn't exist, or isn't visible yet.
+	StartProcessing(ctx context.Context, id uuid.UUID) error
+	// CancelPending cancels the recipients of a message that haven't been
+	// handed to a provider yet within tx. It returns how many were cancelled
+	// and how many recipients the message has in total.
+	CancelPending(ctx context.Context, tx *sqlx.Tx, messageID uuid.UUID) (cancelled, total int, err error)
+	// ClaimDeferred locks up to limit deferred recipients due before the
+	// given time within tx, skipping rows locked by another transaction.
+	ClaimDeferred(ctx context.Context, tx *sqlx.Tx, before time.Time, limit int) ([]model.Recipient, error)
+	// ClaimDeferredByMessage is ClaimDeferred for the recipients of one
+	// message.
+	ClaimDeferredByMessage(ctx context.Context, tx *sqlx.Tx, messageID uuid.UUID, before time.Time) ([]model.Recipient, error)
+	// RecordDispatchFailure counts a failed publish of a claimed deferred
+	// recipient within tx, deferring it again until retryAt. Once
+	// maxAttempts have failed the recipient is moved to StatusFailed with
+	// errMsg instead, and failed is true.
+	RecordDispatchFailure(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, retryAt time.Time, maxAttempts int, errMsg string) (failed bool, err error)
+	// FailPending fails the recipients of a message that haven't been handed
+	// to the queue yet within tx, recording why.
+	FailPending(ctx context.Context, tx *sqlx.Tx, messageID uuid.UUID, errMsg string) error
+	// Release moves a claimed deferred recipient back to StatusPending within
+	// tx once it has been published. It returns ErrNotFound if the recipient
+	// is no longer deferred.
+	Release(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) error
+	// ListByAddress returns the recipient rows for address across userID's
+	// messages, newest first, starting after the given cursor. The returned
+	// cursor is nil when there are no more rows.
+	ListByAddress(ctx context.Context, userID uuid.UUID, address string, limit int, after *Cursor) ([]model.RecipientHistoryEntry, *Cursor, error)
+	// SummarizeByMessageIDs returns recipient status counts for each of the
+	// given messages. Messages without recipients are absent from the map.
+	SummarizeByMessageIDs(ctx context.Context, messageIDs []uuid.UUID) (map[uuid.UUID]*model.DeliverySummary, error)
+	// DeleteByMessageID removes all recipients of a message within tx, and
+	// records the hours they were created in so the delivery rollups drop
+	// them.
+	DeleteByMessageID(ctx context.Context, tx *sqlx.Tx, messageID uuid.UUID) error
 }
 
 type recipientRepository struct {
@@ -29,9 +89,12 @@ func NewRecipientRepository(db *sqlx.DB) RecipientRepository {
 	return &recipientRepository{db: db}
 }
 
+const recipientColumns = `id, message_id, recipient, status, provider_id, error_message, retry_count,
+	                  sent_at, delivered_at, deferred_until, created_at, updated_at`
+
 func (r *recipientRepository) BatchCreate(ctx context.Context, tx *sqlx.Tx, recipients []model.Recipient) error {
-	query := `INSERT INTO message_recipients (id, message_id, recipient, status, retry_count, created_at, updated_at)
-	           VALUES (:id, :message_id, :recipient, :status, :retry_count, :created_at, :updated_at)`
+	query := `INSERT INTO message_recipients (id, message_id, recipient, status, retry_count, deferred_until, created_at, updated_at)
+	           VALUES (:id, :message_id, :recipient, :status, :retry_count, :deferred_until, :created_at, :updated_at)`
 
 	_, err := tx.NamedExecContext(ctx, query, recipients)
 	return err
@@ -46,7 +109,7 @@ func (r *recipientRepository) UpdateStatus(ctx context.Context, id uuid.UUID, st
 	// Set timestamp columns based on status
 	switch status {
 	case model.StatusSent:
-		query += `, sent_at = $4 WHERE id = $5`
+		query += `, sent_at = $4, error_message = NULL WHERE id = $5`
 		result, err := r.db.ExecContext(ctx, query, status, providerID, now, now, id)
 		if err != nil {
 			return err
@@ -69,10 +132,24 @@ func (r *recipientRepository) UpdateStatus(ctx context.Context, id uuid.UUID, st
 	}
 }
 
+func (r *recipientRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Recipient, error) {
+	var recipient model.Recipient
+	query := `SELECT ` + recipientColumns + `
+	           FROM message_recipients WHERE id = $1`
+
+	if err := r.db.GetContext(ctx, &recipient, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &recipient, nil
+}
+
 func (r *recipientRepository) GetByMessageID(ctx context.Context, messageID uuid.UUID) ([]model.Recipient, error) {
 	var recipients []model.Recipient
-	query := `SELECT id, message_id, recipient, status, provider_id, error_message, retry_count,
-	                  sent_at, delivered_at, created_at, updated_at
+	query := `SELECT ` + recipientColumns + `
 	           FROM message_recipients WHERE message_id = $1 ORDER BY created_at`
 
 	if err := r.db.SelectContext(ctx, &recipients, query, messageID); err != nil {
@@ -84,8 +161,7 @@ func (r *recipientRepository) GetByMessageID(ctx context.Context, messageID uuid
 
 func (r *recipientRepository) GetByProviderID(ctx context.Context, providerID string) (*model.Recipient, error) {
 	var recipient model.Recipient
-	query := `SELECT id, message_id, recipient, status, provider_id, error_message, retry_count,
-	                  sent_at, delivered_at, created_at, updated_at
+	query := `SELECT ` + recipientColumns + `
 	           FROM message_recipients WHERE provider_id = $1`
 
 	if err := r.db.GetContext(ctx, &recipient, query, providerID); err != nil {
@@ -98,6 +174,241 @@ func (r *recipientRepository) GetByProviderID(ctx context.Context, providerID st
 	return &recipient, nil
 }
 
+func (r *recipientRepository) Fail(ctx context.Context, id uuid.UUID, errMsg string) error {
+	query := `UPDATE message_recipients SET status = $1, error_message = $2, updated_at = $3 WHERE id = $4`
+	result, err := r.db.ExecContext(ctx, query, model.StatusFailed, errMsg, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(result)
+}
+
+func (r *recipientRepository) Defer(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, until time.Time) error {
+	query := `UPDATE message_recipients SET status = $1, deferred_until = $2, updated_at = $3 WHERE id = $4`
+	result, err := tx.ExecContext(ctx, query, model.StatusScheduled, until, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(result)
+}
+
+func (r *recipientRepository) Reschedule(ctx context.Context, id uuid.UUID, until time.Time) error {
+	query := `UPDATE message_recipients SET status = $1, deferred_until = $2, updated_at = $3 WHERE id = $4 AND status IN ($5, $6)`
+	result, err := r.db.ExecContext(ctx, query, model.StatusScheduled, until, time.Now(), id, model.StatusPending, model.StatusQueued)
+	if err != nil {
+		return err
+	}
+	return r.checkPending(ctx, id, result)
+}
+
+func (r *recipientRepository) StartProcessing(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE message_recipients SET status = $1, updated_at = $2 WHERE id = $3 AND status IN ($4, $5)`
+	result, err := r.db.ExecContext(ctx, query, model.StatusProcessing, time.Now(), id, model.StatusPending, model.StatusQueued)
+	if err != nil {
+		return err
+	}
+	return r.checkPending(ctx, id, result)
+}
+
+// checkPending explains an update of recipient id guarded by "status is
+// pending or queued" that matched no rows: ErrCancelled if the recipient is
+// cancelled, ErrNotPending if it is in another status, otherwise ErrNotFound.
+func (r *recipientRepository) checkPending(ctx context.Context, id uuid.UUID, result sql.Result) error {
+	if err := checkRowsAffected(result); !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	var status model.MessageStatus
+	if err := r.db.GetContext(ctx, &status, `SELECT status FROM message_recipients WHERE id = $1`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+	switch status {
+	case model.StatusCancelled:
+		return ErrCancelled
+	case model.StatusPending, model.StatusQueued:
+		// Committed between the update and the check; the caller can retry.
+		return ErrNotFound
+	default:
+		return ErrNotPending
+	}
+}
+
+func (r *recipientRepository) CancelPending(ctx context.Context, tx *sqlx.Tx, messageID uuid.UUID) (int, int, error) {
+	// The count in the outer query sees the rows as they were before the
+	// update, so it includes the recipients being cancelled.
+	query := `WITH cancelled AS (
+	               UPDATE message_recipients
+	               SET status = $1, deferred_until = NULL, updated_at = $2
+	               WHERE message_id = $3 AND status IN ($4, $5, $6)
+	               RETURNING 1
+	           )
+	           SELECT (SELECT COUNT(*) FROM cancelled) AS cancelled,
+	                  (SELECT COUNT(*) FROM message_recipients WHERE message_id = $3) AS total`
+
+	var counts struct {
+		Cancelled int `db:"cancelled"`
+		Total     int `db:"total"`
+	}
+	err := tx.GetContext(ctx, &counts, query, model.StatusCancelled, time.Now(), messageID,
+		model.StatusQueued, model.StatusPending, model.StatusScheduled)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return counts.Cancelled, counts.Total, nil
+}
+
+func (r *recipientRepository) ClaimDeferred(ctx context.Context, tx *sqlx.Tx, before time.Time, limit int) ([]model.Recipient, error) {
+	query := `SELECT ` + recipientColumns + `
+	           FROM message_recipients
+	           WHERE status = $1 AND deferred_until <= $2
+	           ORDER BY deferred_until ASC
+	           LIMIT $3
+	           FOR UPDATE SKIP LOCKED`
+
+	var recipients []model.Recipient
+	if err := tx.SelectContext(ctx, &recipients, query, model.StatusScheduled, before, limit); err != nil {
+		return nil, err
+	}
+
+	return recipients, nil
+}
+
+func (r *recipientRepository) ClaimDeferredByMessage(ctx context.Context, tx *sqlx.Tx, messageID uuid.UUID, before time.Time) ([]model.Recipient, error) {
+	query := `SELECT ` + recipientColumns + `
+	           FROM message_recipients
+	           WHERE message_id = $1 AND status = $2 AND deferred_until <= $3
+	           ORDER BY created_at
+	           FOR UPDATE SKIP LOCKED`
+
+	var recipients []model.Recipient
+	if err := tx.SelectContext(ctx, &recipients, query, messageID, model.StatusScheduled, before); err != nil {
+		return nil, err
+	}
+
+	return recipients, nil
+}
+
+func (r *recipientRepository) RecordDispatchFailure(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, retryAt time.Time, maxAttempts int, errMsg string) (bool, error) {
+	// The CASEs see dispatch_attempts before this update.
+	query := `UPDATE message_recipients
+	           SET dispatch_attempts = dispatch_attempts + 1,
+	               status = CASE WHEN dispatch_attempts + 1 >= $1 THEN $2 ELSE status END,
+	               error_message = CASE WHEN dispatch_attempts + 1 >= $1 THEN $3 ELSE error_message END,
+	               deferred_until = CASE WHEN dispatch_attempts + 1 >= $1 THEN NULL ELSE $4 END,
+	               updated_at = $5
+	           WHERE id = $6 AND status = $7
+	           RETURNING status`
+
+	var status model.MessageStatus
+	err := tx.GetContext(ctx, &status, query, maxAttempts, model.StatusFailed, errMsg, retryAt, time.Now(), id, model.StatusScheduled)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, ErrNotFound
+		}
+		return false, err
+	}
+
+	return status == model.StatusFailed, nil
+}
+
+func (r *recipientRepository) FailPending(ctx context.Context, tx *sqlx.Tx, messageID uuid.UUID, errMsg string) error {
+	query := `UPDATE message_recipients
+	           SET status = $1, error_message = $2, deferred_until = NULL, updated_at = $3
+	           WHERE message_id = $4 AND status IN ($5, $6)`
+	_, err := tx.ExecContext(ctx, query, model.StatusFailed, errMsg, time.Now(), messageID,
+		model.StatusPending, model.StatusScheduled)
+	return err
+}
+
+func (r *recipientRepository) Release(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) error {
+	query := `UPDATE message_recipients SET status = $1, deferred_until = NULL, updated_at = $2 WHERE id = $3 AND status = $4`
+	result, err := tx.ExecContext(ctx, query, model.StatusPending, time.Now(), id, model.StatusScheduled)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(result)
+}
+
+func (r *recipientRepository) ListByAddress(ctx context.Context, userID uuid.UUID, address string, limit int, after *Cursor) ([]model.RecipientHistoryEntry, *Cursor, error) {
+	// The address lookup uses idx_recipients_recipient; the join scopes the
+	// rows to the caller's messages.
+	query := `SELECT mr.id, mr.message_id, mr.recipient, mr.status, mr.provider_id, mr.error_message,
+	                 mr.retry_count, mr.sent_at, mr.delivered_at, mr.deferred_until, mr.created_at,
+	                 mr.updated_at, m.subject, m.platform
+	           FROM message_recipients mr
+	           JOIN messages m ON m.id = mr.message_id
+	           WHERE mr.recipient = $1 AND m.user_id = $2`
+	args := []interface{}{address, userID}
+
+	if after != nil {
+		query += ` AND (mr.created_at, mr.id) < ($3, $4)`
+		args = append(args, after.CreatedAt, after.ID)
+	}
+	query += fmt.Sprintf(` ORDER BY mr.created_at DESC, mr.id DESC LIMIT $%d`, len(args)+1)
+	args = append(args, limit+1)
+
+	var entries []model.RecipientHistoryEntry
+	if err := r.db.SelectContext(ctx, &entries, query, args...); err != nil {
+		return nil, nil, err
+	}
+
+	var next *Cursor
+	if len(entries) > limit {
+		entries = entries[:limit]
+		last := entries[len(entries)-1]
+		next = &Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	return entries, next, nil
+}
+
+func (r *recipientRepository) SummarizeByMessageIDs(ctx context.Context, messageIDs []uuid.UUID) (map[uuid.UUID]*model.DeliverySummary, error) {
+	summaries := make(map[uuid.UUID]*model.DeliverySummary, len(messageIDs))
+	if len(messageIDs) == 0 {
+		return summaries, nil
+	}
+
+	query := `SELECT message_id, status, COUNT(*) AS count
+	           FROM message_recipients
+	           WHERE message_id = ANY($1)
+	           GROUP BY message_id, status`
+
+	var rows []struct {
+		MessageID uuid.UUID           `db:"message_id"`
+		Status    model.MessageStatus `db:"status"`
+		Count     int                 `db:"count"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, pq.Array(messageIDs)); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		summary, ok := summaries[row.MessageID]
+		if !ok {
+			summary = &model.DeliverySummary{}
+			summaries[row.MessageID] = summary
+		}
+		summary.Add(row.Status, row.Count)
+	}
+
+	return summaries, nil
+}
+
+func (r *recipientRepository) DeleteByMessageID(ctx context.Context, tx *sqlx.Tx, messageID uuid.UUID) error {
+	query := `WITH deleted AS (
+	              DELETE FROM message_recipients WHERE message_id = $1 RETURNING created_at
+	          )
+	          INSERT INTO delivery_rollup_deletions (bucket, deleted_at)
+	          SELECT DISTINCT date_trunc('hour', created_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC', $2
+	          FROM deleted`
+	_, err := tx.ExecContext(ctx, query, messageID, time.Now())
+	return err
+}
+
 // checkRowsAffected returns ErrNotFound if no rows were updated.
 func checkRowsAffected(result interface{ RowsAffected() (int64, error) }) error {
 	rows, err := result.RowsAffected()
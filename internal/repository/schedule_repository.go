@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"notification-system/internal/model"
+)
+
+// ScheduleRepository defines data access operations for recurring schedules.
+type ScheduleRepository interface {
+	Create(ctx context.Context, sched *model.Schedule) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Schedule, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]model.Schedule, error)
+	// SetStatus changes a schedule's status and next run time.
+	SetStatus(ctx context.Context, id uuid.UUID, status model.ScheduleStatus, nextRunAt *time.Time) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// ClaimDue locks up to limit active schedules whose next run is due
+	// before the given time within tx, skipping rows locked by another
+	// transaction.
+	ClaimDue(ctx context.Context, tx *sqlx.Tx, before time.Time, limit int) ([]model.Schedule, error)
+	// Advance records a run within tx and moves the schedule to its next run.
+	Advance(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, lastRunAt time.Time, nextRunAt *time.Time, status model.ScheduleStatus) error
+}
+
+type scheduleRepository struct {
+	db *sqlx.DB
+}
+
+// NewScheduleRepository creates a new ScheduleRepository backed by sqlx.
+func NewScheduleRepository(db *sqlx.DB) ScheduleRepository {
+	return &scheduleRepository{db: db}
+}
+
+const scheduleColumns = `id, user_id, api_key_id, name, cron_expr, rrule, timezone, subject, body, sender, recipients, platform, priority, status, starts_at, ends_at, send_window, next_run_at, last_run_at, created_at, updated_at`
+
+func (r *scheduleRepository) Create(ctx context.Context, sched *model.Schedule) error {
+	query := `INSERT INTO schedules (` + scheduleColumns + `)
+	           VALUES (:id, :user_id, :api_key_id, :name, :cron_expr, :rrule, :timezone, :subject, :body, :sender, :recipients, :platform, :priority, :status, :starts_at, :ends_at, :send_window, :next_run_at, :last_run_at, :created_at, :updated_at)`
+
+	_, err := r.db.NamedExecContext(ctx, query, sched)
+	return err
+}
+
+func (r *scheduleRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Schedule, error) {
+	var sched model.Schedule
+	query := `SELECT ` + scheduleColumns + ` FROM schedules WHERE id = $1`
+
+	if err := r.db.GetContext(ctx, &sched, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &sched, nil
+}
+
+func (r *scheduleRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]model.Schedule, error) {
+	var schedules []model.Schedule
+	query := `SELECT ` + scheduleColumns + ` FROM schedules WHERE user_id = $1 ORDER BY created_at DESC`
+
+	if err := r.db.SelectContext(ctx, &schedules, query, userID); err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+func (r *scheduleRepository) SetStatus(ctx context.Context, id uuid.UUID, status model.ScheduleStatus, nextRunAt *time.Time) error {
+	query := `UPDATE schedules SET status = $1, next_run_at = $2, updated_at = $3 WHERE id = $4`
+	result, err := r.db.ExecContext(ctx, query, status, nextRunAt, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(result)
+}
+
+func (r *scheduleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM schedules WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(result)
+}
+
+func (r *scheduleRepository) ClaimDue(ctx context.Context, tx *sqlx.Tx, before time.Time, limit int) ([]model.Schedule, error) {
+	query := `SELECT ` + scheduleColumns + `
+	           FROM schedules
+	           WHERE status = $1 AND next_run_at <= $2
+	           ORDER BY next_run_at ASC
+	           LIMIT $3
+	           FOR UPDATE SKIP LOCKED`
+
+	var schedules []model.Schedule
+	if err := tx.SelectContext(ctx, &schedules, query, model.ScheduleActive, before, limit); err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+func (r *scheduleRepository) Advance(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, lastRunAt time.Time, nextRunAt *time.Time, status model.ScheduleStatus) error {
+	query := `UPDATE schedules SET status = $1, last_run_at = $2, next_run_at = $3, updated_at = $4 WHERE id = $5`
+	result, err := tx.ExecContext(ctx, query, status, lastRunAt, nextRunAt, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(result)
+}
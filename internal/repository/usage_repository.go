@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"notification-system/internal/model"
+)
+
+// UsageRepository defines data access operations for the usage ledger.
+type UsageRepository interface {
+	Create(ctx context.Context, rec *model.UsageRecord) error
+	Report(ctx context.Context, f model.UsageReportFilter) ([]model.UsageReportRow, error)
+}
+
+type usageRepository struct {
+	db *sqlx.DB
+}
+
+// NewUsageRepository creates a new UsageRepository backed by sqlx.
+func NewUsageRepository(db *sqlx.DB) UsageRepository {
+	return &usageRepository{db: db}
+}
+
+func (r *usageRepository) Create(ctx context.Context, rec *model.UsageRecord) error {
+	query := `INSERT INTO usage_records (id, user_id, api_key_id, message_id, recipient_id, platform, provider, encoding,
+	                                     segments, success, estimated_cost, currency, created_at)
+	           VALUES (:id, :user_id, :api_key_id, :message_id, :recipient_id, :platform, :provider, :encoding,
+	                   :segments, :success, :estimated_cost, :currency, :created_at)`
+
+	_, err := r.db.NamedExecContext(ctx, query, rec)
+	return err
+}
+
+func (r *usageRepository) Report(ctx context.Context, f model.UsageReportFilter) ([]model.UsageReportRow, error) {
+	conditions := []string{"u.created_at >= :from_date", "u.created_at < :to_date"}
+	params := map[string]interface{}{
+		"from_date": f.From,
+		"to_date":   f.To,
+	}
+
+	if f.UserID != nil {
+		conditions = append(conditions, "u.user_id = :user_id")
+		params["user_id"] = *f.UserID
+	}
+	if f.Platform != "" {
+		conditions = append(conditions, "u.platform = :platform")
+		params["platform"] = f.Platform
+	}
+
+	query := fmt.Sprintf(
+		`SELECT to_char(date_trunc('day', u.created_at AT TIME ZONE 'UTC'), 'YYYY-MM-DD') AS day,
+		        u.platform, u.user_id, usr.email,
+		        u.api_key_id, k.name AS api_key_name, k.prefix AS api_key_prefix,
+		        COUNT(*)                                  AS attempts,
+		        COUNT(*) FILTER (WHERE u.success)         AS successful,
+		        COUNT(*) FILTER (WHERE NOT u.success)     AS failed,
+		        COALESCE(SUM(u.segments), 0)              AS segments,
+		        COALESCE(SUM(u.estimated_cost), 0)        AS estimated_cost,
+		        u.currency
+		 FROM usage_records u
+		 JOIN users usr ON usr.id = u.user_id
+		 LEFT JOIN api_keys k ON k.id = u.api_key_id
+		 WHERE %s
+		 GROUP BY 1, u.platform, u.user_id, usr.email, u.api_key_id, k.name, k.prefix, u.currency
+		 ORDER BY 1, u.platform, usr.email, k.name`, strings.Join(conditions, " AND "))
+
+	query, args, err := sqlx.Named(query, params)
+	if err != nil {
+		return nil, err
+	}
+	query = r.db.Rebind(query)
+
+	var rows []model.UsageReportRow
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
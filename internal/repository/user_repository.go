@@ -4,20 +4,31 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 
 	"notification-system/internal/model"
 )
 
-// ErrNotFound is returned when a query finds no matching rows.
-var ErrNotFound = errors.New("record not found")
+var (
+	// ErrNotFound is returned when a query finds no matching rows.
+	ErrNotFound = errors.New("record not found")
+	// ErrDuplicate is returned when an insert violates a unique constraint.
+	ErrDuplicate = errors.New("record already exists")
+)
 
 // UserRepository defines data access operations for users.
 type UserRepository interface {
-	GetByAPIKeyHash(ctx context.Context, hash string) (*model.User, error)
+	Create(ctx context.Context, tx *sqlx.Tx, user *model.User) error
 	GetByID(ctx context.Context, id uuid.UUID) (*model.User, error)
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	Update(ctx context.Context, user *model.User) error
+	List(ctx context.Context, q model.ListUsersQuery) ([]model.UserWithStats, int, error)
 }
 
 type userRepository struct {
@@ -29,12 +40,25 @@ func NewUserRepository(db *sqlx.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) GetByAPIKeyHash(ctx context.Context, hash string) (*model.User, error) {
+func (r *userRepository) Create(ctx context.Context, tx *sqlx.Tx, user *model.User) error {
+	query := `INSERT INTO users (id, email, role, rate_limit_tier, is_active, send_window, created_at, updated_at)
+	           VALUES (:id, :email, :role, :rate_limit_tier, :is_active, :send_window, :created_at, :updated_at)`
+
+	if _, err := tx.NamedExecContext(ctx, query, user); err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
 	var user model.User
-	query := `SELECT id, email, api_key_hash, role, rate_limit_tier, is_active, created_at, updated_at
-	           FROM users WHERE api_key_hash = $1`
+	query := `SELECT id, email, role, rate_limit_tier, is_active, send_window, created_at, updated_at
+	           FROM users WHERE id = $1`
 
-	if err := r.db.GetContext(ctx, &user, query, hash); err != nil {
+	if err := r.db.GetContext(ctx, &user, query, id); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrNotFound
 		}
@@ -44,12 +68,12 @@ func (r *userRepository) GetByAPIKeyHash(ctx context.Context, hash string) (*mod
 	return &user, nil
 }
 
-func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
-	query := `SELECT id, email, api_key_hash, role, rate_limit_tier, is_active, created_at, updated_at
-	           FROM users WHERE id = $1`
+	query := `SELECT id, email, role, rate_limit_tier, is_active, send_window, created_at, updated_at
+	           FROM users WHERE email = $1`
 
-	if err := r.db.GetContext(ctx, &user, query, id); err != nil {
+	if err := r.db.GetContext(ctx, &user, query, email); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrNotFound
 		}
@@ -58,3 +82,89 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.User
 
 	return &user, nil
 }
+
+// Update persists the mutable fields of user: role, tier, active flag and
+// send window.
+func (r *userRepository) Update(ctx context.Context, user *model.User) error {
+	user.UpdatedAt = time.Now()
+	query := `UPDATE users
+	           SET role = $1, rate_limit_tier = $2, is_active = $3, send_window = $4, updated_at = $5
+	           WHERE id = $6`
+
+	result, err := r.db.ExecContext(ctx, query, user.Role, user.RateLimitTier, user.IsActive, user.SendWindow, user.UpdatedAt, user.ID)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(result)
+}
+
+func (r *userRepository) List(ctx context.Context, q model.ListUsersQuery) ([]model.UserWithStats, int, error) {
+	conditions := []string{"TRUE"}
+	params := map[string]interface{}{
+		"since": time.Now().AddDate(0, 0, -q.StatsDays),
+	}
+
+	if q.Email != "" {
+		conditions = append(conditions, "u.email ILIKE :email")
+		params["email"] = "%" + q.Email + "%"
+	}
+	if q.Role != "" {
+		conditions = append(conditions, "u.role = :role")
+		params["role"] = q.Role
+	}
+	if q.RateLimitTier != "" {
+		conditions = append(conditions, "u.rate_limit_tier = :tier")
+		params["tier"] = q.RateLimitTier
+	}
+	if q.IsActive != nil {
+		conditions = append(conditions, "u.is_active = :is_active")
+		params["is_active"] = *q.IsActive
+	}
+
+	where := strings.Join(conditions, " AND ")
+
+	countQuery, countArgs, err := sqlx.Named(fmt.Sprintf("SELECT COUNT(*) FROM users u WHERE %s", where), params)
+	if err != nil {
+		return nil, 0, err
+	}
+	countQuery = r.db.Rebind(countQuery)
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, countQuery, countArgs...); err != nil {
+		return nil, 0, err
+	}
+
+	params["limit"] = q.Limit
+	params["offset"] = (q.Page - 1) * q.Limit
+
+	dataQuery := fmt.Sprintf(
+		`SELECT u.id, u.email, u.role, u.rate_limit_tier, u.is_active, u.send_window, u.created_at, u.updated_at,
+		        (SELECT COUNT(*) FROM api_keys k
+		          WHERE k.user_id = u.id AND k.revoked_at IS NULL
+		            AND (k.expires_at IS NULL OR k.expires_at > NOW()))        AS active_keys,
+		        (SELECT COUNT(*) FROM messages m
+		          WHERE m.user_id = u.id AND m.created_at >= :since)           AS messages_sent,
+		        (SELECT COALESCE(SUM(ur.estimated_cost), 0) FROM usage_records ur
+		          WHERE ur.user_id = u.id AND ur.created_at >= :since)         AS estimated_cost
+		 FROM users u WHERE %s
+		 ORDER BY u.created_at DESC LIMIT :limit OFFSET :offset`, where)
+
+	dataQuery, dataArgs, err := sqlx.Named(dataQuery, params)
+	if err != nil {
+		return nil, 0, err
+	}
+	dataQuery = r.db.Rebind(dataQuery)
+
+	var users []model.UserWithStats
+	if err := r.db.SelectContext(ctx, &users, dataQuery, dataArgs...); err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// isUniqueViolation reports whether err is a PostgreSQL unique_violation.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
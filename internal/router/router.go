@@ -10,26 +10,42 @@ import (
 	"github.com/redis/go-redis/v9"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
 	"notification-system/docs"
+	"notification-system/internal/auth"
+	"notification-system/internal/cache"
 	"notification-system/internal/config"
 	"notification-system/internal/handler"
+	"notification-system/internal/health"
 	"notification-system/internal/middleware"
-	"notification-system/internal/queue"
+	"notification-system/internal/model"
 	"notification-system/internal/repository"
 	"notification-system/internal/service"
 	"notification-system/internal/version"
 )
 
-// Deps holds dependencies required by the router.
+// Deps holds dependencies required by the router. The services shared
+// with the background jobs are built by the caller, so each exists once.
 type Deps struct {
-	DB            *sqlx.DB
-	UserRepo      repository.UserRepository
-	MessageRepo   repository.MessageRepository
-	RecipientRepo repository.RecipientRepository
-	RedisClient   *redis.Client
-	RateLimit     config.RateLimitConfig
-	Publisher     *queue.Publisher
+	DB               *sqlx.DB
+	UserRepo         repository.UserRepository
+	APIKeyRepo       repository.APIKeyRepository
+	MessageRepo      repository.MessageRepository
+	RecipientRepo    repository.RecipientRepository
+	AttemptRepo      repository.DeliveryAttemptRepository
+	UsageRepo        repository.UsageRepository
+	AuditRepo        repository.AuditRepository
+	MessageService   *service.MessageService
+	ScheduleService  *service.ScheduleService
+	BulkService      *service.BulkService
+	ExportService    *service.ExportService
+	AnalyticsService *service.AnalyticsService
+	RedisClient      *redis.Client
+	Health           *health.Checker
+	CredCache        *cache.CredentialCache
+	TokenVerifier    *auth.TokenVerifier
+	RateLimit        config.RateLimitConfig
 }
 
 // NewRouter creates and configures the Gin engine with middleware and routes.
@@ -40,15 +56,27 @@ func NewRouter(deps Deps) *gin.Engine {
 
 	// Global middleware
 	r.Use(middleware.Recovery())
+	r.Use(middleware.RequestID())
+	r.Use(otelgin.Middleware("notification-api", otelgin.WithFilter(func(req *http.Request) bool {
+		switch req.URL.Path {
+		case "/health", "/livez", "/readyz", "/metrics":
+			return false
+		}
+		return true
+	})))
 	r.Use(middleware.Logger())
 	r.Use(middleware.Metrics())
 	r.Use(middleware.CORS())
 
-	// Health check
+	// Health check — kept for existing monitors; equivalent to /livez
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
+	// Liveness and readiness probes
+	r.GET("/livez", gin.WrapH(health.LiveHandler()))
+	r.GET("/readyz", gin.WrapH(deps.Health.ReadyHandler()))
+
 	// Version info
 	r.GET("/version", func(c *gin.Context) {
 		c.JSON(http.StatusOK, version.Info())
@@ -68,21 +96,98 @@ func NewRouter(deps Deps) *gin.Engine {
 
 	// API v1 route group — protected by auth + rate limiting
 	v1 := r.Group("/api/v1")
-	v1.Use(middleware.AuthMiddleware(deps.UserRepo))
+	v1.Use(middleware.AuthMiddleware(deps.APIKeyRepo, deps.UserRepo, deps.CredCache, deps.TokenVerifier))
 	v1.Use(middleware.RateLimitMiddleware(deps.RedisClient, deps.RateLimit))
 
 	// Services
-	msgService := service.NewMessageService(deps.DB, deps.MessageRepo, deps.RecipientRepo, deps.Publisher)
+	keyService := service.NewKeyService(deps.DB, deps.APIKeyRepo, deps.CredCache)
+	userService := service.NewUserService(deps.DB, deps.UserRepo, keyService, rateLimitTiers(deps.RateLimit))
 
 	// Message routes
-	msgHandler := handler.NewMessageHandler(deps.DB, deps.MessageRepo, deps.RecipientRepo, msgService)
+	msgHandler := handler.NewMessageHandler(deps.DB, deps.MessageRepo, deps.RecipientRepo, deps.AttemptRepo, deps.AuditRepo, deps.MessageService, deps.BulkService)
 	messages := v1.Group("/messages")
 	{
-		messages.POST("/send", msgHandler.SendMessage)
-		messages.POST("/bulk", msgHandler.BulkSend)
-		messages.GET("/:id", msgHandler.GetMessageStatus)
-		messages.GET("", msgHandler.ListMessages)
-		messages.DELETE("/:id", msgHandler.CancelMessage)
+		messages.POST("/send", middleware.RequireScope(auth.ScopeMessagesSend), msgHandler.SendMessage)
+		messages.POST("/bulk", middleware.RequireScope(auth.ScopeMessagesSend), msgHandler.BulkSend)
+		messages.POST("/bulk/upload", middleware.RequireScope(auth.ScopeMessagesSend), msgHandler.BulkUpload)
+		messages.GET("/:id", middleware.RequireScope(auth.ScopeMessagesRead), msgHandler.GetMessageStatus)
+		messages.GET("/:id/recipients/:rid", middleware.RequireScope(auth.ScopeMessagesRead), msgHandler.GetRecipient)
+		messages.GET("", middleware.RequireScope(auth.ScopeMessagesRead), msgHandler.ListMessages)
+		messages.PATCH("/:id", middleware.RequireScope(auth.ScopeMessagesWrite), msgHandler.UpdateMessage)
+		messages.DELETE("/:id", middleware.RequireScope(auth.ScopeMessagesWrite), msgHandler.CancelMessage)
+	}
+
+	// Bulk job routes
+	jobHandler := handler.NewJobHandler(deps.BulkService)
+	jobs := v1.Group("/jobs")
+	{
+		jobs.GET("/:id", middleware.RequireScope(auth.ScopeMessagesRead), jobHandler.GetJob)
+	}
+
+	// Export routes
+	exportHandler := handler.NewExportHandler(deps.ExportService, deps.AuditRepo)
+	exports := v1.Group("/exports")
+	{
+		exports.POST("", middleware.RequireScope(auth.ScopeMessagesRead), exportHandler.CreateExport)
+		exports.GET("/:id", middleware.RequireScope(auth.ScopeMessagesRead), exportHandler.GetExport)
+		exports.GET("/:id/download", middleware.RequireScope(auth.ScopeMessagesRead), exportHandler.DownloadExport)
+	}
+
+	// Recipient history routes
+	recipientHandler := handler.NewRecipientHandler(deps.RecipientRepo)
+	recipients := v1.Group("/recipients")
+	{
+		recipients.GET("", middleware.RequireScope(auth.ScopeMessagesRead), recipientHandler.ListRecipients)
+	}
+
+	// Recurring schedule routes
+	scheduleHandler := handler.NewScheduleHandler(deps.ScheduleService, deps.AuditRepo)
+	schedules := v1.Group("/schedules")
+	{
+		schedules.POST("", middleware.RequireScope(auth.ScopeSchedulesWrite), scheduleHandler.CreateSchedule)
+		schedules.GET("", middleware.RequireScope(auth.ScopeSchedulesRead), scheduleHandler.ListSchedules)
+		schedules.GET("/:id", middleware.RequireScope(auth.ScopeSchedulesRead), scheduleHandler.GetSchedule)
+		schedules.POST("/:id/pause", middleware.RequireScope(auth.ScopeSchedulesWrite), scheduleHandler.PauseSchedule)
+		schedules.POST("/:id/resume", middleware.RequireScope(auth.ScopeSchedulesWrite), scheduleHandler.ResumeSchedule)
+		schedules.DELETE("/:id", middleware.RequireScope(auth.ScopeSchedulesWrite), scheduleHandler.DeleteSchedule)
+	}
+
+	// API key routes
+	keyHandler := handler.NewKeyHandler(keyService, deps.AuditRepo)
+	keys := v1.Group("/keys")
+	{
+		keys.POST("", middleware.RequireScope(auth.ScopeKeysWrite), keyHandler.CreateKey)
+		keys.GET("", middleware.RequireScope(auth.ScopeKeysRead), keyHandler.ListKeys)
+		keys.POST("/:id/rotate", middleware.RequireScope(auth.ScopeKeysWrite), keyHandler.RotateKey)
+		keys.DELETE("/:id", middleware.RequireScope(auth.ScopeKeysWrite), keyHandler.RevokeKey)
+	}
+
+	// Usage routes
+	usageHandler := handler.NewUsageHandler(deps.UsageRepo)
+	usage := v1.Group("/usage")
+	{
+		usage.GET("/report", middleware.RequireScope(auth.ScopeUsageRead), usageHandler.Report)
+	}
+
+	// Analytics routes
+	analyticsHandler := handler.NewAnalyticsHandler(deps.AnalyticsService)
+	analytics := v1.Group("/analytics")
+	{
+		analytics.GET("/deliveries", middleware.RequireScope(auth.ScopeMessagesRead), analyticsHandler.Deliveries)
+	}
+
+	// Admin routes — require the admin role as well as the admin scope
+	adminHandler := handler.NewAdminHandler(userService, deps.AuditRepo)
+	admin := v1.Group("/admin")
+	admin.Use(middleware.RequireRole(model.RoleAdmin), middleware.RequireScope(auth.ScopeAdmin))
+	{
+		admin.POST("/users", adminHandler.CreateUser)
+		admin.GET("/users", adminHandler.ListUsers)
+		admin.GET("/users/:id", adminHandler.GetUser)
+		admin.PATCH("/users/:id", adminHandler.UpdateUser)
+		admin.POST("/users/:id/deactivate", adminHandler.DeactivateUser)
+		admin.POST("/users/:id/reactivate", adminHandler.ReactivateUser)
+		admin.GET("/audit", adminHandler.ListAudit)
 	}
 
 	// Webhook routes — unauthenticated (providers POST callbacks here)
@@ -95,3 +200,12 @@ func NewRouter(deps Deps) *gin.Engine {
 
 	return r
 }
+
+// rateLimitTiers returns the names of the configured rate limit tiers.
+func rateLimitTiers(cfg config.RateLimitConfig) []string {
+	tiers := make([]string, 0, len(cfg.Tiers))
+	for name := range cfg.Tiers {
+		tiers = append(tiers, name)
+	}
+	return tiers
+}
@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"notification-system/internal/metrics"
+	"notification-system/internal/service"
+)
+
+// AnalyticsRollup periodically brings the hourly delivery rollups up to
+// date. Recipients updated within the last lag are left for the next run,
+// and updates within overlap before the previous run's cutoff are read
+// again, so that updates committed late, or stamped by a replica whose
+// clock is behind, aren't skipped.
+type AnalyticsRollup struct {
+	analyticsService *service.AnalyticsService
+	interval         time.Duration
+	lag              time.Duration
+	overlap          time.Duration
+}
+
+// NewAnalyticsRollup creates a new AnalyticsRollup job.
+func NewAnalyticsRollup(analyticsService *service.AnalyticsService, interval, lag, overlap time.Duration) *AnalyticsRollup {
+	if interval == 0 {
+		interval = time.Minute
+	}
+	return &AnalyticsRollup{
+		analyticsService: analyticsService,
+		interval:         interval,
+		lag:              lag,
+		overlap:          overlap,
+	}
+}
+
+// Start runs the rollup loop. Blocks until ctx is cancelled; a rollup in
+// progress is rolled back and redone by the next run.
+func (a *AnalyticsRollup) Start(ctx context.Context) {
+	log.Info().
+		Dur("interval", a.interval).
+		Dur("lag", a.lag).
+		Dur("overlap", a.overlap).
+		Msg("analytics rollup started")
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	a.rollup(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("analytics rollup stopped")
+			return
+		case <-ticker.C:
+			a.rollup(ctx)
+		}
+	}
+}
+
+func (a *AnalyticsRollup) rollup(ctx context.Context) {
+	hours, err := a.analyticsService.Rollup(ctx, time.Now().Add(-a.lag), a.overlap)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error().Err(err).Msg("analytics rollup: failed to refresh rollups")
+		}
+		return
+	}
+	if hours > 0 {
+		log.Debug().Int("hours", hours).Msg("analytics rollup: refreshed rollups")
+	}
+
+	watermark, err := a.analyticsService.Watermark(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("analytics rollup: failed to read watermark")
+		return
+	}
+	if watermark != nil {
+		metrics.AnalyticsRollupLag.Set(time.Since(*watermark).Seconds())
+	}
+}
@@ -0,0 +1,69 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"notification-system/internal/repository"
+)
+
+// AuditRetention periodically deletes audit events older than the retention period.
+type AuditRetention struct {
+	auditRepo repository.AuditRepository
+	retention time.Duration
+	interval  time.Duration
+}
+
+// NewAuditRetention creates a new AuditRetention job.
+func NewAuditRetention(auditRepo repository.AuditRepository, retention, interval time.Duration) *AuditRetention {
+	if interval == 0 {
+		interval = time.Hour
+	}
+	return &AuditRetention{
+		auditRepo: auditRepo,
+		retention: retention,
+		interval:  interval,
+	}
+}
+
+// Start runs the purge loop. It returns immediately if retention is disabled,
+// otherwise it blocks until ctx is cancelled.
+func (a *AuditRetention) Start(ctx context.Context) {
+	if a.retention <= 0 {
+		log.Info().Msg("audit retention disabled, keeping events forever")
+		return
+	}
+
+	log.Info().
+		Dur("retention", a.retention).
+		Dur("interval", a.interval).
+		Msg("audit retention started")
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	a.purge(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("audit retention stopped")
+			return
+		case <-ticker.C:
+			a.purge(ctx)
+		}
+	}
+}
+
+func (a *AuditRetention) purge(ctx context.Context) {
+	cutoff := time.Now().Add(-a.retention)
+	n, err := a.auditRepo.DeleteBefore(ctx, cutoff)
+	if err != nil {
+		log.Error().Err(err).Msg("audit retention: failed to purge events")
+		return
+	}
+	if n > 0 {
+		log.Info().Int64("deleted", n).Time("cutoff", cutoff).Msg("audit retention: purged events")
+	}
+}
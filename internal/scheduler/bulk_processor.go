@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"notification-system/internal/service"
+)
+
+// BulkProcessor works through the items of accepted bulk jobs. It runs a
+// fixed number of loops, each processing one item at a time, so at most
+// concurrency items are sent at once per replica.
+type BulkProcessor struct {
+	bulkService *service.BulkService
+	concurrency int
+	batchSize   int
+	interval    time.Duration
+}
+
+// NewBulkProcessor creates a new BulkProcessor.
+func NewBulkProcessor(bulkService *service.BulkService, concurrency, batchSize int, interval time.Duration) *BulkProcessor {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if batchSize <= 0 {
+		batchSize = 20
+	}
+	if interval == 0 {
+		interval = time.Second
+	}
+	return &BulkProcessor{
+		bulkService: bulkService,
+		concurrency: concurrency,
+		batchSize:   batchSize,
+		interval:    interval,
+	}
+}
+
+// Start runs the processing loops. Blocks until ctx is cancelled and every
+// loop has finished its current batch.
+func (p *BulkProcessor) Start(ctx context.Context) {
+	log.Info().
+		Int("concurrency", p.concurrency).
+		Int("batch_size", p.batchSize).
+		Dur("interval", p.interval).
+		Msg("bulk processor started")
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.loop(ctx)
+		}()
+	}
+	wg.Wait()
+
+	log.Info().Msg("bulk processor stopped")
+}
+
+func (p *BulkProcessor) loop(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.drain(ctx)
+		}
+	}
+}
+
+// drain processes batches until none are left or ctx is cancelled. A batch
+// in progress is allowed to finish.
+func (p *BulkProcessor) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := p.bulkService.ProcessPending(context.WithoutCancel(ctx), p.batchSize)
+		if err != nil {
+			log.Error().Err(err).Msg("bulk processor: failed to process items")
+			return
+		}
+		if n < p.batchSize {
+			return
+		}
+	}
+}
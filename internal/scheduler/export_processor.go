@@ -0,0 +1,80 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"notification-system/internal/service"
+)
+
+// purgeEvery is how many polls pass between purges of expired exports.
+const purgeEvery = 60
+
+// ExportProcessor builds pending message exports one at a time and deletes
+// the files of expired ones.
+type ExportProcessor struct {
+	exportService *service.ExportService
+	interval      time.Duration
+}
+
+// NewExportProcessor creates a new ExportProcessor.
+func NewExportProcessor(exportService *service.ExportService, interval time.Duration) *ExportProcessor {
+	if interval == 0 {
+		interval = 5 * time.Second
+	}
+	return &ExportProcessor{
+		exportService: exportService,
+		interval:      interval,
+	}
+}
+
+// Start runs the processing loop. Blocks until ctx is cancelled and the
+// export in progress, if any, has finished.
+func (p *ExportProcessor) Start(ctx context.Context) {
+	log.Info().Dur("interval", p.interval).Msg("export processor started")
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	p.purge(ctx)
+	for polls := 1; ; polls++ {
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("export processor stopped")
+			return
+		case <-ticker.C:
+			p.drain(ctx)
+			if polls%purgeEvery == 0 {
+				p.purge(ctx)
+			}
+		}
+	}
+}
+
+// drain builds exports until none are pending or ctx is cancelled. An
+// export in progress is allowed to finish.
+func (p *ExportProcessor) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		found, err := p.exportService.ProcessNext(context.WithoutCancel(ctx))
+		if err != nil {
+			log.Error().Err(err).Msg("export processor: failed to process export")
+			return
+		}
+		if !found {
+			return
+		}
+	}
+}
+
+func (p *ExportProcessor) purge(ctx context.Context) {
+	n, err := p.exportService.PurgeExpired(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("export processor: failed to purge expired exports")
+		return
+	}
+	if n > 0 {
+		log.Info().Int("deleted", n).Msg("export processor: purged expired exports")
+	}
+}
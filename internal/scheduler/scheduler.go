@@ -6,22 +6,24 @@ import (
 
 	"github.com/rs/zerolog/log"
 
-	"notification-system/internal/repository"
 	"notification-system/internal/service"
 )
 
-// Scheduler polls for scheduled messages and publishes them when due.
+// Scheduler polls for recurring schedules, scheduled messages and deferred
+// recipients and publishes them when due. It is safe to run in every API replica: schedules
+// and messages are claimed with row locks, so each is handled by exactly one
+// scheduler.
 type Scheduler struct {
-	messageRepo repository.MessageRepository
-	msgService  *service.MessageService
-	interval    time.Duration
-	batchSize   int
+	msgService      *service.MessageService
+	scheduleService *service.ScheduleService
+	interval        time.Duration
+	batchSize       int
 }
 
 // NewScheduler creates a new Scheduler.
 func NewScheduler(
-	messageRepo repository.MessageRepository,
 	msgService *service.MessageService,
+	scheduleService *service.ScheduleService,
 	interval time.Duration,
 	batchSize int,
 ) *Scheduler {
@@ -32,14 +34,16 @@ func NewScheduler(
 		batchSize = 50
 	}
 	return &Scheduler{
-		messageRepo: messageRepo,
-		msgService:  msgService,
-		interval:    interval,
-		batchSize:   batchSize,
+		msgService:      msgService,
+		scheduleService: scheduleService,
+		interval:        interval,
+		batchSize:       batchSize,
 	}
 }
 
 // Start begins the scheduler polling loop. Blocks until ctx is cancelled.
+// A batch in progress when ctx is cancelled is allowed to finish, so it is
+// never left half-published, but no further batches are started.
 func (s *Scheduler) Start(ctx context.Context) {
 	log.Info().
 		Dur("interval", s.interval).
@@ -60,25 +64,73 @@ func (s *Scheduler) Start(ctx context.Context) {
 	}
 }
 
-// scan finds due scheduled messages and publishes them.
+// scan materializes due recurring schedules into scheduled messages, then
+// publishes due scheduled messages and recipients deferred by a send window.
+// Each runs a batch at a time until none are left, so a backlog drains
+// without waiting for further ticks. Batches run with ctx's cancellation
+// removed; ctx is checked between them.
 func (s *Scheduler) scan(ctx context.Context) {
-	messages, err := s.messageRepo.GetScheduledMessages(ctx, time.Now(), s.batchSize)
-	if err != nil {
-		log.Error().Err(err).Msg("scheduler: failed to get scheduled messages")
-		return
+	s.materialize(ctx)
+	s.dispatch(ctx)
+	s.dispatchDeferred(ctx)
+}
+
+func (s *Scheduler) materialize(ctx context.Context) {
+	for ctx.Err() == nil {
+		created, err := s.scheduleService.MaterializeDue(context.WithoutCancel(ctx), time.Now(), s.batchSize)
+		if err != nil {
+			log.Error().Err(err).Msg("scheduler: failed to materialize schedules")
+			return
+		}
+
+		if created == 0 {
+			return
+		}
+
+		log.Info().Int("count", created).Msg("scheduler: materialized recurring schedules")
+
+		if created < s.batchSize {
+			return
+		}
 	}
+}
+
+func (s *Scheduler) dispatch(ctx context.Context) {
+	for ctx.Err() == nil {
+		dispatched, err := s.msgService.DispatchScheduled(context.WithoutCancel(ctx), time.Now(), s.batchSize)
+		if err != nil {
+			log.Error().Err(err).Msg("scheduler: failed to dispatch scheduled messages")
+			return
+		}
 
-	if len(messages) == 0 {
-		return
+		if dispatched == 0 {
+			return
+		}
+
+		log.Info().Int("count", dispatched).Msg("scheduler: dispatched scheduled messages")
+
+		if dispatched < s.batchSize {
+			return
+		}
 	}
+}
 
-	log.Info().Int("count", len(messages)).Msg("scheduler: found scheduled messages")
+func (s *Scheduler) dispatchDeferred(ctx context.Context) {
+	for ctx.Err() == nil {
+		published, err := s.msgService.DispatchDeferred(context.WithoutCancel(ctx), time.Now(), s.batchSize)
+		if err != nil {
+			log.Error().Err(err).Msg("scheduler: failed to dispatch deferred recipients")
+			return
+		}
 
-	for i := range messages {
-		if err := s.msgService.PublishMessage(ctx, &messages[i]); err != nil {
-			log.Error().Err(err).
-				Str("message_id", messages[i].ID.String()).
-				Msg("scheduler: failed to publish scheduled message")
+		if published == 0 {
+			return
+		}
+
+		log.Info().Int("count", published).Msg("scheduler: published deferred recipients")
+
+		if published < s.batchSize {
+			return
 		}
 	}
 }
@@ -0,0 +1,143 @@
+package sendwindow
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// countryZones maps international calling codes to the timezones of the
+// country or numbering plan, main timezone first. Countries spanning many
+// timezones list a representative spread rather than every zone.
+var countryZones = map[string][]string{
+	"1":   {"America/New_York", "America/Chicago", "America/Denver", "America/Los_Angeles"},
+	"7":   {"Europe/Moscow", "Asia/Almaty", "Asia/Novosibirsk", "Asia/Vladivostok"},
+	"20":  {"Africa/Cairo"},
+	"27":  {"Africa/Johannesburg"},
+	"30":  {"Europe/Athens"},
+	"31":  {"Europe/Amsterdam"},
+	"32":  {"Europe/Brussels"},
+	"33":  {"Europe/Paris"},
+	"34":  {"Europe/Madrid"},
+	"36":  {"Europe/Budapest"},
+	"39":  {"Europe/Rome"},
+	"40":  {"Europe/Bucharest"},
+	"41":  {"Europe/Zurich"},
+	"43":  {"Europe/Vienna"},
+	"44":  {"Europe/London"},
+	"45":  {"Europe/Copenhagen"},
+	"46":  {"Europe/Stockholm"},
+	"47":  {"Europe/Oslo"},
+	"48":  {"Europe/Warsaw"},
+	"49":  {"Europe/Berlin"},
+	"51":  {"America/Lima"},
+	"52":  {"America/Mexico_City", "America/Tijuana"},
+	"54":  {"America/Argentina/Buenos_Aires"},
+	"55":  {"America/Sao_Paulo", "America/Manaus"},
+	"56":  {"America/Santiago"},
+	"57":  {"America/Bogota"},
+	"60":  {"Asia/Kuala_Lumpur"},
+	"61":  {"Australia/Sydney", "Australia/Adelaide", "Australia/Perth"},
+	"62":  {"Asia/Jakarta", "Asia/Makassar", "Asia/Jayapura"},
+	"63":  {"Asia/Manila"},
+	"64":  {"Pacific/Auckland"},
+	"65":  {"Asia/Singapore"},
+	"66":  {"Asia/Bangkok"},
+	"81":  {"Asia/Tokyo"},
+	"82":  {"Asia/Seoul"},
+	"84":  {"Asia/Ho_Chi_Minh"},
+	"86":  {"Asia/Shanghai"},
+	"90":  {"Europe/Istanbul"},
+	"91":  {"Asia/Kolkata"},
+	"92":  {"Asia/Karachi"},
+	"94":  {"Asia/Colombo"},
+	"98":  {"Asia/Tehran"},
+	"212": {"Africa/Casablanca"},
+	"213": {"Africa/Algiers"},
+	"216": {"Africa/Tunis"},
+	"234": {"Africa/Lagos"},
+	"254": {"Africa/Nairobi"},
+	"351": {"Europe/Lisbon"},
+	"352": {"Europe/Luxembourg"},
+	"353": {"Europe/Dublin"},
+	"354": {"Atlantic/Reykjavik"},
+	"358": {"Europe/Helsinki"},
+	"380": {"Europe/Kyiv"},
+	"420": {"Europe/Prague"},
+	"421": {"Europe/Bratislava"},
+	"852": {"Asia/Hong_Kong"},
+	"880": {"Asia/Dhaka"},
+	"886": {"Asia/Taipei"},
+	"966": {"Asia/Riyadh"},
+	"971": {"Asia/Dubai"},
+	"972": {"Asia/Jerusalem"},
+}
+
+var (
+	loadZonesOnce sync.Once
+	phoneZones    map[string][]*time.Location
+)
+
+// ZonesForPhone returns the timezones of an international phone number's
+// country, main timezone first, or nil if the number isn't in international
+// format or its country code is unknown. Numbers may be written with a
+// leading + or 00 and spaces, dashes, dots or parentheses.
+func ZonesForPhone(number string) []*time.Location {
+	loadZonesOnce.Do(loadPhoneZones)
+
+	digits, ok := internationalDigits(number)
+	if !ok {
+		return nil
+	}
+
+	// Calling codes are prefix-free, so the first match is the only one.
+	for n := 1; n <= 3 && n <= len(digits); n++ {
+		if zones, ok := phoneZones[digits[:n]]; ok {
+			return zones
+		}
+	}
+	return nil
+}
+
+// loadPhoneZones resolves countryZones, skipping names missing from the
+// system's timezone database.
+func loadPhoneZones() {
+	phoneZones = make(map[string][]*time.Location, len(countryZones))
+	for code, names := range countryZones {
+		var zones []*time.Location
+		for _, name := range names {
+			if loc, err := time.LoadLocation(name); err == nil {
+				zones = append(zones, loc)
+			}
+		}
+		if len(zones) > 0 {
+			phoneZones[code] = zones
+		}
+	}
+}
+
+// internationalDigits strips formatting from number and returns its digits
+// after the international prefix.
+func internationalDigits(number string) (string, bool) {
+	s := strings.TrimSpace(number)
+	switch {
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	case strings.HasPrefix(s, "00"):
+		s = s[2:]
+	default:
+		return "", false
+	}
+
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", false
+		}
+	}
+	return b.String(), b.Len() > 0
+}
@@ -0,0 +1,40 @@
+package sendwindow
+
+import "testing"
+
+func TestZonesForPhone(t *testing.T) {
+	tests := []struct {
+		number string
+		want   []string
+	}{
+		{"+14155550100", countryZones["1"]},
+		{"+1 (415) 555-0100", countryZones["1"]},
+		{"001 415 555 0100", countryZones["1"]},
+		{"+7 495 123-45-67", countryZones["7"]},
+		{"+49 151 12345678", []string{"Europe/Berlin"}},
+		{"+44 20 7946 0958", []string{"Europe/London"}},
+		{"+353 1 234 5678", []string{"Europe/Dublin"}},
+		{"+61.2.9876.5432", countryZones["61"]},
+		{" +81 3-1234-5678 ", []string{"Asia/Tokyo"}},
+		{"14155550100", nil},
+		{"0151 12345678", nil},
+		{"+999 1234", nil},
+		{"+", nil},
+		{"+49 151 CALL NOW", nil},
+		{"user@example.com", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			zones := ZonesForPhone(tt.number)
+			if len(zones) != len(tt.want) {
+				t.Fatalf("got %v, want %v", zones, tt.want)
+			}
+			for i, loc := range zones {
+				if loc.String() != tt.want[i] {
+					t.Errorf("zone %d: got %s, want %s", i, loc, tt.want[i])
+				}
+			}
+		})
+	}
+}
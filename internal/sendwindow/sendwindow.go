@@ -0,0 +1,166 @@
+// Package sendwindow decides when a recipient may be contacted, given a daily
+// window of local time such as 09:00–20:00 and the recipient's timezone.
+package sendwindow
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrInvalidWindow is returned when a window's times or timezone are invalid.
+var ErrInvalidWindow = errors.New("invalid send window")
+
+// clockLayout is the format of Window.Start and Window.End.
+const clockLayout = "15:04"
+
+// Window is a daily range of local time during which recipients may be
+// contacted. Start is inclusive and End exclusive; a window whose End is
+// before its Start spans midnight. When Timezone is empty, the recipient's
+// timezone is inferred from their phone number, falling back to UTC.
+type Window struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// IsZero reports whether w is the empty window, which clears a setting.
+func (w Window) IsZero() bool {
+	return w == Window{}
+}
+
+// Validate checks that Start and End are distinct HH:MM times and that
+// Timezone, if set, is a known IANA name.
+func (w Window) Validate() error {
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return fmt.Errorf("%w: start: %v", ErrInvalidWindow, err)
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return fmt.Errorf("%w: end: %v", ErrInvalidWindow, err)
+	}
+	if start == end {
+		return fmt.Errorf("%w: start and end must differ", ErrInvalidWindow)
+	}
+	if w.Timezone != "" {
+		if _, err := time.LoadLocation(w.Timezone); err != nil || w.Timezone == "Local" {
+			return fmt.Errorf("%w: unknown timezone %q", ErrInvalidWindow, w.Timezone)
+		}
+	}
+	return nil
+}
+
+// Next returns the earliest instant at or after now at which recipient may
+// be contacted; that is now itself when the window is open. phone reports
+// whether recipient is a phone number whose country code can be used to
+// infer its timezone. A recipient in a country spanning several timezones is
+// contacted only when the window is open in all of them, or in the
+// country's main timezone when the window never is.
+func (w Window) Next(recipient string, phone bool, now time.Time) (time.Time, error) {
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: start: %v", ErrInvalidWindow, err)
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: end: %v", ErrInvalidWindow, err)
+	}
+
+	zones, err := w.zones(recipient, phone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if t, ok := nextOpen(start, end, zones, now); ok {
+		return t, nil
+	}
+	t, _ := nextOpen(start, end, zones[:1], now)
+	return t, nil
+}
+
+// Value implements driver.Valuer, storing the window as JSON.
+func (w Window) Value() (driver.Value, error) {
+	return json.Marshal(w)
+}
+
+// Scan implements sql.Scanner for windows stored as JSON.
+func (w *Window) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, w)
+	case string:
+		return json.Unmarshal([]byte(v), w)
+	default:
+		return fmt.Errorf("sendwindow: cannot scan %T", src)
+	}
+}
+
+// zones returns the timezones recipient may be in, most likely first.
+func (w Window) zones(recipient string, phone bool) ([]*time.Location, error) {
+	if w.Timezone != "" {
+		loc, err := time.LoadLocation(w.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidWindow, w.Timezone)
+		}
+		return []*time.Location{loc}, nil
+	}
+	if phone {
+		if zones := ZonesForPhone(recipient); len(zones) > 0 {
+			return zones, nil
+		}
+	}
+	return []*time.Location{time.UTC}, nil
+}
+
+// nextOpen returns the earliest instant at or after now at which the window
+// [start, end) is open in every zone. Such an instant is either now or the
+// moment the window opens in one of the zones, so only those are tried.
+func nextOpen(start, end int, zones []*time.Location, now time.Time) (time.Time, bool) {
+	candidates := []time.Time{now}
+	for _, loc := range zones {
+		local := now.In(loc)
+		for day := -1; day <= 2; day++ {
+			d := local.AddDate(0, 0, day)
+			opens := time.Date(d.Year(), d.Month(), d.Day(), start/60, start%60, 0, 0, loc)
+			if !opens.Before(now) {
+				candidates = append(candidates, opens)
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+
+	for _, t := range candidates {
+		if openInAll(start, end, zones, t) {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func openInAll(start, end int, zones []*time.Location, t time.Time) bool {
+	for _, loc := range zones {
+		local := t.In(loc)
+		m := local.Hour()*60 + local.Minute()
+		open := m >= start && m < end
+		if end < start {
+			open = m >= start || m < end
+		}
+		if !open {
+			return false
+		}
+	}
+	return true
+}
+
+// parseClock returns the minutes after midnight of an HH:MM time.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse(clockLayout, s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not an HH:MM time", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
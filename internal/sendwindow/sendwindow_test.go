@@ -0,0 +1,218 @@
+package sendwindow
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func utc(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestWindowNext(t *testing.T) {
+	daytime := Window{Start: "09:00", End: "20:00"}
+	overnight := Window{Start: "21:00", End: "08:00", Timezone: "Europe/Berlin"}
+
+	tests := []struct {
+		name      string
+		window    Window
+		recipient string
+		phone     bool
+		now       time.Time
+		want      time.Time
+	}{
+		{
+			name:      "open",
+			window:    daytime,
+			recipient: "user@example.com",
+			now:       utc("2025-01-15T10:00:00Z"),
+			want:      utc("2025-01-15T10:00:00Z"),
+		},
+		{
+			name:      "before opening",
+			window:    daytime,
+			recipient: "user@example.com",
+			now:       utc("2025-01-15T08:00:00Z"),
+			want:      utc("2025-01-15T09:00:00Z"),
+		},
+		{
+			name:      "end is exclusive",
+			window:    daytime,
+			recipient: "user@example.com",
+			now:       utc("2025-01-15T20:00:00Z"),
+			want:      utc("2025-01-16T09:00:00Z"),
+		},
+		{
+			name:   "spans midnight, open in the evening",
+			window: overnight,
+			now:    utc("2025-01-15T21:30:00Z"), // 22:30 CET
+			want:   utc("2025-01-15T21:30:00Z"),
+		},
+		{
+			name:   "spans midnight, open after midnight",
+			window: overnight,
+			now:    utc("2025-01-15T23:30:00Z"), // 00:30 CET
+			want:   utc("2025-01-15T23:30:00Z"),
+		},
+		{
+			name:   "spans midnight, closed at its end",
+			window: overnight,
+			now:    utc("2025-01-15T07:00:00Z"), // 08:00 CET
+			want:   utc("2025-01-15T20:00:00Z"),
+		},
+		{
+			name:   "spans midnight, closed during the day",
+			window: overnight,
+			now:    utc("2025-01-15T12:00:00Z"),
+			want:   utc("2025-01-15T20:00:00Z"),
+		},
+		{
+			name:      "timezone from the country code",
+			window:    daytime,
+			recipient: "+49 151 12345678",
+			phone:     true,
+			now:       utc("2025-01-15T07:30:00Z"), // 08:30 CET
+			want:      utc("2025-01-15T08:00:00Z"),
+		},
+		{
+			name:      "00 international prefix",
+			window:    daytime,
+			recipient: "0049 151 12345678",
+			phone:     true,
+			now:       utc("2025-01-15T07:30:00Z"),
+			want:      utc("2025-01-15T08:00:00Z"),
+		},
+		{
+			name:      "explicit timezone wins over the country code",
+			window:    Window{Start: "09:00", End: "20:00", Timezone: "Asia/Tokyo"},
+			recipient: "+49 151 12345678",
+			phone:     true,
+			now:       utc("2025-01-15T07:30:00Z"), // 16:30 JST
+			want:      utc("2025-01-15T07:30:00Z"),
+		},
+		{
+			name:      "number without + is UTC",
+			window:    daytime,
+			recipient: "4915112345678",
+			phone:     true,
+			now:       utc("2025-01-15T08:30:00Z"),
+			want:      utc("2025-01-15T09:00:00Z"),
+		},
+		{
+			name:      "unknown country code is UTC",
+			window:    daytime,
+			recipient: "+999 1234 5678",
+			phone:     true,
+			now:       utc("2025-01-15T08:30:00Z"),
+			want:      utc("2025-01-15T09:00:00Z"),
+		},
+		{
+			name:      "email that looks like a number is UTC",
+			window:    daytime,
+			recipient: "+49@example.com",
+			now:       utc("2025-01-15T08:30:00Z"),
+			want:      utc("2025-01-15T09:00:00Z"),
+		},
+		{
+			// New York and Los Angeles are both between 09:00 and 20:00
+			// from 17:00Z to 01:00Z.
+			name:      "several timezones, waits for the last to open",
+			window:    daytime,
+			recipient: "+1 212 555 0100",
+			phone:     true,
+			now:       utc("2025-01-15T14:00:00Z"),
+			want:      utc("2025-01-15T17:00:00Z"),
+		},
+		{
+			name:      "several timezones, open in all",
+			window:    daytime,
+			recipient: "+1 212 555 0100",
+			phone:     true,
+			now:       utc("2025-01-15T23:00:00Z"),
+			want:      utc("2025-01-15T23:00:00Z"),
+		},
+		{
+			name:      "several timezones, closed once the first closes",
+			window:    daytime,
+			recipient: "+1 212 555 0100",
+			phone:     true,
+			now:       utc("2025-01-16T01:00:00Z"), // 20:00 EST
+			want:      utc("2025-01-16T17:00:00Z"),
+		},
+		{
+			name:      "several timezones never open together, main timezone",
+			window:    Window{Start: "09:00", End: "10:00"},
+			recipient: "+1 212 555 0100",
+			phone:     true,
+			now:       utc("2025-01-15T12:00:00Z"), // 07:00 EST
+			want:      utc("2025-01-15T14:00:00Z"),
+		},
+		{
+			name:      "across spring forward",
+			window:    daytime,
+			recipient: "+49 151 12345678",
+			phone:     true,
+			now:       utc("2025-03-29T19:30:00Z"), // 20:30 CET
+			want:      utc("2025-03-30T07:00:00Z"), // 09:00 CEST
+		},
+		{
+			name:      "across fall back",
+			window:    daytime,
+			recipient: "+49 151 12345678",
+			phone:     true,
+			now:       utc("2025-10-25T19:00:00Z"), // 21:00 CEST
+			want:      utc("2025-10-26T08:00:00Z"), // 09:00 CET
+		},
+		{
+			name:   "spans midnight across spring forward",
+			window: overnight,
+			now:    utc("2025-03-30T06:30:00Z"), // 08:30 CEST
+			want:   utc("2025-03-30T19:00:00Z"), // 21:00 CEST
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.window.Next(tt.recipient, tt.phone, tt.now)
+			if err != nil {
+				t.Fatalf("Next: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Next: got %s, want %s", got.UTC(), tt.want.UTC())
+			}
+		})
+	}
+}
+
+func TestWindowValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  Window
+		wantErr bool
+	}{
+		{name: "daytime", window: Window{Start: "09:00", End: "20:00"}},
+		{name: "spans midnight", window: Window{Start: "21:00", End: "08:00", Timezone: "America/New_York"}},
+		{name: "bad start", window: Window{Start: "9am", End: "20:00"}, wantErr: true},
+		{name: "bad end", window: Window{Start: "09:00", End: "24:00"}, wantErr: true},
+		{name: "empty", window: Window{Start: "09:00", End: "09:00"}, wantErr: true},
+		{name: "unknown timezone", window: Window{Start: "09:00", End: "20:00", Timezone: "Mars/Olympus_Mons"}, wantErr: true},
+		{name: "local timezone", window: Window{Start: "09:00", End: "20:00", Timezone: "Local"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.window.Validate()
+			if tt.wantErr && !errors.Is(err, ErrInvalidWindow) {
+				t.Fatalf("Validate: got error %v, want %v", err, ErrInvalidWindow)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+		})
+	}
+}
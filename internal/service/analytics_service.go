@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"notification-system/internal/model"
+	"notification-system/internal/repository"
+)
+
+const (
+	// rollupMaxSpan bounds how much recipient activity a single rollup
+	// transaction covers, so a backfill proceeds in steps.
+	rollupMaxSpan = 6 * time.Hour
+	// topErrorsLimit is how many error messages analytics reports.
+	topErrorsLimit = 10
+)
+
+// AnalyticsService maintains the hourly delivery rollups and reads
+// delivery analytics from them.
+type AnalyticsService struct {
+	db            *sqlx.DB
+	analyticsRepo repository.AnalyticsRepository
+}
+
+// NewAnalyticsService creates a new AnalyticsService.
+func NewAnalyticsService(db *sqlx.DB, analyticsRepo repository.AnalyticsRepository) *AnalyticsService {
+	return &AnalyticsService{
+		db:            db,
+		analyticsRepo: analyticsRepo,
+	}
+}
+
+// Rollup brings the delivery rollups up to date with recipients updated at
+// or before upTo, and returns the number of hours recomputed. It does
+// nothing if another replica is rolling up.
+//
+// updated_at is set when a recipient is written, not when the write
+// commits, so a recipient can become visible with an updated_at already
+// behind the watermark. Each step therefore also re-reads updates from the
+// overlap before the watermark, so a recipient is counted as long as it
+// becomes visible before the watermark is more than overlap past its
+// updated_at.
+func (s *AnalyticsService) Rollup(ctx context.Context, upTo time.Time, overlap time.Duration) (int, error) {
+	total := 0
+	for ctx.Err() == nil {
+		hours, done, err := s.rollupStep(ctx, upTo, overlap)
+		total += hours
+		if err != nil || done {
+			return total, err
+		}
+	}
+	return total, ctx.Err()
+}
+
+// rollupStep rolls up at most rollupMaxSpan of recipient updates past the
+// watermark, plus the overlap before it, in one transaction. done is true
+// once the watermark has reached upTo.
+func (s *AnalyticsService) rollupStep(ctx context.Context, upTo time.Time, overlap time.Duration) (hours int, done bool, err error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	watermark, err := s.analyticsRepo.LockWatermark(ctx, tx)
+	if errors.Is(err, repository.ErrNotFound) {
+		return 0, true, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to lock rollups: %w", err)
+	}
+
+	if watermark == nil {
+		first, err := s.analyticsRepo.FirstUpdate(ctx, tx)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to find first recipient: %w", err)
+		}
+		if first == nil {
+			return 0, true, nil
+		}
+		from := first.Add(-time.Microsecond)
+		watermark = &from
+	}
+	if !watermark.Before(upTo) {
+		return 0, true, nil
+	}
+
+	to := watermark.Add(rollupMaxSpan)
+	if !to.Before(upTo) {
+		to = upTo
+		done = true
+	}
+
+	after := watermark.Add(-overlap)
+	buckets, err := s.analyticsRepo.ChangedBuckets(ctx, tx, after, to)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to find changed hours: %w", err)
+	}
+	// Deletions before the overlap won't be read again.
+	if err := s.analyticsRepo.PruneDeletions(ctx, tx, after); err != nil {
+		return 0, false, fmt.Errorf("failed to prune deleted hours: %w", err)
+	}
+	if len(buckets) > 0 {
+		if err := s.analyticsRepo.Refresh(ctx, tx, buckets); err != nil {
+			return 0, false, fmt.Errorf("failed to refresh rollups: %w", err)
+		}
+	}
+	if err := s.analyticsRepo.SetWatermark(ctx, tx, to); err != nil {
+		return 0, false, fmt.Errorf("failed to advance watermark: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return len(buckets), done, nil
+}
+
+// Watermark returns the time up to which the rollups are complete, or nil
+// if nothing has been rolled up yet.
+func (s *AnalyticsService) Watermark(ctx context.Context) (*time.Time, error) {
+	return s.analyticsRepo.Watermark(ctx)
+}
+
+// Deliveries returns delivery analytics for f, one bucket per period and
+// platform with any recipients.
+func (s *AnalyticsService) Deliveries(ctx context.Context, f model.DeliveryAnalyticsFilter) (*model.DeliveryAnalyticsResponse, error) {
+	statuses, err := s.analyticsRepo.StatusCounts(ctx, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read status counts: %w", err)
+	}
+	latencies, err := s.analyticsRepo.Latencies(ctx, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read latencies: %w", err)
+	}
+	topErrors, err := s.analyticsRepo.TopErrors(ctx, f, topErrorsLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read top errors: %w", err)
+	}
+	watermark, err := s.analyticsRepo.Watermark(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read watermark: %w", err)
+	}
+
+	type bucketKey struct {
+		start    int64
+		platform string
+	}
+	buckets := []model.DeliveryBucket{}
+	index := make(map[bucketKey]int)
+	bucketFor := func(start time.Time, platform string) *model.DeliveryBucket {
+		key := bucketKey{start.Unix(), platform}
+		i, ok := index[key]
+		if !ok {
+			i = len(buckets)
+			index[key] = i
+			buckets = append(buckets, model.DeliveryBucket{
+				Start:    start.UTC(),
+				Platform: platform,
+				Counts:   make(map[string]int64),
+			})
+		}
+		return &buckets[i]
+	}
+
+	for _, row := range statuses {
+		b := bucketFor(row.Bucket, row.Platform)
+		b.Counts[row.Status.String()] += row.Recipients
+		b.Total += row.Recipients
+	}
+
+	// Latency rows arrive sorted by bucket, platform, stage and slot, so
+	// each histogram is a contiguous run.
+	for i := 0; i < len(latencies); {
+		j := i
+		for j < len(latencies) && latencies[j].Bucket.Equal(latencies[i].Bucket) &&
+			latencies[j].Platform == latencies[i].Platform && latencies[j].Stage == latencies[i].Stage {
+			j++
+		}
+		b := bucketFor(latencies[i].Bucket, latencies[i].Platform)
+		summary := summarizeLatency(latencies[i:j])
+		switch latencies[i].Stage {
+		case model.StageSent:
+			b.TimeToSent = summary
+		case model.StageDelivered:
+			b.TimeToDelivered = summary
+		}
+		i = j
+	}
+
+	if topErrors == nil {
+		topErrors = []model.DeliveryErrorCount{}
+	}
+
+	return &model.DeliveryAnalyticsResponse{
+		Success:     true,
+		Granularity: f.Granularity,
+		From:        f.From,
+		To:          f.To,
+		UpdatedAt:   watermark,
+		Buckets:     buckets,
+		TopErrors:   topErrors,
+	}, nil
+}
+
+// summarizeLatency estimates the p50 and p95 of one histogram, given as its
+// non-empty slots in ascending order.
+func summarizeLatency(slots []model.DeliveryLatencyRow) model.LatencySummary {
+	var summary model.LatencySummary
+	for _, s := range slots {
+		summary.Count += s.Recipients
+	}
+	if summary.Count == 0 {
+		return summary
+	}
+	summary.P50Ms = latencyPercentile(slots, summary.Count, 0.50)
+	summary.P95Ms = latencyPercentile(slots, summary.Count, 0.95)
+	return summary
+}
+
+// latencyPercentile interpolates linearly within the slot holding the p-th
+// percentile. Negative latencies count as 0, and latencies past the last
+// bound as the last bound.
+func latencyPercentile(slots []model.DeliveryLatencyRow, count int64, p float64) *int64 {
+	bounds := model.DeliveryLatencyBounds
+	rank := p * float64(count)
+
+	var seen int64
+	for _, s := range slots {
+		if float64(seen+s.Recipients) < rank {
+			seen += s.Recipients
+			continue
+		}
+
+		var ms float64
+		switch {
+		case s.Slot <= 0:
+			ms = 0
+		case s.Slot >= len(bounds):
+			ms = float64(bounds[len(bounds)-1].Milliseconds())
+		default:
+			lower := float64(bounds[s.Slot-1].Milliseconds())
+			upper := float64(bounds[s.Slot].Milliseconds())
+			ms = lower + (upper-lower)*(rank-float64(seen))/float64(s.Recipients)
+		}
+		v := int64(math.Round(ms))
+		return &v
+	}
+	return nil
+}
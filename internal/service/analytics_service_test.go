@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"notification-system/internal/config"
+	"notification-system/internal/model"
+	"notification-system/internal/repository"
+)
+
+func TestRollupDropsReplacedRecipientsIntegration(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	svc := NewMessageService(db, repository.NewMessageRepository(db), repository.NewRecipientRepository(db), nil, config.SMSConfig{})
+	analytics := NewAnalyticsService(db, repository.NewAnalyticsRepository(db))
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback()
+	scheduledAt := time.Now().Add(time.Hour)
+	msg, err := svc.CreateDueTx(ctx, tx, user.ID, nil, model.CreateMessageRequest{
+		Subject:     "rollup test",
+		Message:     "hello",
+		From:        "test@example.com",
+		To:          []string{"a@example.com", "b@example.com", "c@example.com"},
+		Platform:    string(model.PlatformEmail),
+		ScheduledAt: &scheduledAt,
+	})
+	if err != nil {
+		t.Fatalf("create message: %v", err)
+	}
+	// Move the original recipients to an earlier hour than their
+	// replacements, so only the deletion can mark that hour changed.
+	if _, err := tx.ExecContext(ctx, `UPDATE message_recipients SET created_at = created_at - interval '2 hours' WHERE message_id = $1`, msg.ID); err != nil {
+		t.Fatalf("backdate recipients: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	total := func() int64 {
+		t.Helper()
+		if _, err := analytics.Rollup(ctx, time.Now(), time.Minute); err != nil {
+			t.Fatalf("rollup: %v", err)
+		}
+		resp, err := analytics.Deliveries(ctx, model.DeliveryAnalyticsFilter{
+			UserID:      &user.ID,
+			Granularity: "hour",
+			From:        time.Now().Add(-4 * time.Hour),
+			To:          time.Now().Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("deliveries: %v", err)
+		}
+		var n int64
+		for _, b := range resp.Buckets {
+			n += b.Total
+		}
+		return n
+	}
+
+	if got := total(); got != 3 {
+		t.Fatalf("recipients before edit: got %d, want 3", got)
+	}
+
+	if _, _, err := svc.UpdateScheduled(ctx, user.ID, msg.ID, model.UpdateMessageRequest{To: []string{"d@example.com"}}); err != nil {
+		t.Fatalf("update message: %v", err)
+	}
+
+	if got := total(); got != 1 {
+		t.Errorf("recipients after edit: got %d, want 1", got)
+	}
+}
@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"notification-system/internal/metrics"
+	"notification-system/internal/model"
+	"notification-system/internal/repository"
+	"notification-system/internal/sendwindow"
+	"notification-system/pkg/requestid"
+)
+
+var (
+	// ErrBulkJobNotFound is returned when a bulk job doesn't exist or belongs to another user.
+	ErrBulkJobNotFound = errors.New("bulk job not found")
+	// ErrInvalidRows is returned when an upload has invalid rows and partial
+	// sends weren't allowed, or has no valid rows at all.
+	ErrInvalidRows = errors.New("upload has invalid rows")
+	// ErrTooManyRows is returned when an upload has more than MaxUploadRows rows.
+	ErrTooManyRows = errors.New("upload has too many rows")
+)
+
+const (
+	// MaxUploadRows is the most rows a single upload may have.
+	MaxUploadRows = 100000
+	// maxReportedRowErrors bounds the row errors kept for the response.
+	maxReportedRowErrors = 100
+	// uploadBatchSize is how many items are inserted at a time while an
+	// upload is read.
+	uploadBatchSize = 1000
+)
+
+// UploadRow is one row of an uploaded bulk send: the message it describes,
+// or Err when the row is invalid.
+type UploadRow struct {
+	Line    int
+	Request model.CreateMessageRequest
+	Err     error
+}
+
+// UploadResult is the outcome of SubmitRows. Errors holds the first invalid
+// rows; Rejected counts all of them.
+type UploadResult struct {
+	Job      *model.BulkJob
+	Rejected int
+	Errors   []model.BulkRowError
+}
+
+// BulkService accepts bulk sends as jobs and works through their items in
+// the background.
+type BulkService struct {
+	db         *sqlx.DB
+	jobRepo    repository.BulkJobRepository
+	msgService *MessageService
+}
+
+// NewBulkService creates a new BulkService.
+func NewBulkService(db *sqlx.DB, jobRepo repository.BulkJobRepository, msgService *MessageService) *BulkService {
+	return &BulkService{
+		db:         db,
+		jobRepo:    jobRepo,
+		msgService: msgService,
+	}
+}
+
+// Submit stores a pending job for userID with one item per request. The
+// request ID of ctx is kept with the job and carried by every message it
+// creates.
+func (s *BulkService) Submit(ctx context.Context, userID uuid.UUID, apiKeyID *uuid.UUID, reqs []model.CreateMessageRequest) (*model.BulkJob, error) {
+	now := time.Now()
+
+	job := &model.BulkJob{
+		ID:        uuid.New(),
+		UserID:    userID,
+		APIKeyID:  apiKeyID,
+		Status:    model.BulkJobPending,
+		Total:     len(reqs),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if reqID := requestid.FromContext(ctx); reqID != "" {
+		job.RequestID = &reqID
+	}
+
+	items := make([]model.BulkJobItem, len(reqs))
+	for i, req := range reqs {
+		items[i] = model.BulkJobItem{
+			JobID:   job.ID,
+			Index:   i,
+			Request: model.BulkItemBody(req),
+			Status:  model.BulkItemPending,
+		}
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.jobRepo.Create(ctx, tx, job); err != nil {
+		return nil, fmt.Errorf("failed to create bulk job: %w", err)
+	}
+	if err := s.jobRepo.AddItems(ctx, tx, items); err != nil {
+		return nil, fmt.Errorf("failed to create bulk job items: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return job, nil
+}
+
+// SubmitRows stores a pending job for userID from rows read with next, which
+// returns io.EOF after the last row. Items are written in batches as they
+// are read, so the upload is never held in memory. Rows the message service
+// would reject are counted as invalid too. If any row is invalid and partial
+// is false, or no row is valid, nothing is stored and ErrInvalidRows is
+// returned along with the result describing the invalid rows.
+func (s *BulkService) SubmitRows(ctx context.Context, userID uuid.UUID, apiKeyID *uuid.UUID, next func() (UploadRow, error), partial bool) (*UploadResult, error) {
+	now := time.Now()
+
+	job := &model.BulkJob{
+		ID:        uuid.New(),
+		UserID:    userID,
+		APIKeyID:  apiKeyID,
+		Status:    model.BulkJobPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if reqID := requestid.FromContext(ctx); reqID != "" {
+		job.RequestID = &reqID
+	}
+	result := &UploadResult{Job: job}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.jobRepo.Create(ctx, tx, job); err != nil {
+		return nil, fmt.Errorf("failed to create bulk job: %w", err)
+	}
+
+	batch := make([]model.BulkJobItem, 0, uploadBatchSize)
+	flush := func() error {
+		if err := s.jobRepo.AddItems(ctx, tx, batch); err != nil {
+			return fmt.Errorf("failed to create bulk job items: %w", err)
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for rows := 0; ; rows++ {
+		row, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if rows == MaxUploadRows {
+			return nil, ErrTooManyRows
+		}
+
+		if row.Err == nil {
+			row.Err = s.msgService.ValidateBody(model.Platform(row.Request.Platform), row.Request.Message)
+		}
+		if row.Err != nil {
+			result.Rejected++
+			if len(result.Errors) < maxReportedRowErrors {
+				result.Errors = append(result.Errors, model.BulkRowError{Line: row.Line, Error: row.Err.Error()})
+			}
+			continue
+		}
+
+		batch = append(batch, model.BulkJobItem{
+			JobID:   job.ID,
+			Index:   job.Total,
+			Request: model.BulkItemBody(row.Request),
+			Status:  model.BulkItemPending,
+		})
+		job.Total++
+		if len(batch) == uploadBatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if job.Total == 0 || (result.Rejected > 0 && !partial) {
+		return result, ErrInvalidRows
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	if err := s.jobRepo.SetTotal(ctx, tx, job.ID, job.Total); err != nil {
+		return nil, fmt.Errorf("failed to update bulk job total: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// Get returns a job owned by userID with its progress.
+func (s *BulkService) Get(ctx context.Context, userID, id uuid.UUID) (*model.BulkJob, model.BulkJobProgress, error) {
+	job, err := s.jobRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, model.BulkJobProgress{}, ErrBulkJobNotFound
+		}
+		return nil, model.BulkJobProgress{}, err
+	}
+	if job.UserID != userID {
+		return nil, model.BulkJobProgress{}, ErrBulkJobNotFound
+	}
+
+	progress, err := s.jobRepo.Progress(ctx, id)
+	if err != nil {
+		return nil, model.BulkJobProgress{}, err
+	}
+
+	return job, progress, nil
+}
+
+// Items returns up to limit of a job's items after the given index,
+// optionally only those in status. The job's ownership must already have
+// been checked with Get.
+func (s *BulkService) Items(ctx context.Context, jobID uuid.UUID, status model.BulkItemStatus, after, limit int) ([]model.BulkJobItem, error) {
+	return s.jobRepo.ListItems(ctx, jobID, status, after, limit)
+}
+
+// ProcessPending sends up to limit pending items and returns how many were
+// processed. Each item is claimed with a row lock and handled in its own
+// transaction, which creates the item's message and records the result, so
+// this is safe to run concurrently and on several replicas and an item's
+// message is created exactly once. The message is published only after
+// that transaction commits; if publishing fails, the scheduler publishes it
+// on its next pass. An item the message service rejects is marked failed;
+// any other error leaves it pending for a later attempt and ends the batch
+// early.
+func (s *BulkService) ProcessPending(ctx context.Context, limit int) (int, error) {
+	jobs := make(map[uuid.UUID]*model.BulkJob)
+	processed := 0
+	for processed < limit {
+		found, err := s.processNext(ctx, jobs)
+		if err != nil {
+			return processed, err
+		}
+		if !found {
+			break
+		}
+		processed++
+	}
+	return processed, nil
+}
+
+// processNext claims and processes one pending item, if there is one.
+// jobs caches the jobs already looked up by ID.
+func (s *BulkService) processNext(ctx context.Context, jobs map[uuid.UUID]*model.BulkJob) (bool, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	items, err := s.jobRepo.ClaimPending(ctx, tx, 1)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim bulk item: %w", err)
+	}
+	if len(items) == 0 {
+		return false, nil
+	}
+	item := &items[0]
+
+	job, ok := jobs[item.JobID]
+	if !ok {
+		job, err = s.jobRepo.GetByID(ctx, item.JobID)
+		if err != nil {
+			return false, fmt.Errorf("failed to get bulk job: %w", err)
+		}
+		jobs[item.JobID] = job
+	}
+	if job.RequestID != nil {
+		ctx = requestid.NewContext(ctx, *job.RequestID)
+	}
+
+	if err := s.process(ctx, tx, job, item); err != nil {
+		return false, err
+	}
+
+	if err := s.jobRepo.RefreshStatus(ctx, tx, []uuid.UUID{job.ID}); err != nil {
+		return false, fmt.Errorf("failed to update bulk job status: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	metrics.BulkItemsProcessedTotal.WithLabelValues(string(item.Status)).Inc()
+
+	if item.MessageID != nil {
+		if err := s.msgService.PublishDue(ctx, *item.MessageID); err != nil {
+			log.Warn().Err(err).
+				Str("job_id", item.JobID.String()).
+				Int("index", item.Index).
+				Str("message_id", item.MessageID.String()).
+				Msg("failed to publish bulk item, leaving it to the scheduler")
+		}
+	}
+
+	return true, nil
+}
+
+// process creates a claimed item's message within tx and records its
+// outcome. It returns an error only when the outcome couldn't be decided,
+// such as the database being unavailable.
+func (s *BulkService) process(ctx context.Context, tx *sqlx.Tx, job *model.BulkJob, item *model.BulkJobItem) error {
+	msg, err := s.msgService.CreateDueTx(ctx, tx, job.UserID, job.APIKeyID, model.CreateMessageRequest(item.Request))
+	switch {
+	case err == nil:
+		item.Status = model.BulkItemSucceeded
+		item.MessageID = &msg.ID
+	case errors.Is(err, ErrTooManySegments), errors.Is(err, sendwindow.ErrInvalidWindow):
+		// Both are rejected before anything is written.
+		msg := err.Error()
+		item.Status = model.BulkItemFailed
+		item.Error = &msg
+	default:
+		log.Error().Err(err).
+			Str("job_id", item.JobID.String()).
+			Int("index", item.Index).
+			Msg("failed to create bulk item message, will retry")
+		return err
+	}
+
+	now := time.Now()
+	item.ProcessedAt = &now
+	if err := s.jobRepo.SetItemResult(ctx, tx, item); err != nil {
+		return fmt.Errorf("failed to record bulk item result: %w", err)
+	}
+
+	return nil
+}
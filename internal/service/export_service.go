@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"notification-system/internal/export"
+	"notification-system/internal/metrics"
+	"notification-system/internal/model"
+	"notification-system/internal/repository"
+	"notification-system/internal/storage"
+)
+
+var (
+	// ErrExportNotFound is returned when an export doesn't exist or belongs to another user.
+	ErrExportNotFound = errors.New("export not found")
+	// ErrExportNotReady is returned when downloading an export that hasn't completed.
+	ErrExportNotReady = errors.New("export is not ready")
+	// ErrExportExpired is returned when downloading an export whose file has been deleted.
+	ErrExportExpired = errors.New("export has expired")
+)
+
+const (
+	// exportHeartbeat is how often a running export is touched to show it
+	// is still being built.
+	exportHeartbeat = time.Minute
+	// exportStaleAfter is how long a running export may go untouched before
+	// it is assumed abandoned, for example by a replica that crashed, and
+	// built again.
+	exportStaleAfter = 5 * time.Minute
+	// exportPurgeBatch is how many expired exports are deleted at a time.
+	exportPurgeBatch = 100
+)
+
+// ExportService writes exports of users' messages to a store in the
+// background and serves them until they expire.
+type ExportService struct {
+	exportRepo  repository.ExportRepository
+	messageRepo repository.MessageRepository
+	store       storage.Store
+	ttl         time.Duration
+}
+
+// NewExportService creates a new ExportService. Completed exports can be
+// downloaded for ttl.
+func NewExportService(exportRepo repository.ExportRepository, messageRepo repository.MessageRepository, store storage.Store, ttl time.Duration) *ExportService {
+	return &ExportService{
+		exportRepo:  exportRepo,
+		messageRepo: messageRepo,
+		store:       store,
+		ttl:         ttl,
+	}
+}
+
+// Create stores a pending export of the messages of userID matching req's
+// filters.
+func (s *ExportService) Create(ctx context.Context, userID uuid.UUID, req model.CreateExportRequest) (*model.Export, error) {
+	now := time.Now()
+	exp := &model.Export{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Format:    model.ExportFormat(req.Format),
+		Filters:   model.ExportFilters(req.MessageFilter),
+		Status:    model.ExportPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := s.exportRepo.Create(ctx, exp); err != nil {
+		return nil, fmt.Errorf("failed to create export: %w", err)
+	}
+
+	return exp, nil
+}
+
+// Get returns an export owned by userID.
+func (s *ExportService) Get(ctx context.Context, userID, id uuid.UUID) (*model.Export, error) {
+	exp, err := s.exportRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrExportNotFound
+		}
+		return nil, err
+	}
+	if exp.UserID != userID {
+		return nil, ErrExportNotFound
+	}
+
+	return exp, nil
+}
+
+// Open returns a completed export owned by userID and its file, which the
+// caller must close.
+func (s *ExportService) Open(ctx context.Context, userID, id uuid.UUID) (*model.Export, io.ReadCloser, error) {
+	exp, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch {
+	case exp.Status == model.ExportExpired,
+		exp.Status == model.ExportCompleted && exp.ExpiresAt != nil && exp.ExpiresAt.Before(time.Now()):
+		return nil, nil, ErrExportExpired
+	case exp.Status != model.ExportCompleted:
+		return nil, nil, fmt.Errorf("%w: export is %s", ErrExportNotReady, exp.Status)
+	}
+
+	file, err := s.store.Get(ctx, *exp.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, ErrExportExpired
+		}
+		return nil, nil, fmt.Errorf("failed to open export: %w", err)
+	}
+
+	return exp, file, nil
+}
+
+// ProcessNext builds the oldest pending export and reports whether there
+// was one. An export that can't be built is marked failed. The claim is
+// touched every exportHeartbeat while the export is built, and the build is
+// abandoned if another replica has reclaimed it meanwhile.
+func (s *ExportService) ProcessNext(ctx context.Context) (bool, error) {
+	exp, err := s.exportRepo.ClaimNext(ctx, time.Now().Add(-exportStaleAfter))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to claim export: %w", err)
+	}
+
+	buildCtx, abort := context.WithCancel(ctx)
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		s.heartbeat(buildCtx, exp, abort)
+	}()
+	err = s.build(buildCtx, exp)
+	reclaimed := buildCtx.Err() != nil && ctx.Err() == nil
+	abort()
+	<-heartbeatDone
+
+	if reclaimed {
+		return true, nil
+	}
+	if err != nil {
+		log.Error().Err(err).Str("export_id", exp.ID.String()).Msg("failed to build export")
+		metrics.ExportsTotal.WithLabelValues(string(exp.Format), string(model.ExportFailed)).Inc()
+		if err := s.exportRepo.Fail(ctx, exp, err.Error()); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return true, fmt.Errorf("failed to mark export failed: %w", err)
+		}
+		return true, nil
+	}
+
+	metrics.ExportsTotal.WithLabelValues(string(exp.Format), string(model.ExportCompleted)).Inc()
+	return true, nil
+}
+
+// heartbeat touches exp every exportHeartbeat until ctx is done, and calls
+// abort if exp turns out to have been claimed again.
+func (s *ExportService) heartbeat(ctx context.Context, exp *model.Export, abort context.CancelFunc) {
+	ticker := time.NewTicker(exportHeartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := s.exportRepo.Touch(ctx, exp)
+			switch {
+			case errors.Is(err, repository.ErrNotFound):
+				log.Warn().Str("export_id", exp.ID.String()).Msg("export was reclaimed, abandoning it")
+				abort()
+				return
+			case err != nil && ctx.Err() == nil:
+				log.Warn().Err(err).Str("export_id", exp.ID.String()).Msg("failed to touch running export")
+			}
+		}
+	}
+}
+
+// build writes a claimed export to a temporary file, copies it to the store
+// and records it as completed.
+func (s *ExportService) build(ctx context.Context, exp *model.Export) error {
+	tmp, err := os.CreateTemp("", "export-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	w, err := export.NewWriter(tmp, exp.Format)
+	if err != nil {
+		return err
+	}
+
+	var rows int64
+	err = s.messageRepo.Export(ctx, exp.UserID, model.MessageFilter(exp.Filters), func(row *model.ExportRow) error {
+		rows++
+		return w.Write(row)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read messages: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("exports/%s/%s.%s", exp.UserID, exp.ID, exp.Format)
+	if err := s.store.Put(ctx, key, tmp, size, export.ContentType(exp.Format)); err != nil {
+		return fmt.Errorf("failed to store export: %w", err)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.ttl)
+	exp.StorageKey = &key
+	exp.Rows = rows
+	exp.SizeBytes = size
+	exp.CompletedAt = &now
+	exp.ExpiresAt = &expiresAt
+
+	if err := s.exportRepo.Complete(ctx, exp); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			// Claimed again after missing heartbeats; the new claim writes
+			// the same key and records the outcome.
+			log.Warn().Str("export_id", exp.ID.String()).Msg("export was reclaimed before it completed")
+			return nil
+		}
+		return fmt.Errorf("failed to complete export: %w", err)
+	}
+
+	log.Info().
+		Str("export_id", exp.ID.String()).
+		Int64("rows", rows).
+		Int64("size_bytes", size).
+		Msg("export completed")
+	return nil
+}
+
+// PurgeExpired deletes the files of expired exports and returns how many
+// were deleted.
+func (s *ExportService) PurgeExpired(ctx context.Context) (int, error) {
+	purged := 0
+	for {
+		exports, err := s.exportRepo.ListExpired(ctx, time.Now(), exportPurgeBatch)
+		if err != nil {
+			return purged, fmt.Errorf("failed to list expired exports: %w", err)
+		}
+
+		for _, exp := range exports {
+			if exp.StorageKey != nil {
+				if err := s.store.Delete(ctx, *exp.StorageKey); err != nil {
+					return purged, fmt.Errorf("failed to delete export %s: %w", exp.ID, err)
+				}
+			}
+			if err := s.exportRepo.MarkExpired(ctx, exp.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return purged, fmt.Errorf("failed to mark export %s expired: %w", exp.ID, err)
+			}
+			purged++
+		}
+
+		if len(exports) < exportPurgeBatch {
+			return purged, nil
+		}
+	}
+}
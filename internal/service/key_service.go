@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+
+	"notification-system/internal/auth"
+	"notification-system/internal/cache"
+	"notification-system/internal/model"
+	"notification-system/internal/repository"
+)
+
+// defaultRotationGrace is how long the old key keeps working after a rotation
+// when the caller doesn't specify a grace period.
+const defaultRotationGrace = 24 * time.Hour
+
+var (
+	// ErrKeyNotFound is returned when a key doesn't exist or belongs to another user.
+	ErrKeyNotFound = errors.New("api key not found")
+	// ErrUnknownScope is returned when a requested scope is not recognised.
+	ErrUnknownScope = errors.New("unknown scope")
+	// ErrScopeNotAllowed is returned when a key tries to grant, or manage a
+	// key with, scopes it doesn't hold.
+	ErrScopeNotAllowed = errors.New("cannot grant or manage scopes the current key does not hold")
+	// ErrKeyInactive is returned when rotating a key that is revoked or expired.
+	ErrKeyInactive = errors.New("api key is revoked or expired")
+)
+
+// KeyService handles API key issuance, rotation and revocation.
+type KeyService struct {
+	db        *sqlx.DB
+	keyRepo   repository.APIKeyRepository
+	credCache *cache.CredentialCache
+}
+
+// NewKeyService creates a new KeyService. credCache may be nil.
+func NewKeyService(db *sqlx.DB, keyRepo repository.APIKeyRepository, credCache *cache.CredentialCache) *KeyService {
+	return &KeyService{
+		db:        db,
+		keyRepo:   keyRepo,
+		credCache: credCache,
+	}
+}
+
+// Issue generates a new key for userID and stores its hash within tx.
+// The raw key is returned and must be shown to the caller exactly once.
+func (s *KeyService) Issue(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (string, *model.APIKey, error) {
+	for _, scope := range scopes {
+		if !auth.IsKnownScope(scope) {
+			return "", nil, fmt.Errorf("%w: %s", ErrUnknownScope, scope)
+		}
+	}
+
+	raw, prefix, err := auth.GenerateAPIKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	key := &model.APIKey{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   auth.HashAPIKey(raw),
+		Scopes:    pq.StringArray(scopes),
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.keyRepo.Create(ctx, tx, key); err != nil {
+		return "", nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	return raw, key, nil
+}
+
+// Create issues a new key for userID. grantedScopes are the scopes of the key
+// making the request; the new key cannot exceed them.
+func (s *KeyService) Create(ctx context.Context, userID uuid.UUID, grantedScopes []string, req model.CreateAPIKeyRequest) (string, *model.APIKey, error) {
+	if !auth.HasAllScopes(grantedScopes, req.Scopes) {
+		return "", nil, ErrScopeNotAllowed
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	raw, key, err := s.Issue(ctx, tx, userID, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return raw, key, nil
+}
+
+// Rotate issues a replacement for keyID with the same name and scopes, and
+// schedules the old key to expire after grace. Both keys are valid during
+// the grace period so clients can switch over without downtime.
+// grantedScopes are the scopes of the key making the request; as with
+// Create, it can't rotate a key with scopes it doesn't have.
+func (s *KeyService) Rotate(ctx context.Context, userID, keyID uuid.UUID, grantedScopes []string, grace *time.Duration) (string, *model.APIKey, error) {
+	old, err := s.getOwnedKey(ctx, userID, keyID)
+	if err != nil {
+		return "", nil, err
+	}
+	if !auth.HasAllScopes(grantedScopes, old.Scopes) {
+		return "", nil, ErrScopeNotAllowed
+	}
+
+	now := time.Now()
+	if old.IsRevoked() || old.IsExpired(now) {
+		return "", nil, ErrKeyInactive
+	}
+
+	gracePeriod := defaultRotationGrace
+	if grace != nil {
+		gracePeriod = *grace
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	raw, key, err := s.Issue(ctx, tx, userID, old.Name, old.Scopes, old.ExpiresAt)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := s.keyRepo.SetExpiry(ctx, tx, old.ID, now.Add(gracePeriod)); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return "", nil, ErrKeyInactive
+		}
+		return "", nil, fmt.Errorf("failed to expire old api key: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	s.invalidate(ctx, old.KeyHash)
+
+	return raw, key, nil
+}
+
+// Revoke immediately disables keyID. As with Rotate, the key making the
+// request, holding grantedScopes, can't revoke a key with scopes it doesn't
+// have.
+func (s *KeyService) Revoke(ctx context.Context, userID, keyID uuid.UUID, grantedScopes []string) (*model.APIKey, error) {
+	key, err := s.getOwnedKey(ctx, userID, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if !auth.HasAllScopes(grantedScopes, key.Scopes) {
+		return nil, ErrScopeNotAllowed
+	}
+
+	if err := s.keyRepo.Revoke(ctx, key.ID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrKeyInactive
+		}
+		return nil, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	s.invalidate(ctx, key.KeyHash)
+
+	return key, nil
+}
+
+// List returns all keys belonging to userID, including revoked and expired ones.
+func (s *KeyService) List(ctx context.Context, userID uuid.UUID) ([]model.APIKey, error) {
+	keys, err := s.keyRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// InvalidateUser drops every cached credential of userID, so changes to the
+// user take effect on the next request.
+func (s *KeyService) InvalidateUser(ctx context.Context, userID uuid.UUID) error {
+	if s.credCache == nil {
+		return nil
+	}
+
+	keys, err := s.keyRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	hashes := make([]string, len(keys))
+	for i, k := range keys {
+		hashes[i] = k.KeyHash
+	}
+	s.invalidate(ctx, hashes...)
+	return nil
+}
+
+// invalidate removes hashes from the credential cache. A failure only delays
+// the change until the entry's TTL runs out, so it is logged, not returned.
+func (s *KeyService) invalidate(ctx context.Context, hashes ...string) {
+	if err := s.credCache.Invalidate(ctx, hashes...); err != nil {
+		log.Error().Err(err).Msg("failed to invalidate cached api keys")
+	}
+}
+
+func (s *KeyService) getOwnedKey(ctx context.Context, userID, keyID uuid.UUID) (*model.APIKey, error) {
+	key, err := s.keyRepo.GetByID(ctx, keyID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	if key.UserID != userID {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
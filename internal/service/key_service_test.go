@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"notification-system/internal/auth"
+	"notification-system/internal/model"
+	"notification-system/internal/repository"
+)
+
+// fakeKeyRepo serves API keys from memory.
+type fakeKeyRepo struct {
+	repository.APIKeyRepository
+	keys map[uuid.UUID]*model.APIKey
+}
+
+func (r *fakeKeyRepo) GetByID(_ context.Context, id uuid.UUID) (*model.APIKey, error) {
+	key, ok := r.keys[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	k := *key
+	return &k, nil
+}
+
+func (r *fakeKeyRepo) Revoke(_ context.Context, id uuid.UUID) error {
+	key, ok := r.keys[id]
+	if !ok || key.IsRevoked() {
+		return repository.ErrNotFound
+	}
+	now := time.Now()
+	key.RevokedAt = &now
+	return nil
+}
+
+func TestRevokeRequiresTargetScopes(t *testing.T) {
+	userID := uuid.New()
+	admin := &model.APIKey{ID: uuid.New(), UserID: userID, Name: "admin", Scopes: []string{auth.ScopeAll}}
+	sender := &model.APIKey{ID: uuid.New(), UserID: userID, Name: "sender", Scopes: []string{auth.ScopeMessagesSend}}
+
+	tests := []struct {
+		name    string
+		granted []string
+		key     *model.APIKey
+		wantErr error
+	}{
+		{
+			name:    "narrow key can't revoke a full-scope key",
+			granted: []string{auth.ScopeKeysWrite, auth.ScopeMessagesRead},
+			key:     admin,
+			wantErr: ErrScopeNotAllowed,
+		},
+		{
+			name:    "narrow key can't revoke a key with another scope",
+			granted: []string{auth.ScopeKeysWrite, auth.ScopeMessagesRead},
+			key:     sender,
+			wantErr: ErrScopeNotAllowed,
+		},
+		{
+			name:    "key holding the target's scopes can revoke it",
+			granted: []string{auth.ScopeKeysWrite, auth.ScopeMessagesSend},
+			key:     sender,
+		},
+		{
+			name:    "full-scope key can revoke any key",
+			granted: []string{auth.ScopeAll},
+			key:     admin,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := *tt.key
+			repo := &fakeKeyRepo{keys: map[uuid.UUID]*model.APIKey{target.ID: &target}}
+			svc := NewKeyService(nil, repo, nil)
+
+			_, err := svc.Revoke(context.Background(), userID, target.ID, tt.granted)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Revoke: got error %v, want %v", err, tt.wantErr)
+			}
+			if revoked := target.IsRevoked(); revoked != (tt.wantErr == nil) {
+				t.Errorf("key revoked = %t, want %t", revoked, tt.wantErr == nil)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,17 +10,49 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/rs/zerolog/log"
 
+	"notification-system/internal/config"
+	"notification-system/internal/metrics"
 	"notification-system/internal/model"
 	"notification-system/internal/queue"
 	"notification-system/internal/repository"
+	"notification-system/internal/sendwindow"
+	"notification-system/pkg/requestid"
+	"notification-system/pkg/sms"
 )
 
+var (
+	// ErrTooManySegments is returned when an SMS body exceeds the configured segment limit.
+	ErrTooManySegments = errors.New("sms body exceeds segment limit")
+	// ErrMessageNotFound is returned when a message doesn't exist or belongs to another user.
+	ErrMessageNotFound = errors.New("message not found")
+	// ErrMessageState is returned when a message isn't in a state that allows the operation.
+	ErrMessageState = errors.New("message is not in a state that allows this operation")
+	// ErrInvalidMessage is returned when an edit leaves a message invalid.
+	ErrInvalidMessage = errors.New("invalid message")
+)
+
+const (
+	// dispatchRetryDelay is how long a scheduled message or deferred
+	// recipient that failed to dispatch waits before it is tried again.
+	dispatchRetryDelay = time.Minute
+	// maxDispatchAttempts is how many times a scheduled message or deferred
+	// recipient is tried before it is failed.
+	maxDispatchAttempts = 5
+)
+
+// EventPublisher publishes events to the message queue. *queue.Publisher
+// implements it.
+type EventPublisher interface {
+	Publish(ctx context.Context, exchange, routingKey string, body interface{}) error
+}
+
 // MessageService handles message processing logic.
 type MessageService struct {
 	db            *sqlx.DB
 	messageRepo   repository.MessageRepository
 	recipientRepo repository.RecipientRepository
-	publisher     *queue.Publisher
+	publisher     EventPublisher
+	smsConfig     config.SMSConfig
 }
 
 // NewMessageService creates a new MessageService.
@@ -27,19 +60,321 @@ func NewMessageService(
 	db *sqlx.DB,
 	messageRepo repository.MessageRepository,
 	recipientRepo repository.RecipientRepository,
-	publisher *queue.Publisher,
+	publisher EventPublisher,
+	smsConfig config.SMSConfig,
 ) *MessageService {
 	return &MessageService{
 		db:            db,
 		messageRepo:   messageRepo,
 		recipientRepo: recipientRepo,
 		publisher:     publisher,
+		smsConfig:     smsConfig,
 	}
 }
 
 // SendMessage handles the creation and queuing of a message.
-func (s *MessageService) SendMessage(ctx context.Context, userID uuid.UUID, req model.CreateMessageRequest) (*model.SendMessageResponse, error) {
+// apiKeyID identifies the key the request was made with, for usage attribution.
+func (s *MessageService) SendMessage(ctx context.Context, userID uuid.UUID, apiKeyID *uuid.UUID, req model.CreateMessageRequest) (*model.SendMessageResponse, error) {
+	now := time.Now()
+
+	msg, recipients, analysis, err := s.newMessage(ctx, userID, apiKeyID, req, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Scheduled messages are saved but not published until the scheduler picks them up
+	isScheduled := req.ScheduledAt != nil && req.ScheduledAt.After(now)
+	due := recipients
+	if isScheduled {
+		msg.Status = model.StatusScheduled
+	} else {
+		due = s.applySendWindow(msg, recipients, now, "api")
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.persist(ctx, tx, msg, recipients); err != nil {
+		return nil, err
+	}
+
+	// Only publish immediately if not scheduled
+	if !isScheduled {
+		if err := s.publishRecipients(ctx, msg, due); err != nil {
+			return nil, err
+		}
+
+		_, err := tx.ExecContext(ctx,
+			"UPDATE messages SET status = $1, updated_at = $2 WHERE id = $3",
+			model.StatusQueued, time.Now(), msg.ID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update message status: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	resp := &model.SendMessageResponse{
+		Success:            true,
+		MessageID:          msg.ID.String(),
+		RecipientsCount:    len(recipients),
+		EstimatedDelivery:  now.Add(30 * time.Second),
+		RequestID:          *msg.RequestID,
+		DeferredRecipients: len(recipients) - len(due),
+	}
+	if analysis != nil {
+		resp.Encoding = string(analysis.Encoding)
+		resp.Segments = analysis.Segments
+		resp.TotalSegments = analysis.Segments * len(recipients)
+	}
+
+	return resp, nil
+}
+
+// UpdateScheduled applies req to a message of userID that is still
+// scheduled and returns the message before and after the change. The message
+// is locked for the whole edit, so it can't be published by the scheduler
+// halfway through; if the scheduler got there first, ErrMessageState is
+// returned.
+func (s *MessageService) UpdateScheduled(ctx context.Context, userID, id uuid.UUID, req model.UpdateMessageRequest) (before, after *model.MessageWithRecipients, err error) {
+	if req.Subject == nil && req.Message == nil && req.To == nil && req.ScheduledAt == nil {
+		return nil, nil, fmt.Errorf("%w: no fields to update", ErrInvalidMessage)
+	}
+
+	// The binding's omitempty skips min=1 for an empty list, which would
+	// otherwise delete every recipient.
+	if req.To != nil && len(req.To) == 0 {
+		return nil, nil, fmt.Errorf("%w: to must list at least one recipient", ErrInvalidMessage)
+	}
+
+	now := time.Now()
+	if req.ScheduledAt != nil && !req.ScheduledAt.After(now) {
+		return nil, nil, fmt.Errorf("%w: scheduled_at must be in the future", ErrInvalidMessage)
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	msg, err := s.messageRepo.GetForUpdate(ctx, tx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, ErrMessageNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	if msg.UserID != userID {
+		return nil, nil, ErrMessageNotFound
+	}
+	if msg.Status != model.StatusScheduled {
+		return nil, nil, fmt.Errorf("%w: message is %s", ErrMessageState, msg.Status)
+	}
+
+	recipients, err := s.recipientRepo.GetByMessageID(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get recipients: %w", err)
+	}
+	before = withRecipients(msg, recipients)
+
+	updated := *msg
+	if req.Subject != nil {
+		updated.Subject = *req.Subject
+	}
+	if req.Message != nil {
+		updated.Body = *req.Message
+		if updated.Platform == model.PlatformSMS {
+			if updated.Body, _, err = s.prepareSMSBody(updated.Body); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if req.ScheduledAt != nil {
+		updated.ScheduledAt = req.ScheduledAt
+	}
+
+	if err := s.messageRepo.UpdateScheduled(ctx, tx, &updated); err != nil {
+		return nil, nil, fmt.Errorf("failed to update message: %w", err)
+	}
+
+	if req.To != nil {
+		if err := s.recipientRepo.DeleteByMessageID(ctx, tx, id); err != nil {
+			return nil, nil, fmt.Errorf("failed to delete recipients: %w", err)
+		}
+		recipients = newRecipients(id, req.To, now)
+		if err := s.recipientRepo.BatchCreate(ctx, tx, recipients); err != nil {
+			return nil, nil, fmt.Errorf("failed to create recipients: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return before, withRecipients(&updated, recipients), nil
+}
+
+// Cancel cancels a scheduled or queued message of userID. Recipients that
+// haven't been handed to a provider yet are cancelled and skipped by the
+// worker; those already in flight or processed can't be stopped. It returns
+// the message before cancellation and the cancellation counts.
+func (s *MessageService) Cancel(ctx context.Context, userID, id uuid.UUID) (*model.Message, *model.CancelMessageResponse, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	msg, err := s.messageRepo.GetForUpdate(ctx, tx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, ErrMessageNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	if msg.UserID != userID {
+		return nil, nil, ErrMessageNotFound
+	}
+	if msg.Status != model.StatusScheduled && msg.Status != model.StatusQueued {
+		return nil, nil, fmt.Errorf("%w: message is %s", ErrMessageState, msg.Status)
+	}
+
+	cancelled, total, err := s.recipientRepo.CancelPending(ctx, tx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to cancel recipients: %w", err)
+	}
+	if cancelled == 0 && total > 0 {
+		return nil, nil, fmt.Errorf("%w: all %d recipients have already been sent", ErrMessageState, total)
+	}
+
+	if err := s.messageRepo.TransitionStatus(ctx, tx, id, msg.Status, model.StatusCancelled); err != nil {
+		return nil, nil, fmt.Errorf("failed to update message status: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return msg, &model.CancelMessageResponse{
+		Success:               true,
+		MessageID:             id.String(),
+		Status:                model.StatusCancelled.String(),
+		RecipientsCancelled:   cancelled,
+		RecipientsAlreadySent: total - cancelled,
+	}, nil
+}
+
+// withRecipients pairs msg with the addresses of its recipients.
+func withRecipients(msg *model.Message, recipients []model.Recipient) *model.MessageWithRecipients {
+	addresses := make([]string, len(recipients))
+	for i, r := range recipients {
+		addresses[i] = r.Recipient
+	}
+	return &model.MessageWithRecipients{Message: *msg, Recipients: addresses}
+}
+
+// CreateFromSchedule persists one occurrence of sched within tx as a
+// scheduled message due at runAt. DispatchScheduled publishes it once due.
+func (s *MessageService) CreateFromSchedule(ctx context.Context, tx *sqlx.Tx, sched *model.Schedule, runAt time.Time) (*model.Message, error) {
+	priority := int(sched.Priority)
+	req := model.CreateMessageRequest{
+		Subject:     sched.Subject,
+		Message:     sched.Body,
+		From:        sched.Sender,
+		To:          sched.Recipients,
+		Platform:    string(sched.Platform),
+		Priority:    &priority,
+		ScheduledAt: &runAt,
+		SendWindow:  sched.SendWindow,
+	}
+
+	msg, recipients, _, err := s.newMessage(ctx, sched.UserID, sched.APIKeyID, req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	msg.Status = model.StatusScheduled
+	msg.ScheduleID = &sched.ID
+
+	if err := s.persist(ctx, tx, msg, recipients); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// CreateDueTx persists a message within tx without publishing anything.
+// Unless req schedules it for later, it is scheduled for now, so once tx
+// has committed PublishDue can publish it, and the scheduler will if that
+// fails.
+func (s *MessageService) CreateDueTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, apiKeyID *uuid.UUID, req model.CreateMessageRequest) (*model.Message, error) {
 	now := time.Now()
+
+	msg, recipients, _, err := s.newMessage(ctx, userID, apiKeyID, req, now)
+	if err != nil {
+		return nil, err
+	}
+	msg.Status = model.StatusScheduled
+	if msg.ScheduledAt == nil {
+		msg.ScheduledAt = &now
+	}
+
+	if err := s.persist(ctx, tx, msg, recipients); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// PublishDue publishes a committed scheduled message that is due, as
+// DispatchScheduled would. It does nothing if the message has already been
+// published, cancelled or isn't due yet.
+func (s *MessageService) PublishDue(ctx context.Context, id uuid.UUID) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	msg, err := s.messageRepo.GetForUpdate(ctx, tx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get message: %w", err)
+	}
+	if msg.Status != model.StatusScheduled || msg.ScheduledAt == nil || msg.ScheduledAt.After(time.Now()) {
+		return nil
+	}
+
+	if err := s.dispatchScheduled(ctx, tx, msg); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.publishDispatched(ctx, msg)
+	return nil
+}
+
+// ValidateBody reports whether body can be sent on platform, applying the
+// same SMS segment limit as SendMessage.
+func (s *MessageService) ValidateBody(platform model.Platform, body string) error {
+	if platform != model.PlatformSMS {
+		return nil
+	}
+	_, _, err := s.prepareSMSBody(body)
+	return err
+}
+
+// newMessage builds a pending message and its recipients from req. The
+// request ID is taken from ctx, or generated when there is none.
+func (s *MessageService) newMessage(ctx context.Context, userID uuid.UUID, apiKeyID *uuid.UUID, req model.CreateMessageRequest, now time.Time) (*model.Message, []model.Recipient, *sms.Analysis, error) {
 	msgID := uuid.New()
 
 	priority := model.PriorityNormal
@@ -47,32 +382,56 @@ func (s *MessageService) SendMessage(ctx context.Context, userID uuid.UUID, req
 		priority = model.Priority(*req.Priority)
 	}
 
-	// Scheduled messages are saved but not published until the scheduler picks them up
-	isScheduled := req.ScheduledAt != nil && req.ScheduledAt.After(now)
-	status := model.StatusPending
-	if isScheduled {
-		status = model.StatusScheduled
+	body := req.Message
+	var analysis *sms.Analysis
+	if model.Platform(req.Platform) == model.PlatformSMS {
+		var err error
+		body, analysis, err = s.prepareSMSBody(body)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	var window *sendwindow.Window
+	if req.SendWindow != nil && !req.SendWindow.IsZero() {
+		if err := req.SendWindow.Validate(); err != nil {
+			return nil, nil, nil, err
+		}
+		window = req.SendWindow
+	}
+
+	reqID := requestid.FromContext(ctx)
+	if reqID == "" {
+		reqID = requestid.New()
 	}
 
 	msg := &model.Message{
 		ID:          msgID,
 		UserID:      userID,
+		APIKeyID:    apiKeyID,
+		RequestID:   &reqID,
 		Subject:     req.Subject,
-		Body:        req.Message,
+		Body:        body,
 		Sender:      req.From,
 		Platform:    model.Platform(req.Platform),
 		Priority:    priority,
-		Status:      status,
+		Status:      model.StatusPending,
 		ScheduledAt: req.ScheduledAt,
+		SendWindow:  window,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
 
-	recipients := make([]model.Recipient, len(req.To))
-	for i, to := range req.To {
+	return msg, newRecipients(msgID, req.To, now), analysis, nil
+}
+
+// newRecipients builds pending recipients of messageID for each address.
+func newRecipients(messageID uuid.UUID, addresses []string, now time.Time) []model.Recipient {
+	recipients := make([]model.Recipient, len(addresses))
+	for i, to := range addresses {
 		recipients[i] = model.Recipient{
 			ID:         uuid.New(),
-			MessageID:  msgID,
+			MessageID:  messageID,
 			Recipient:  to,
 			Status:     model.StatusPending,
 			RetryCount: 0,
@@ -80,61 +439,113 @@ func (s *MessageService) SendMessage(ctx context.Context, userID uuid.UUID, req
 			UpdatedAt:  now,
 		}
 	}
+	return recipients
+}
 
-	tx, err := s.db.Beginx()
-	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback()
-
+// persist inserts msg and its recipients within tx.
+func (s *MessageService) persist(ctx context.Context, tx *sqlx.Tx, msg *model.Message, recipients []model.Recipient) error {
 	if err := s.messageRepo.Create(ctx, tx, msg); err != nil {
-		return nil, fmt.Errorf("failed to create message: %w", err)
+		return fmt.Errorf("failed to create message: %w", err)
 	}
 
 	if err := s.recipientRepo.BatchCreate(ctx, tx, recipients); err != nil {
-		return nil, fmt.Errorf("failed to create recipients: %w", err)
+		return fmt.Errorf("failed to create recipients: %w", err)
 	}
 
-	// Only publish immediately if not scheduled
-	if !isScheduled {
-		if err := s.publishRecipients(ctx, msg, recipients); err != nil {
-			return nil, err
-		}
+	return nil
+}
 
-		_, err := tx.ExecContext(ctx,
-			"UPDATE messages SET status = $1, updated_at = $2 WHERE id = $3",
-			model.StatusQueued, time.Now(), msgID,
-		)
+// prepareSMSBody optionally transliterates an SMS body to GSM-7 and enforces
+// the configured segment limit.
+func (s *MessageService) prepareSMSBody(body string) (string, *sms.Analysis, error) {
+	if s.smsConfig.Transliterate {
+		body = sms.Transliterate(body)
+	}
+
+	analysis := sms.Analyze(body)
+	if s.smsConfig.MaxSegments > 0 && analysis.Segments > s.smsConfig.MaxSegments {
+		return "", nil, fmt.Errorf("%w: %d %s segments, limit is %d",
+			ErrTooManySegments, analysis.Segments, analysis.Encoding, s.smsConfig.MaxSegments)
+	}
+
+	return body, &analysis, nil
+}
+
+// sendWindow returns the window msg's recipients must be contacted in, or
+// nil if there is none. High-priority messages bypass the window.
+func sendWindow(msg *model.Message) *sendwindow.Window {
+	if msg.Priority == model.PriorityHigh {
+		return nil
+	}
+	return msg.SendWindow
+}
+
+// applySendWindow marks the recipients that are outside msg's send window at
+// now as scheduled until it next opens, and returns the rest, which are due
+// now. stage labels the deferral metric.
+func (s *MessageService) applySendWindow(msg *model.Message, recipients []model.Recipient, now time.Time, stage string) []model.Recipient {
+	window := sendWindow(msg)
+	if window == nil {
+		return recipients
+	}
+
+	due := make([]model.Recipient, 0, len(recipients))
+	for i := range recipients {
+		r := &recipients[i]
+		next, err := window.Next(r.Recipient, msg.Platform.UsesPhoneNumbers(), now)
 		if err != nil {
-			return nil, fmt.Errorf("failed to update message status: %w", err)
+			// The window was validated when it was set; don't hold the
+			// recipient back indefinitely if it can no longer be evaluated.
+			log.Warn().Err(err).Str("message_id", msg.ID.String()).Msg("failed to evaluate send window")
+			due = append(due, *r)
+			continue
+		}
+		if !next.After(now) {
+			due = append(due, *r)
+			continue
 		}
-	}
 
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		r.Status = model.StatusScheduled
+		r.DeferredUntil = &next
+		metrics.RecipientsDeferredTotal.WithLabelValues(string(msg.Platform), stage).Inc()
 	}
 
-	return &model.SendMessageResponse{
-		Success:           true,
-		MessageID:         msgID.String(),
-		RecipientsCount:   len(recipients),
-		EstimatedDelivery: now.Add(30 * time.Second),
-		RequestID:         uuid.New().String(),
-	}, nil
+	return due
 }
 
 // publishRecipients fans out events to RabbitMQ for each recipient.
 func (s *MessageService) publishRecipients(ctx context.Context, msg *model.Message, recipients []model.Recipient) error {
 	routingKey := platformToRoutingKey(msg.Platform)
 
+	var apiKeyID string
+	if msg.APIKeyID != nil {
+		apiKeyID = msg.APIKeyID.String()
+	}
+
+	// Scheduled messages are published long after the originating request, so
+	// the ID stored with the message takes precedence over the current context.
+	reqID := requestid.FromContext(ctx)
+	if msg.RequestID != nil {
+		reqID = *msg.RequestID
+	}
+	var metadata map[string]string
+	if reqID != "" {
+		metadata = map[string]string{requestid.MetadataKey: reqID}
+		ctx = requestid.NewContext(ctx, reqID)
+	}
+
 	for _, r := range recipients {
 		event := queue.MessageQueuedEvent{
 			MessageID:   msg.ID.String(),
 			RecipientID: r.ID.String(),
+			UserID:      msg.UserID.String(),
+			APIKeyID:    apiKeyID,
 			To:          r.Recipient,
 			Body:        msg.Body,
 			Subject:     msg.Subject,
 			Platform:    string(msg.Platform),
+			Metadata:    metadata,
+			SendWindow:  sendWindow(msg),
 			Timestamp:   time.Now(),
 		}
 
@@ -146,30 +557,246 @@ func (s *MessageService) publishRecipients(ctx context.Context, msg *model.Messa
 	return nil
 }
 
-// PublishMessage publishes an already-persisted message's recipients to RabbitMQ.
-// Used by the scheduler to dispatch scheduled messages.
-func (s *MessageService) PublishMessage(ctx context.Context, msg *model.Message) error {
+// DispatchScheduled dispatches up to limit scheduled messages that are due
+// before the given time and returns how many were dispatched. Messages are
+// claimed with row locks, so any number of replicas can dispatch
+// concurrently. Each message's due recipients are deferred until now and the
+// message is marked queued in one transaction; nothing is published until
+// that has committed. The recipients are then published as DispatchDeferred
+// would, which is also how they are retried if publishing fails. A message
+// that can't be dispatched is retried after dispatchRetryDelay, and failed
+// after maxDispatchAttempts.
+func (s *MessageService) DispatchScheduled(ctx context.Context, before time.Time, limit int) (int, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	messages, err := s.messageRepo.ClaimScheduled(ctx, tx, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim scheduled messages: %w", err)
+	}
+
+	// Each message runs under a savepoint, so a failure undoes that
+	// message's writes without aborting the transaction for the rest.
+	dispatched := make([]*model.Message, 0, len(messages))
+	for i := range messages {
+		msg := &messages[i]
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT scheduled_message"); err != nil {
+			return 0, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+
+		if err := s.dispatchScheduled(ctx, tx, msg); err != nil {
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT scheduled_message"); err != nil {
+				return 0, fmt.Errorf("failed to roll back savepoint: %w", err)
+			}
+			log.Error().Err(err).
+				Str("message_id", msg.ID.String()).
+				Msg("failed to dispatch scheduled message")
+			if err := s.recordScheduledFailure(ctx, tx, msg, err); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT scheduled_message"); err != nil {
+			return 0, fmt.Errorf("failed to release savepoint: %w", err)
+		}
+		dispatched = append(dispatched, msg)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for _, msg := range dispatched {
+		s.publishDispatched(ctx, msg)
+	}
+
+	return len(dispatched), nil
+}
+
+// dispatchScheduled hands a claimed scheduled message's recipients to the
+// deferred dispatcher and marks the message queued within tx. Recipients
+// outside the send window are deferred until it opens, the rest until now.
+func (s *MessageService) dispatchScheduled(ctx context.Context, tx *sqlx.Tx, msg *model.Message) error {
 	recipients, err := s.recipientRepo.GetByMessageID(ctx, msg.ID)
 	if err != nil {
 		return fmt.Errorf("failed to get recipients: %w", err)
 	}
 
-	if err := s.publishRecipients(ctx, msg, recipients); err != nil {
-		return err
+	now := time.Now()
+	due := s.applySendWindow(msg, recipients, now, "scheduler")
+	for _, r := range recipients {
+		until := now
+		if r.Status == model.StatusScheduled && r.DeferredUntil != nil {
+			until = *r.DeferredUntil
+		}
+		if err := s.recipientRepo.Defer(ctx, tx, r.ID, until); err != nil {
+			return fmt.Errorf("failed to defer recipient: %w", err)
+		}
 	}
 
-	if err := s.messageRepo.UpdateStatus(ctx, msg.ID, model.StatusQueued); err != nil {
+	if err := s.messageRepo.TransitionStatus(ctx, tx, msg.ID, model.StatusScheduled, model.StatusQueued); err != nil {
 		return fmt.Errorf("failed to update message status: %w", err)
 	}
 
-	log.Info().
+	event := log.Info()
+	if msg.RequestID != nil {
+		event = event.Str("request_id", *msg.RequestID)
+	}
+	event.
 		Str("message_id", msg.ID.String()).
-		Int("recipients", len(recipients)).
-		Msg("scheduled message published")
+		Int("recipients", len(due)).
+		Int("deferred", len(recipients)-len(due)).
+		Msg("scheduled message dispatched")
 
 	return nil
 }
 
+// recordScheduledFailure holds back a scheduled message whose dispatch
+// failed with cause, or fails it and its recipients once it has run out of
+// attempts.
+func (s *MessageService) recordScheduledFailure(ctx context.Context, tx *sqlx.Tx, msg *model.Message, cause error) error {
+	failed, err := s.messageRepo.RecordDispatchFailure(ctx, tx, msg.ID, time.Now().Add(dispatchRetryDelay), maxDispatchAttempts)
+	if err != nil {
+		return fmt.Errorf("failed to record dispatch failure: %w", err)
+	}
+	if !failed {
+		return nil
+	}
+
+	errMsg := fmt.Sprintf("dispatch failed after %d attempts: %v", maxDispatchAttempts, cause)
+	if err := s.recipientRepo.FailPending(ctx, tx, msg.ID, errMsg); err != nil {
+		return fmt.Errorf("failed to fail recipients: %w", err)
+	}
+	log.Error().
+		Str("message_id", msg.ID.String()).
+		Int("attempts", maxDispatchAttempts).
+		Msg("giving up on scheduled message")
+	return nil
+}
+
+// publishDispatched publishes the due recipients of a message that has just
+// been dispatched. Failures are logged; the recipients stay deferred, so
+// DispatchDeferred retries them.
+func (s *MessageService) publishDispatched(ctx context.Context, msg *model.Message) {
+	_, err := s.dispatchDeferred(ctx, func(tx *sqlx.Tx) ([]model.Recipient, error) {
+		return s.recipientRepo.ClaimDeferredByMessage(ctx, tx, msg.ID, time.Now())
+	})
+	if err != nil {
+		log.Warn().Err(err).
+			Str("message_id", msg.ID.String()).
+			Msg("failed to publish dispatched message, leaving it to the scheduler")
+	}
+}
+
+// DispatchDeferred publishes up to limit recipients that were deferred by a
+// send window, or by DispatchScheduled, and are due before the given time,
+// and returns how many were published. Like DispatchScheduled, recipients are
+// claimed with row locks so replicas never publish the same recipient twice.
+func (s *MessageService) DispatchDeferred(ctx context.Context, before time.Time, limit int) (int, error) {
+	return s.dispatchDeferred(ctx, func(tx *sqlx.Tx) ([]model.Recipient, error) {
+		return s.recipientRepo.ClaimDeferred(ctx, tx, before, limit)
+	})
+}
+
+// dispatchDeferred publishes the deferred recipients claimed by claim. Each
+// recipient is released under a savepoint before it is published, and the
+// release is rolled back if the publish fails, so one failure doesn't undo
+// the rest of the batch. The row lock is held until the release commits, so
+// a worker that receives the event first waits for it. A recipient that
+// can't be published is deferred by dispatchRetryDelay, and failed after
+// maxDispatchAttempts.
+func (s *MessageService) dispatchDeferred(ctx context.Context, claim func(tx *sqlx.Tx) ([]model.Recipient, error)) (int, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	recipients, err := claim(tx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim deferred recipients: %w", err)
+	}
+
+	messages := make(map[uuid.UUID]*model.Message)
+	published := 0
+	for _, r := range recipients {
+		msg, ok := messages[r.MessageID]
+		if !ok {
+			msg, err = s.messageRepo.GetByID(ctx, r.MessageID)
+			if err != nil {
+				log.Error().Err(err).
+					Str("message_id", r.MessageID.String()).
+					Msg("failed to get message for deferred recipient")
+				if err := s.recordDeferredFailure(ctx, tx, r, err); err != nil {
+					return 0, err
+				}
+				continue
+			}
+			messages[r.MessageID] = msg
+		}
+
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT deferred_recipient"); err != nil {
+			return 0, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+
+		if err := s.releaseAndPublish(ctx, tx, msg, r); err != nil {
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT deferred_recipient"); err != nil {
+				return 0, fmt.Errorf("failed to roll back savepoint: %w", err)
+			}
+			log.Error().Err(err).
+				Str("message_id", msg.ID.String()).
+				Str("recipient_id", r.ID.String()).
+				Msg("failed to publish deferred recipient")
+			if err := s.recordDeferredFailure(ctx, tx, r, err); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT deferred_recipient"); err != nil {
+			return 0, fmt.Errorf("failed to release savepoint: %w", err)
+		}
+		published++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return published, nil
+}
+
+// releaseAndPublish releases a claimed deferred recipient within tx and
+// publishes it.
+func (s *MessageService) releaseAndPublish(ctx context.Context, tx *sqlx.Tx, msg *model.Message, r model.Recipient) error {
+	if err := s.recipientRepo.Release(ctx, tx, r.ID); err != nil {
+		return fmt.Errorf("failed to release deferred recipient: %w", err)
+	}
+	return s.publishRecipients(ctx, msg, []model.Recipient{r})
+}
+
+// recordDeferredFailure defers a recipient whose publish failed with cause
+// again, or fails it once it has run out of attempts.
+func (s *MessageService) recordDeferredFailure(ctx context.Context, tx *sqlx.Tx, r model.Recipient, cause error) error {
+	errMsg := fmt.Sprintf("publish failed after %d attempts: %v", maxDispatchAttempts, cause)
+	failed, err := s.recipientRepo.RecordDispatchFailure(ctx, tx, r.ID, time.Now().Add(dispatchRetryDelay), maxDispatchAttempts, errMsg)
+	if err != nil {
+		return fmt.Errorf("failed to record dispatch failure: %w", err)
+	}
+	if failed {
+		log.Error().
+			Str("message_id", r.MessageID.String()).
+			Str("recipient_id", r.ID.String()).
+			Int("attempts", maxDispatchAttempts).
+			Msg("giving up on deferred recipient")
+	}
+	return nil
+}
+
 // platformToRoutingKey maps a platform to its RabbitMQ routing key.
 func platformToRoutingKey(p model.Platform) string {
 	switch p {
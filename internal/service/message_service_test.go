@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"notification-system/internal/config"
+	"notification-system/internal/model"
+	"notification-system/internal/queue"
+	"notification-system/internal/repository"
+)
+
+// testDatabaseEnv names the environment variable holding the URL of a
+// disposable Postgres database for integration tests. The tests migrate it
+// up, and dispatching publishes every due message in it, not just their own.
+const testDatabaseEnv = "TEST_DATABASE_URL"
+
+// testDB connects to the integration test database, skipping the test when
+// none is configured.
+func testDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	dsn := os.Getenv(testDatabaseEnv)
+	if dsn == "" || testing.Short() {
+		t.Skipf("%s not set", testDatabaseEnv)
+	}
+
+	m, err := migrate.New("file://../../migrations", dsn)
+	if err != nil {
+		t.Fatalf("create migrate instance: %v", err)
+	}
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		t.Fatalf("migrate up: %v", err)
+	}
+	m.Close()
+
+	db, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// createTestUser creates a user that is deleted, with everything it owns,
+// when the test ends.
+func createTestUser(t *testing.T, db *sqlx.DB) *model.User {
+	t.Helper()
+	ctx := context.Background()
+
+	now := time.Now()
+	user := &model.User{
+		ID:            uuid.New(),
+		Email:         fmt.Sprintf("%s@example.com", uuid.NewString()),
+		Role:          model.RoleUser,
+		RateLimitTier: "free",
+		IsActive:      true,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback()
+	if err := repository.NewUserRepository(db).Create(ctx, tx, user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if _, err := db.Exec(`DELETE FROM users WHERE id = $1`, user.ID); err != nil {
+			t.Errorf("delete test user: %v", err)
+		}
+	})
+	return user
+}
+
+// countingPublisher counts the events published for each recipient. If fail
+// is set, events it returns true for aren't published.
+type countingPublisher struct {
+	mu     sync.Mutex
+	counts map[string]int
+	fail   func(event queue.MessageQueuedEvent) bool
+}
+
+func (p *countingPublisher) Publish(_ context.Context, _, _ string, body interface{}) error {
+	event, ok := body.(queue.MessageQueuedEvent)
+	if !ok {
+		return fmt.Errorf("unexpected event %T", body)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.fail != nil && p.fail(event) {
+		return errors.New("broker unavailable")
+	}
+	p.counts[event.RecipientID]++
+	return nil
+}
+
+// createDueMessages commits n due scheduled messages of user, each with
+// recipients recipients, and returns their IDs.
+func createDueMessages(t *testing.T, db *sqlx.DB, svc *MessageService, user *model.User, n, recipients int) []uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback()
+
+	ids := make([]uuid.UUID, n)
+	for i := range ids {
+		to := make([]string, recipients)
+		for j := range to {
+			to[j] = fmt.Sprintf("user%d.%d@example.com", i, j)
+		}
+		msg, err := svc.CreateDueTx(ctx, tx, user.ID, nil, model.CreateMessageRequest{
+			Subject:  "dispatch test",
+			Message:  "hello",
+			From:     "test@example.com",
+			To:       to,
+			Platform: string(model.PlatformEmail),
+		})
+		if err != nil {
+			t.Fatalf("create message %d: %v", i, err)
+		}
+		ids[i] = msg.ID
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	return ids
+}
+
+// assertPublishedOnce checks that every recipient of the messages was
+// published exactly once and released, and that the messages are queued.
+func assertPublishedOnce(t *testing.T, db *sqlx.DB, publisher *countingPublisher, ids []uuid.UUID) {
+	t.Helper()
+	ctx := context.Background()
+	messageRepo := repository.NewMessageRepository(db)
+	recipientRepo := repository.NewRecipientRepository(db)
+
+	for _, id := range ids {
+		msg, err := messageRepo.GetByID(ctx, id)
+		if err != nil {
+			t.Fatalf("get message %s: %v", id, err)
+		}
+		if msg.Status != model.StatusQueued {
+			t.Errorf("message %s is %s, want queued", id, msg.Status)
+		}
+
+		recipients, err := recipientRepo.GetByMessageID(ctx, id)
+		if err != nil {
+			t.Fatalf("get recipients of %s: %v", id, err)
+		}
+		for _, r := range recipients {
+			if got := publisher.counts[r.ID.String()]; got != 1 {
+				t.Errorf("recipient %s published %d times, want 1", r.ID, got)
+			}
+			if r.Status != model.StatusPending {
+				t.Errorf("recipient %s is %s, want pending", r.ID, r.Status)
+			}
+		}
+	}
+}
+
+func TestDispatchScheduledIntegration(t *testing.T) {
+	const messages, dispatchers, batch = 200, 8, 10
+
+	db := testDB(t)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	publisher := &countingPublisher{counts: make(map[string]int)}
+	svc := NewMessageService(db, repository.NewMessageRepository(db), repository.NewRecipientRepository(db), publisher, config.SMSConfig{})
+	ids := createDueMessages(t, db, svc, user, messages, 1)
+
+	// Dispatch concurrently, as several scheduler replicas would, until a
+	// round dispatches nothing.
+	var wg sync.WaitGroup
+	errs := make(chan error, dispatchers)
+	for i := 0; i < dispatchers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				n, err := svc.DispatchScheduled(ctx, time.Now(), batch)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if n == 0 {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("DispatchScheduled: %v", err)
+	}
+
+	assertPublishedOnce(t, db, publisher, ids)
+}
+
+func TestDispatchScheduledPublishFailure(t *testing.T) {
+	const messages, recipients, batch = 20, 3, 5
+
+	db := testDB(t)
+	ctx := context.Background()
+	user := createTestUser(t, db)
+
+	// The broker rejects the first publish of every other recipient, so
+	// batches and messages fail partway through.
+	attempts := make(map[string]int)
+	publisher := &countingPublisher{
+		counts: make(map[string]int),
+		fail: func(event queue.MessageQueuedEvent) bool {
+			attempts[event.RecipientID]++
+			return len(attempts)%2 == 0 && attempts[event.RecipientID] == 1
+		},
+	}
+	svc := NewMessageService(db, repository.NewMessageRepository(db), repository.NewRecipientRepository(db), publisher, config.SMSConfig{})
+	ids := createDueMessages(t, db, svc, user, messages, recipients)
+
+	for {
+		n, err := svc.DispatchScheduled(ctx, time.Now(), batch)
+		if err != nil {
+			t.Fatalf("DispatchScheduled: %v", err)
+		}
+		if n == 0 {
+			break
+		}
+	}
+	if len(publisher.counts) == messages*recipients {
+		t.Fatal("every recipient was published despite the failures")
+	}
+
+	// Another dispatch publishes nothing new, and the recipients that failed
+	// are retried once their retry delay is up.
+	if n, err := svc.DispatchScheduled(ctx, time.Now(), batch); err != nil || n != 0 {
+		t.Fatalf("DispatchScheduled again: dispatched %d, error %v; want 0, nil", n, err)
+	}
+	if n, err := svc.DispatchDeferred(ctx, time.Now(), messages*recipients); err != nil || n != 0 {
+		t.Fatalf("DispatchDeferred before the retry delay: published %d, error %v; want 0, nil", n, err)
+	}
+	for {
+		n, err := svc.DispatchDeferred(ctx, time.Now().Add(dispatchRetryDelay), batch)
+		if err != nil {
+			t.Fatalf("DispatchDeferred: %v", err)
+		}
+		if n == 0 {
+			break
+		}
+	}
+
+	assertPublishedOnce(t, db, publisher, ids)
+}
+
+func TestUpdateScheduledRejectsEmptyTo(t *testing.T) {
+	svc := NewMessageService(nil, nil, nil, nil, config.SMSConfig{})
+
+	_, _, err := svc.UpdateScheduled(context.Background(), uuid.New(), uuid.New(), model.UpdateMessageRequest{To: []string{}})
+	if !errors.Is(err, ErrInvalidMessage) {
+		t.Fatalf("UpdateScheduled with an empty to: got error %v, want %v", err, ErrInvalidMessage)
+	}
+}
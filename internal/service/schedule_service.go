@@ -0,0 +1,342 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+
+	"notification-system/internal/model"
+	"notification-system/internal/recurrence"
+	"notification-system/internal/repository"
+	"notification-system/internal/sendwindow"
+)
+
+var (
+	// ErrScheduleNotFound is returned when a schedule doesn't exist or belongs to another user.
+	ErrScheduleNotFound = errors.New("schedule not found")
+	// ErrInvalidSchedule is returned when a schedule's recurrence, timezone or dates are invalid.
+	ErrInvalidSchedule = errors.New("invalid schedule")
+	// ErrScheduleState is returned when pausing or resuming a schedule that isn't in a suitable state.
+	ErrScheduleState = errors.New("schedule is not in a state that allows this operation")
+)
+
+// ScheduleService manages recurring schedules and materializes their
+// occurrences as scheduled messages.
+type ScheduleService struct {
+	db           *sqlx.DB
+	scheduleRepo repository.ScheduleRepository
+	msgService   *MessageService
+}
+
+// NewScheduleService creates a new ScheduleService.
+func NewScheduleService(db *sqlx.DB, scheduleRepo repository.ScheduleRepository, msgService *MessageService) *ScheduleService {
+	return &ScheduleService{
+		db:           db,
+		scheduleRepo: scheduleRepo,
+		msgService:   msgService,
+	}
+}
+
+// Create validates and stores a new active schedule for userID.
+func (s *ScheduleService) Create(ctx context.Context, userID uuid.UUID, apiKeyID *uuid.UUID, req model.CreateScheduleRequest) (*model.Schedule, error) {
+	now := time.Now()
+
+	startsAt := now
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+	if req.EndsAt != nil && !req.EndsAt.After(startsAt) {
+		return nil, fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidSchedule)
+	}
+
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	rule, err := recurrence.Parse(req.Cron, req.RRule, timezone, startsAt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+
+	if err := s.msgService.ValidateBody(model.Platform(req.Platform), req.Message); err != nil {
+		return nil, err
+	}
+
+	var window *sendwindow.Window
+	if req.SendWindow != nil && !req.SendWindow.IsZero() {
+		if err := req.SendWindow.Validate(); err != nil {
+			return nil, err
+		}
+		window = req.SendWindow
+	}
+
+	// An occurrence exactly at starts_at counts as the first run.
+	next := nextRun(rule, latest(now, startsAt.Add(-time.Second)), req.EndsAt)
+	if next == nil {
+		return nil, fmt.Errorf("%w: schedule has no occurrences before ends_at", ErrInvalidSchedule)
+	}
+
+	priority := model.PriorityNormal
+	if req.Priority != nil {
+		priority = model.Priority(*req.Priority)
+	}
+
+	sched := &model.Schedule{
+		ID:         uuid.New(),
+		UserID:     userID,
+		APIKeyID:   apiKeyID,
+		Name:       req.Name,
+		CronExpr:   optionalString(req.Cron),
+		RRule:      optionalString(req.RRule),
+		Timezone:   timezone,
+		Subject:    req.Subject,
+		Body:       req.Message,
+		Sender:     req.From,
+		Recipients: pq.StringArray(req.To),
+		Platform:   model.Platform(req.Platform),
+		Priority:   priority,
+		Status:     model.ScheduleActive,
+		StartsAt:   startsAt,
+		EndsAt:     req.EndsAt,
+		SendWindow: window,
+		NextRunAt:  next,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := s.scheduleRepo.Create(ctx, sched); err != nil {
+		return nil, fmt.Errorf("failed to create schedule: %w", err)
+	}
+
+	return sched, nil
+}
+
+// List returns all schedules owned by userID.
+func (s *ScheduleService) List(ctx context.Context, userID uuid.UUID) ([]model.Schedule, error) {
+	return s.scheduleRepo.ListByUser(ctx, userID)
+}
+
+// Get returns a schedule owned by userID.
+func (s *ScheduleService) Get(ctx context.Context, userID, id uuid.UUID) (*model.Schedule, error) {
+	sched, err := s.scheduleRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrScheduleNotFound
+		}
+		return nil, err
+	}
+	if sched.UserID != userID {
+		return nil, ErrScheduleNotFound
+	}
+	return sched, nil
+}
+
+// Pause stops an active schedule from producing further messages.
+func (s *ScheduleService) Pause(ctx context.Context, userID, id uuid.UUID) (*model.Schedule, error) {
+	sched, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if sched.Status != model.ScheduleActive {
+		return nil, fmt.Errorf("%w: schedule is %s", ErrScheduleState, sched.Status)
+	}
+
+	return s.setStatus(ctx, sched, model.SchedulePaused, nil)
+}
+
+// Resume reactivates a paused schedule. Occurrences missed while it was
+// paused are skipped; the next run is the first occurrence after now.
+func (s *ScheduleService) Resume(ctx context.Context, userID, id uuid.UUID) (*model.Schedule, error) {
+	sched, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if sched.Status != model.SchedulePaused {
+		return nil, fmt.Errorf("%w: schedule is %s", ErrScheduleState, sched.Status)
+	}
+
+	rule, err := scheduleRule(sched)
+	if err != nil {
+		return nil, err
+	}
+
+	next := nextRun(rule, latest(time.Now(), sched.StartsAt.Add(-time.Second)), sched.EndsAt)
+	if next == nil {
+		return s.setStatus(ctx, sched, model.ScheduleCompleted, nil)
+	}
+	return s.setStatus(ctx, sched, model.ScheduleActive, next)
+}
+
+// Delete removes a schedule. Messages it already produced are kept.
+func (s *ScheduleService) Delete(ctx context.Context, userID, id uuid.UUID) (*model.Schedule, error) {
+	sched, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.scheduleRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrScheduleNotFound
+		}
+		return nil, err
+	}
+
+	return sched, nil
+}
+
+// MaterializeDue creates a scheduled message for each active schedule whose
+// next run is due before now, up to limit, and returns how many were
+// created. Schedules are claimed with row locks, so this is safe to run on
+// several replicas. A schedule that fell behind, for example while the
+// service was down, produces one catch-up message rather than one per missed
+// occurrence.
+func (s *ScheduleService) MaterializeDue(ctx context.Context, now time.Time, limit int) (int, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	schedules, err := s.scheduleRepo.ClaimDue(ctx, tx, now, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim due schedules: %w", err)
+	}
+
+	// Each schedule runs under a savepoint so one failure doesn't abort the
+	// transaction for the rest of the batch.
+	created := 0
+	for i := range schedules {
+		sched := &schedules[i]
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT materialize"); err != nil {
+			return 0, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+
+		ok, err := s.materialize(ctx, tx, sched, now)
+		if err != nil {
+			log.Error().Err(err).
+				Str("schedule_id", sched.ID.String()).
+				Msg("failed to materialize schedule")
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT materialize"); err != nil {
+				return 0, fmt.Errorf("failed to roll back savepoint: %w", err)
+			}
+			continue
+		}
+		if ok {
+			created++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return created, nil
+}
+
+// materialize creates the message for sched's due run within tx and
+// advances it to the next occurrence after now, reporting whether a message
+// was created. A schedule whose rule no longer parses is paused instead, so
+// it stops failing on every scan.
+func (s *ScheduleService) materialize(ctx context.Context, tx *sqlx.Tx, sched *model.Schedule, now time.Time) (bool, error) {
+	runAt := *sched.NextRunAt
+
+	rule, err := scheduleRule(sched)
+	if err != nil {
+		log.Error().Err(err).
+			Str("schedule_id", sched.ID.String()).
+			Msg("pausing schedule with invalid rule")
+		if err := s.scheduleRepo.Advance(ctx, tx, sched.ID, runAt, nil, model.SchedulePaused); err != nil {
+			return false, fmt.Errorf("failed to pause schedule: %w", err)
+		}
+		return false, nil
+	}
+
+	msg, err := s.msgService.CreateFromSchedule(ctx, tx, sched, runAt)
+	if err != nil {
+		return false, err
+	}
+
+	status := model.ScheduleActive
+	next := nextRun(rule, now, sched.EndsAt)
+	if next == nil {
+		status = model.ScheduleCompleted
+	}
+
+	if err := s.scheduleRepo.Advance(ctx, tx, sched.ID, runAt, next, status); err != nil {
+		return false, fmt.Errorf("failed to advance schedule: %w", err)
+	}
+
+	event := log.Info().
+		Str("schedule_id", sched.ID.String()).
+		Str("message_id", msg.ID.String()).
+		Time("run_at", runAt)
+	if next != nil {
+		event = event.Time("next_run_at", *next)
+	}
+	event.Msg("schedule materialized")
+
+	return true, nil
+}
+
+func (s *ScheduleService) setStatus(ctx context.Context, sched *model.Schedule, status model.ScheduleStatus, next *time.Time) (*model.Schedule, error) {
+	if err := s.scheduleRepo.SetStatus(ctx, sched.ID, status, next); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrScheduleNotFound
+		}
+		return nil, err
+	}
+
+	updated := *sched
+	updated.Status = status
+	updated.NextRunAt = next
+	updated.UpdatedAt = time.Now()
+	return &updated, nil
+}
+
+// scheduleRule parses a stored schedule's recurrence.
+func scheduleRule(sched *model.Schedule) (recurrence.Rule, error) {
+	var cronExpr, rrule string
+	if sched.CronExpr != nil {
+		cronExpr = *sched.CronExpr
+	}
+	if sched.RRule != nil {
+		rrule = *sched.RRule
+	}
+
+	rule, err := recurrence.Parse(cronExpr, rrule, sched.Timezone, sched.StartsAt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	return rule, nil
+}
+
+// nextRun returns rule's first occurrence after t, or nil if there is none
+// on or before endsAt.
+func nextRun(rule recurrence.Rule, t time.Time, endsAt *time.Time) *time.Time {
+	next := rule.Next(t)
+	if next.IsZero() || (endsAt != nil && next.After(*endsAt)) {
+		return nil
+	}
+	return &next
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"notification-system/internal/auth"
+	"notification-system/internal/model"
+	"notification-system/internal/repository"
+)
+
+const (
+	defaultUserTier     = "free"
+	defaultFirstKeyName = "default"
+)
+
+var (
+	// ErrUserNotFound is returned when a user doesn't exist.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUserExists is returned when creating a user whose email is taken.
+	ErrUserExists = errors.New("a user with this email already exists")
+	// ErrUnknownTier is returned when a rate limit tier is not configured.
+	ErrUnknownTier = errors.New("unknown rate limit tier")
+	// ErrSelfModification is returned when an admin tries to lock themselves out.
+	ErrSelfModification = errors.New("admins cannot deactivate or demote themselves")
+)
+
+// UserService handles user administration.
+type UserService struct {
+	db         *sqlx.DB
+	userRepo   repository.UserRepository
+	keyService *KeyService
+	tiers      map[string]struct{}
+}
+
+// NewUserService creates a new UserService. tiers lists the configured rate limit tiers.
+func NewUserService(db *sqlx.DB, userRepo repository.UserRepository, keyService *KeyService, tiers []string) *UserService {
+	set := make(map[string]struct{}, len(tiers))
+	for _, t := range tiers {
+		set[t] = struct{}{}
+	}
+	return &UserService{
+		db:         db,
+		userRepo:   userRepo,
+		keyService: keyService,
+		tiers:      set,
+	}
+}
+
+// CreateUser creates a user and issues their first API key in one transaction.
+func (s *UserService) CreateUser(ctx context.Context, req model.CreateUserRequest) (*model.User, string, *model.APIKey, error) {
+	role := req.Role
+	if role == "" {
+		role = model.RoleUser
+	}
+	tier := req.RateLimitTier
+	if tier == "" {
+		tier = defaultUserTier
+	}
+	if err := s.checkTier(tier); err != nil {
+		return nil, "", nil, err
+	}
+	keyName := req.KeyName
+	if keyName == "" {
+		keyName = defaultFirstKeyName
+	}
+	scopes := req.KeyScopes
+	if len(scopes) == 0 {
+		scopes = []string{auth.ScopeAll}
+	}
+
+	now := time.Now()
+	user := &model.User{
+		ID:            uuid.New(),
+		Email:         req.Email,
+		Role:          role,
+		RateLimitTier: tier,
+		IsActive:      true,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.userRepo.Create(ctx, tx, user); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, "", nil, ErrUserExists
+		}
+		return nil, "", nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	raw, key, err := s.keyService.Issue(ctx, tx, user.ID, keyName, scopes, nil)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, "", nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return user, raw, key, nil
+}
+
+// GetUser returns a single user.
+func (s *UserService) GetUser(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// ListUsers returns a page of users with their activity stats.
+func (s *UserService) ListUsers(ctx context.Context, q model.ListUsersQuery) ([]model.UserWithStats, int, error) {
+	users, total, err := s.userRepo.List(ctx, q)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+	return users, total, nil
+}
+
+// UpdateUser applies req to the user and returns the user before and after the change.
+// actorID is the admin making the change.
+func (s *UserService) UpdateUser(ctx context.Context, actorID, id uuid.UUID, req model.UpdateUserRequest) (before, after *model.User, err error) {
+	return s.mutate(ctx, id, func(u *model.User) error {
+		if req.RateLimitTier != nil {
+			if err := s.checkTier(*req.RateLimitTier); err != nil {
+				return err
+			}
+			u.RateLimitTier = *req.RateLimitTier
+		}
+		if req.Role != nil {
+			if u.ID == actorID && *req.Role != model.RoleAdmin {
+				return ErrSelfModification
+			}
+			u.Role = *req.Role
+		}
+		if req.SendWindow != nil {
+			if req.SendWindow.IsZero() {
+				u.SendWindow = nil
+			} else {
+				if err := req.SendWindow.Validate(); err != nil {
+					return err
+				}
+				u.SendWindow = req.SendWindow
+			}
+		}
+		return nil
+	})
+}
+
+// SetActive activates or deactivates a user. Deactivated users are rejected
+// by the auth middleware regardless of their keys.
+func (s *UserService) SetActive(ctx context.Context, actorID, id uuid.UUID, active bool) (before, after *model.User, err error) {
+	return s.mutate(ctx, id, func(u *model.User) error {
+		if u.ID == actorID && !active {
+			return ErrSelfModification
+		}
+		u.IsActive = active
+		return nil
+	})
+}
+
+// mutate loads a user, applies fn to a copy and persists the result.
+func (s *UserService) mutate(ctx context.Context, id uuid.UUID, fn func(u *model.User) error) (*model.User, *model.User, error) {
+	before, err := s.GetUser(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	after := *before
+	if err := fn(&after); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.userRepo.Update(ctx, &after); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, ErrUserNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	// Role, tier and active state are all read from cached credentials.
+	if err := s.keyService.InvalidateUser(ctx, id); err != nil {
+		log.Error().Err(err).Str("user_id", id.String()).Msg("failed to invalidate cached credentials")
+	}
+
+	return before, &after, nil
+}
+
+// checkTier rejects tiers missing from the rate limit config. When no tiers
+// are configured every tier falls back to the default limit, so any is accepted.
+func (s *UserService) checkTier(tier string) error {
+	if len(s.tiers) == 0 {
+		return nil
+	}
+	if _, ok := s.tiers[tier]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownTier, tier)
+	}
+	return nil
+}
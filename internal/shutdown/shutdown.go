@@ -0,0 +1,24 @@
+// Package shutdown holds helpers shared by the commands for stopping
+// gracefully.
+package shutdown
+
+import (
+	"context"
+	"sync"
+)
+
+// Wait waits for wg until ctx is done and reports whether wg finished.
+func Wait(ctx context.Context, wg *sync.WaitGroup) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
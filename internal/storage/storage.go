@@ -0,0 +1,39 @@
+// Package storage keeps files, such as message exports, on local disk or in
+// an S3-compatible object store.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"notification-system/internal/config"
+)
+
+// ErrNotFound is returned when no object exists under a key.
+var ErrNotFound = errors.New("object not found")
+
+// Store saves and serves objects by key. Keys are slash-separated paths.
+type Store interface {
+	// Put stores size bytes read from r under key, replacing any existing
+	// object.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the object under key. The caller must close it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object under key. Deleting a missing object is not
+	// an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// New returns the Store selected by cfg.Driver.
+func New(cfg config.StorageConfig) (Store, error) {
+	switch cfg.Driver {
+	case "local", "":
+		return NewLocal(cfg.Dir)
+	case "s3":
+		return NewS3(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
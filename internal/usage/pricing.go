@@ -0,0 +1,65 @@
+package usage
+
+import (
+	"strings"
+
+	"notification-system/internal/config"
+	"notification-system/internal/model"
+	"notification-system/pkg/sms"
+)
+
+// defaultProvider is the price table key used when a provider has no explicit price.
+const defaultProvider = "default"
+
+// PriceTable estimates the cost of delivery attempts from configured unit prices.
+// A unit is an SMS segment for SMS and a single message for every other platform.
+type PriceTable struct {
+	currency string
+	prices   map[string]map[string]float64 // platform -> provider -> price per unit
+}
+
+// NewPriceTable creates a PriceTable from the usage configuration.
+func NewPriceTable(cfg config.UsageConfig) *PriceTable {
+	currency := strings.ToUpper(cfg.Currency)
+	if currency == "" {
+		currency = "USD"
+	}
+	return &PriceTable{
+		currency: currency,
+		prices:   cfg.Prices,
+	}
+}
+
+// Currency returns the ISO 4217 code prices are expressed in.
+func (p *PriceTable) Currency() string {
+	return p.currency
+}
+
+// UnitPrice returns the price of a single unit for the given platform and provider,
+// falling back to the platform's "default" entry and then to zero.
+func (p *PriceTable) UnitPrice(platform, provider string) float64 {
+	providers, ok := p.prices[platform]
+	if !ok {
+		return 0
+	}
+	if price, ok := providers[provider]; ok {
+		return price
+	}
+	return providers[defaultProvider]
+}
+
+// Estimate returns the estimated cost of sending units through the given platform and provider.
+func (p *PriceTable) Estimate(platform, provider string, units int) float64 {
+	return p.UnitPrice(platform, provider) * float64(units)
+}
+
+// Units returns the number of billable units for a message body on a platform,
+// along with the SMS encoding when applicable.
+func Units(platform, body string) (units int, encoding *string) {
+	if model.Platform(platform) != model.PlatformSMS {
+		return 1, nil
+	}
+	a := sms.Analyze(body)
+	enc := string(a.Encoding)
+	return a.Segments, &enc
+}
@@ -3,23 +3,36 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
-	"github.com/rs/zerolog/log"
 
 	"notification-system/internal/adapter"
 	"notification-system/internal/metrics"
 	"notification-system/internal/model"
 	"notification-system/internal/queue"
 	"notification-system/internal/repository"
+	"notification-system/internal/usage"
+	"notification-system/pkg/logger"
+	"notification-system/pkg/requestid"
 )
 
+// recipientRetryDelays are the waits between attempts to update a recipient
+// that doesn't exist yet. Recipients can be published before the
+// transaction that created them commits, so a delivery may briefly arrive
+// ahead of its row.
+var recipientRetryDelays = []time.Duration{100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond, time.Second}
+
 // Worker processes queued notification events.
 type Worker struct {
 	consumer      *queue.Consumer
 	recipientRepo repository.RecipientRepository
 	messageRepo   repository.MessageRepository
+	usageRepo     repository.UsageRepository
+	attemptRepo   repository.DeliveryAttemptRepository
+	prices        *usage.PriceTable
 	adapters      map[string]adapter.Sender
 }
 
@@ -28,34 +41,66 @@ func NewWorker(
 	consumer *queue.Consumer,
 	recipientRepo repository.RecipientRepository,
 	messageRepo repository.MessageRepository,
+	usageRepo repository.UsageRepository,
+	attemptRepo repository.DeliveryAttemptRepository,
+	prices *usage.PriceTable,
 	adapters map[string]adapter.Sender,
 ) *Worker {
 	return &Worker{
 		consumer:      consumer,
 		recipientRepo: recipientRepo,
 		messageRepo:   messageRepo,
+		usageRepo:     usageRepo,
+		attemptRepo:   attemptRepo,
+		prices:        prices,
 		adapters:      adapters,
 	}
 }
 
 // Start begins consuming messages from the given queue.
-func (w *Worker) Start(ctx context.Context, queueName, routingKey string) error {
-	log.Info().
+func (w *Worker) Start(ctx context.Context, queueName, routingKey string, opts queue.ConsumeOptions) error {
+	logger.Get().Info().
 		Str("queue", queueName).
 		Str("routing_key", routingKey).
+		Int("concurrency", opts.Concurrency).
 		Msg("worker started")
 
-	return w.consumer.Consume(ctx, queueName, routingKey, w.processMessage)
+	return w.consumer.Consume(ctx, queueName, routingKey, w.processMessage, opts)
+}
+
+// RecipientKey is a queue.ConsumeOptions ordering key that keeps deliveries
+// to the same recipient address in order. Bodies that don't decode share the
+// empty key.
+func RecipientKey(body []byte) string {
+	var event struct {
+		To string `json:"to"`
+	}
+	json.Unmarshal(body, &event)
+	return event.To
 }
 
 // processMessage handles a single queued message event.
 func (w *Worker) processMessage(ctx context.Context, body []byte) error {
 	var event queue.MessageQueuedEvent
 	if err := json.Unmarshal(body, &event); err != nil {
-		log.Error().Err(err).Msg("failed to unmarshal event")
+		logger.Ctx(ctx).Error().Err(err).Msg("failed to unmarshal event")
 		return fmt.Errorf("unmarshal error: %w", err)
 	}
 
+	// Prefer the AMQP header; events from older publishers only carry it in metadata.
+	reqID := requestid.FromContext(ctx)
+	if reqID == "" {
+		reqID = event.Metadata[requestid.MetadataKey]
+	}
+	if reqID != "" {
+		ctx = logger.WithRequestID(requestid.NewContext(ctx, reqID), reqID)
+	}
+	log := logger.Ctx(ctx)
+
+	inFlight := metrics.MessagesInFlight.WithLabelValues(event.Platform)
+	inFlight.Inc()
+	defer inFlight.Dec()
+
 	log.Info().
 		Str("message_id", event.MessageID).
 		Str("recipient_id", event.RecipientID).
@@ -68,8 +113,38 @@ func (w *Worker) processMessage(ctx context.Context, body []byte) error {
 		return fmt.Errorf("invalid recipient ID: %w", err)
 	}
 
-	// Update recipient status to Processing
-	if err := w.recipientRepo.UpdateStatus(ctx, recipientID, model.StatusProcessing, nil); err != nil {
+	// A delivery that waited in the queue past the end of its send window is
+	// handed back to the scheduler rather than sent at the wrong time of day.
+	deferred, err := w.deferOutsideWindow(ctx, &event, recipientID)
+	if err != nil {
+		return err
+	}
+	if deferred {
+		return nil
+	}
+
+	// Claim the recipient; a cancelled one, or one another delivery of this
+	// event has already claimed, is skipped and its delivery acked, and a
+	// missing one is handed back to the queue
+	err = whenVisible(ctx, func() error { return w.recipientRepo.StartProcessing(ctx, recipientID) })
+	switch {
+	case errors.Is(err, repository.ErrCancelled):
+		log.Info().
+			Str("message_id", event.MessageID).
+			Str("recipient_id", event.RecipientID).
+			Msg("recipient cancelled, skipping")
+		metrics.MessagesProcessedTotal.WithLabelValues(event.Platform, "cancelled").Inc()
+		return nil
+	case errors.Is(err, repository.ErrNotPending):
+		log.Info().
+			Str("message_id", event.MessageID).
+			Str("recipient_id", event.RecipientID).
+			Msg("recipient already handled, skipping duplicate delivery")
+		metrics.MessagesProcessedTotal.WithLabelValues(event.Platform, "duplicate").Inc()
+		return nil
+	case errors.Is(err, repository.ErrNotFound):
+		return fmt.Errorf("%w: recipient %s not found", queue.ErrRequeue, recipientID)
+	case err != nil:
 		log.Error().Err(err).Str("recipient_id", event.RecipientID).Msg("failed to update recipient status to processing")
 		// Continue processing anyway
 	}
@@ -79,23 +154,39 @@ func (w *Worker) processMessage(ctx context.Context, body []byte) error {
 	if !ok {
 		errMsg := fmt.Sprintf("no adapter for platform: %s", event.Platform)
 		log.Error().Str("platform", event.Platform).Msg(errMsg)
-		w.recipientRepo.UpdateStatus(ctx, recipientID, model.StatusFailed, nil)
-		return fmt.Errorf(errMsg)
+		w.recipientRepo.Fail(ctx, recipientID, errMsg)
+		return errors.New(errMsg)
 	}
 
 	// Send notification
+	provider := senderAdapter.Provider()
+	started := time.Now()
 	result, err := senderAdapter.Send(ctx, event.To, event.Subject, event.Body)
+	w.recordAttempt(ctx, &event, recipientID, provider, started, result, err)
 	if err != nil {
+		code := adapter.ErrorCode(err)
+		metrics.ProviderSendDuration.WithLabelValues(event.Platform, provider, "failure").Observe(time.Since(started).Seconds())
+		metrics.ProviderErrorsTotal.WithLabelValues(event.Platform, provider, code).Inc()
+
 		log.Error().Err(err).
 			Str("message_id", event.MessageID).
 			Str("to", event.To).
+			Str("error_code", code).
 			Msg("failed to send notification")
 
-		w.recipientRepo.UpdateStatus(ctx, recipientID, model.StatusFailed, nil)
+		w.recipientRepo.Fail(ctx, recipientID, err.Error())
+		w.recordUsage(ctx, &event, recipientID, provider, false)
 		metrics.MessagesProcessedTotal.WithLabelValues(event.Platform, "failure").Inc()
 		return fmt.Errorf("send failed: %w", err)
 	}
 
+	metrics.ProviderSendDuration.WithLabelValues(event.Platform, provider, "success").Observe(time.Since(started).Seconds())
+	if !event.Timestamp.IsZero() {
+		metrics.MessageQueueLatency.WithLabelValues(event.Platform).Observe(time.Since(event.Timestamp).Seconds())
+	}
+
+	w.recordUsage(ctx, &event, recipientID, provider, true)
+
 	// Update recipient status to Sent
 	if err := w.recipientRepo.UpdateStatus(ctx, recipientID, model.StatusSent, &result.ProviderID); err != nil {
 		log.Error().Err(err).Str("recipient_id", event.RecipientID).Msg("failed to update recipient status to sent")
@@ -112,3 +203,179 @@ func (w *Worker) processMessage(ctx context.Context, body []byte) error {
 
 	return nil
 }
+
+// deferOutsideWindow reschedules the recipient for the next opening of the
+// event's send window if the window is closed now, and reports whether it did.
+func (w *Worker) deferOutsideWindow(ctx context.Context, event *queue.MessageQueuedEvent, recipientID uuid.UUID) (bool, error) {
+	if event.SendWindow == nil {
+		return false, nil
+	}
+
+	now := time.Now()
+	next, err := event.SendWindow.Next(event.To, model.Platform(event.Platform).UsesPhoneNumbers(), now)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Str("message_id", event.MessageID).Msg("failed to evaluate send window")
+		return false, nil
+	}
+	if !next.After(now) {
+		return false, nil
+	}
+
+	err = whenVisible(ctx, func() error { return w.recipientRepo.Reschedule(ctx, recipientID, next) })
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return false, fmt.Errorf("%w: recipient %s not found", queue.ErrRequeue, recipientID)
+		}
+		if errors.Is(err, repository.ErrCancelled) {
+			// Cancelled: nothing to defer, and the delivery must not be sent.
+			logger.Ctx(ctx).Info().
+				Str("message_id", event.MessageID).
+				Str("recipient_id", event.RecipientID).
+				Msg("recipient cancelled, skipping")
+			metrics.MessagesProcessedTotal.WithLabelValues(event.Platform, "cancelled").Inc()
+			return true, nil
+		}
+		if errors.Is(err, repository.ErrNotPending) {
+			// A duplicate delivery: the recipient is already deferred, or
+			// being or done being sent.
+			logger.Ctx(ctx).Info().
+				Str("message_id", event.MessageID).
+				Str("recipient_id", event.RecipientID).
+				Msg("recipient already handled, skipping duplicate delivery")
+			metrics.MessagesProcessedTotal.WithLabelValues(event.Platform, "duplicate").Inc()
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to defer recipient: %w", err)
+	}
+
+	metrics.RecipientsDeferredTotal.WithLabelValues(event.Platform, "worker").Inc()
+	logger.Ctx(ctx).Info().
+		Str("message_id", event.MessageID).
+		Str("recipient_id", event.RecipientID).
+		Time("deferred_until", next).
+		Msg("recipient outside send window, deferred")
+
+	return true, nil
+}
+
+// recordAttempt writes the delivery attempt history entry for a provider
+// call that started at started and returned result or sendErr. Failures are
+// logged but never fail the delivery itself.
+func (w *Worker) recordAttempt(ctx context.Context, event *queue.MessageQueuedEvent, recipientID uuid.UUID, provider string, started time.Time, result *adapter.SendResult, sendErr error) {
+	finished := time.Now()
+
+	messageID, err := uuid.Parse(event.MessageID)
+	if err != nil {
+		logger.Ctx(ctx).Error().Err(err).Str("message_id", event.MessageID).Msg("attempt: invalid message ID")
+		return
+	}
+
+	attempt := &model.DeliveryAttempt{
+		ID:          uuid.New(),
+		RecipientID: recipientID,
+		MessageID:   messageID,
+		Adapter:     provider,
+		Success:     sendErr == nil,
+		LatencyMs:   int(finished.Sub(started).Milliseconds()),
+		StartedAt:   started,
+		FinishedAt:  finished,
+	}
+
+	statusCode := 0
+	if sendErr != nil {
+		code, msg := adapter.ErrorCode(sendErr), sendErr.Error()
+		attempt.ErrorCode = &code
+		attempt.ErrorMessage = &msg
+		statusCode = adapter.StatusCode(sendErr)
+	} else {
+		attempt.ProviderID = &result.ProviderID
+		statusCode = result.StatusCode
+	}
+	if statusCode != 0 {
+		attempt.ResponseCode = &statusCode
+	}
+
+	if err := w.attemptRepo.Create(ctx, attempt); err != nil {
+		logger.Ctx(ctx).Error().Err(err).
+			Str("message_id", event.MessageID).
+			Str("recipient_id", event.RecipientID).
+			Msg("failed to record delivery attempt")
+	}
+}
+
+// recordUsage writes a usage ledger entry for a single delivery attempt.
+// Failures are logged but never fail the delivery itself.
+func (w *Worker) recordUsage(ctx context.Context, event *queue.MessageQueuedEvent, recipientID uuid.UUID, provider string, success bool) {
+	log := logger.Ctx(ctx)
+
+	messageID, err := uuid.Parse(event.MessageID)
+	if err != nil {
+		log.Error().Err(err).Str("message_id", event.MessageID).Msg("usage: invalid message ID")
+		return
+	}
+
+	// Events published before the user ID was carried on the event need a lookup.
+	userID, err := uuid.Parse(event.UserID)
+	if err != nil {
+		msg, err := w.messageRepo.GetByID(ctx, messageID)
+		if err != nil {
+			log.Error().Err(err).Str("message_id", event.MessageID).Msg("usage: failed to resolve message owner")
+			return
+		}
+		userID = msg.UserID
+	}
+
+	var apiKeyID *uuid.UUID
+	if id, err := uuid.Parse(event.APIKeyID); err == nil {
+		apiKeyID = &id
+	}
+
+	units, encoding := usage.Units(event.Platform, event.Body)
+
+	// Providers don't bill rejected requests, so failed attempts are recorded at zero cost.
+	cost := 0.0
+	if success {
+		cost = w.prices.Estimate(event.Platform, provider, units)
+	}
+
+	rec := &model.UsageRecord{
+		ID:            uuid.New(),
+		UserID:        userID,
+		APIKeyID:      apiKeyID,
+		MessageID:     messageID,
+		RecipientID:   recipientID,
+		Platform:      model.Platform(event.Platform),
+		Provider:      provider,
+		Encoding:      encoding,
+		Segments:      units,
+		Success:       success,
+		EstimatedCost: cost,
+		Currency:      w.prices.Currency(),
+		CreatedAt:     time.Now(),
+	}
+
+	if err := w.usageRepo.Create(ctx, rec); err != nil {
+		log.Error().Err(err).
+			Str("message_id", event.MessageID).
+			Str("recipient_id", event.RecipientID).
+			Msg("failed to record usage")
+	}
+}
+
+// whenVisible calls fn, retrying after each of recipientRetryDelays while it
+// returns repository.ErrNotFound.
+func whenVisible(ctx context.Context, fn func() error) error {
+	err := fn()
+	for _, delay := range recipientRetryDelays {
+		if !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		err = fn()
+	}
+	return err
+}
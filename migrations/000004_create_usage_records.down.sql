@@ -0,0 +1,3 @@
+-- 004_create_usage_records (DOWN)
+
+DROP TABLE IF EXISTS usage_records;
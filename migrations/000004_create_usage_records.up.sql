@@ -0,0 +1,22 @@
+-- 004_create_usage_records (UP)
+
+CREATE TABLE usage_records (
+    id             UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id        UUID          NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    message_id     UUID          NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    recipient_id   UUID          NOT NULL REFERENCES message_recipients(id) ON DELETE CASCADE,
+    platform       VARCHAR(20)   NOT NULL,
+    provider       VARCHAR(50)   NOT NULL,
+    encoding       VARCHAR(10),
+    segments       SMALLINT      NOT NULL DEFAULT 1,
+    success        BOOLEAN       NOT NULL,
+    estimated_cost NUMERIC(12,6) NOT NULL DEFAULT 0,
+    currency       CHAR(3)       NOT NULL,
+    created_at     TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+-- One row per delivery attempt. encoding/segments are only meaningful for SMS;
+-- other platforms are billed per message (segments = 1).
+
+CREATE INDEX idx_usage_records_created_at ON usage_records (created_at);
+CREATE INDEX idx_usage_records_user_created ON usage_records (user_id, created_at);
@@ -0,0 +1,21 @@
+-- 005_create_api_keys (DOWN)
+
+ALTER TABLE usage_records DROP COLUMN IF EXISTS api_key_id;
+ALTER TABLE messages DROP COLUMN IF EXISTS api_key_id;
+
+ALTER TABLE users ADD COLUMN api_key_hash VARCHAR(255) UNIQUE;
+
+-- Restore each user's oldest active key as their single key.
+UPDATE users u
+SET api_key_hash = k.key_hash
+FROM (
+    SELECT DISTINCT ON (user_id) user_id, key_hash
+    FROM api_keys
+    WHERE revoked_at IS NULL
+    ORDER BY user_id, created_at
+) k
+WHERE k.user_id = u.id;
+
+CREATE INDEX idx_users_api_key_hash ON users (api_key_hash);
+
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,32 @@
+-- 005_create_api_keys (UP)
+
+CREATE TABLE api_keys (
+    id           UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id      UUID         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name         VARCHAR(100) NOT NULL,
+    prefix       VARCHAR(20)  NOT NULL,
+    key_hash     VARCHAR(255) NOT NULL UNIQUE,
+    scopes       TEXT[]       NOT NULL DEFAULT '{}',
+    expires_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+-- Scopes: "*" grants every scope. See internal/auth/scopes.go for the full list.
+
+CREATE INDEX idx_api_keys_user_id ON api_keys (user_id);
+
+-- Carry over the single per-user key as an unrestricted "default" key.
+INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, created_at)
+SELECT id, 'default', 'legacy', api_key_hash, ARRAY['*'], created_at
+FROM users;
+
+DROP INDEX IF EXISTS idx_users_api_key_hash;
+ALTER TABLE users DROP COLUMN api_key_hash;
+
+-- Attribute messages and usage to the key that created them.
+ALTER TABLE messages ADD COLUMN api_key_id UUID REFERENCES api_keys(id) ON DELETE SET NULL;
+ALTER TABLE usage_records ADD COLUMN api_key_id UUID REFERENCES api_keys(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_usage_records_api_key_id ON usage_records (api_key_id);
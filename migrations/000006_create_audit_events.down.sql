@@ -0,0 +1,3 @@
+-- 006_create_audit_events (DOWN)
+
+DROP TABLE IF EXISTS audit_events;
@@ -0,0 +1,16 @@
+-- 006_create_audit_events (UP)
+
+CREATE TABLE audit_events (
+    id            UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    actor_user_id UUID         REFERENCES users(id) ON DELETE SET NULL,
+    action        VARCHAR(100) NOT NULL,
+    target_type   VARCHAR(50)  NOT NULL,
+    target_id     VARCHAR(100),
+    before        JSONB        NOT NULL DEFAULT 'null',
+    after         JSONB        NOT NULL DEFAULT 'null',
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_events_created_at ON audit_events (created_at);
+CREATE INDEX idx_audit_events_actor ON audit_events (actor_user_id);
+CREATE INDEX idx_audit_events_target ON audit_events (target_type, target_id);
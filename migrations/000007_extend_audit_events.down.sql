@@ -0,0 +1,11 @@
+-- 007_extend_audit_events (DOWN)
+
+DROP INDEX IF EXISTS idx_audit_events_request_id;
+DROP INDEX IF EXISTS idx_audit_events_action;
+DROP INDEX IF EXISTS idx_audit_events_created_at_id;
+CREATE INDEX idx_audit_events_created_at ON audit_events (created_at);
+
+ALTER TABLE audit_events
+    DROP COLUMN IF EXISTS request_id,
+    DROP COLUMN IF EXISTS ip,
+    DROP COLUMN IF EXISTS actor_key_id;
@@ -0,0 +1,12 @@
+-- 007_extend_audit_events (UP)
+
+ALTER TABLE audit_events
+    ADD COLUMN actor_key_id UUID REFERENCES api_keys(id) ON DELETE SET NULL,
+    ADD COLUMN ip           VARCHAR(45),
+    ADD COLUMN request_id   VARCHAR(128);
+
+-- Keyset pagination walks (created_at, id) newest first
+DROP INDEX IF EXISTS idx_audit_events_created_at;
+CREATE INDEX idx_audit_events_created_at_id ON audit_events (created_at DESC, id DESC);
+CREATE INDEX idx_audit_events_action ON audit_events (action);
+CREATE INDEX idx_audit_events_request_id ON audit_events (request_id);
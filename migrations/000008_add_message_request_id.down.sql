@@ -0,0 +1,3 @@
+-- 008_add_message_request_id (DOWN)
+
+ALTER TABLE messages DROP COLUMN IF EXISTS request_id;
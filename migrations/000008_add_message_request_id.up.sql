@@ -0,0 +1,5 @@
+-- 008_add_message_request_id (UP)
+
+-- The API request that created the message, so scheduled sends can be traced
+-- back to it when they are published later.
+ALTER TABLE messages ADD COLUMN request_id VARCHAR(128);
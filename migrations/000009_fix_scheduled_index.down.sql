@@ -0,0 +1,4 @@
+-- 009_fix_scheduled_index (DOWN)
+
+DROP INDEX IF EXISTS idx_messages_scheduled_at;
+CREATE INDEX idx_messages_scheduled_at ON messages (scheduled_at) WHERE scheduled_at IS NOT NULL AND status = 0;
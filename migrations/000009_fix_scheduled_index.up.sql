@@ -0,0 +1,6 @@
+-- 009_fix_scheduled_index (UP)
+
+-- The original partial index filtered on status 0 (queued) instead of
+-- 7 (scheduled), so the scheduler's due-message query could not use it.
+DROP INDEX IF EXISTS idx_messages_scheduled_at;
+CREATE INDEX idx_messages_scheduled_at ON messages (scheduled_at) WHERE status = 7;
@@ -0,0 +1,4 @@
+-- 010_create_schedules (DOWN)
+
+ALTER TABLE messages DROP COLUMN IF EXISTS schedule_id;
+DROP TABLE IF EXISTS schedules;
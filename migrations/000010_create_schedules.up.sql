@@ -0,0 +1,33 @@
+-- 010_create_schedules (UP)
+
+CREATE TABLE schedules (
+    id           UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id      UUID         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    api_key_id   UUID         REFERENCES api_keys(id) ON DELETE SET NULL,
+    name         VARCHAR(100) NOT NULL,
+    cron_expr    VARCHAR(100),
+    rrule        TEXT,
+    timezone     VARCHAR(64)  NOT NULL DEFAULT 'UTC',
+    subject      VARCHAR(200) NOT NULL,
+    body         TEXT         NOT NULL,
+    sender       VARCHAR(100) NOT NULL,
+    recipients   TEXT[]       NOT NULL,
+    platform     VARCHAR(20)  NOT NULL CHECK (platform IN ('sms', 'whatsapp', 'telegram', 'email')),
+    priority     SMALLINT     NOT NULL DEFAULT 1 CHECK (priority IN (0, 1, 2)),
+    status       VARCHAR(20)  NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'paused', 'completed')),
+    starts_at    TIMESTAMPTZ  NOT NULL,
+    ends_at      TIMESTAMPTZ,
+    next_run_at  TIMESTAMPTZ,
+    last_run_at  TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CHECK ((cron_expr IS NULL) <> (rrule IS NULL))
+);
+
+CREATE INDEX idx_schedules_user_id ON schedules (user_id);
+CREATE INDEX idx_schedules_next_run_at ON schedules (next_run_at) WHERE status = 'active';
+
+-- Messages materialized from a schedule point back at it.
+ALTER TABLE messages ADD COLUMN schedule_id UUID REFERENCES schedules(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_messages_schedule_id ON messages (schedule_id) WHERE schedule_id IS NOT NULL;
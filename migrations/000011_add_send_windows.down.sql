@@ -0,0 +1,7 @@
+-- 011_add_send_windows (DOWN)
+
+DROP INDEX IF EXISTS idx_recipients_deferred;
+ALTER TABLE message_recipients DROP COLUMN IF EXISTS deferred_until;
+ALTER TABLE schedules DROP COLUMN IF EXISTS send_window;
+ALTER TABLE messages DROP COLUMN IF EXISTS send_window;
+ALTER TABLE users DROP COLUMN IF EXISTS send_window;
@@ -0,0 +1,15 @@
+-- 011_add_send_windows (UP)
+
+-- Daily local-time windows ({"start":"09:00","end":"20:00","timezone":...})
+-- outside of which recipients are deferred rather than contacted. The user's
+-- window is the default for their messages and schedules.
+ALTER TABLE users ADD COLUMN send_window JSONB;
+ALTER TABLE messages ADD COLUMN send_window JSONB;
+ALTER TABLE schedules ADD COLUMN send_window JSONB;
+
+-- Recipients deferred to the next window opening are in status 7 (scheduled)
+-- until deferred_until, when the scheduler publishes them.
+ALTER TABLE message_recipients ADD COLUMN deferred_until TIMESTAMPTZ;
+
+CREATE INDEX idx_recipients_deferred ON message_recipients (deferred_until)
+    WHERE status = 7;
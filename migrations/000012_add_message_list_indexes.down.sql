@@ -0,0 +1,4 @@
+-- 012_add_message_list_indexes (DOWN)
+
+DROP INDEX IF EXISTS idx_messages_subject_fts;
+DROP INDEX IF EXISTS idx_messages_user_created;
@@ -0,0 +1,8 @@
+-- 012_add_message_list_indexes (UP)
+
+-- Keyset pagination of a user's messages walks (created_at, id) newest first.
+CREATE INDEX idx_messages_user_created ON messages (user_id, created_at DESC, id DESC);
+
+-- Full-text search on subjects (q=). The expression must match the one used
+-- by MessageRepository.List.
+CREATE INDEX idx_messages_subject_fts ON messages USING GIN (to_tsvector('simple', subject));
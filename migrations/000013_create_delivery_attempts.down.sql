@@ -0,0 +1,3 @@
+-- 013_create_delivery_attempts (DOWN)
+
+DROP TABLE IF EXISTS delivery_attempts;
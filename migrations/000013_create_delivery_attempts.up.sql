@@ -0,0 +1,23 @@
+-- 013_create_delivery_attempts (UP)
+
+-- One row per call the worker makes to a provider adapter for a recipient,
+-- successful or not.
+CREATE TABLE delivery_attempts (
+    id            UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    recipient_id  UUID         NOT NULL REFERENCES message_recipients(id) ON DELETE CASCADE,
+    message_id    UUID         NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    attempt       SMALLINT     NOT NULL,
+    adapter       VARCHAR(50)  NOT NULL,
+    success       BOOLEAN      NOT NULL,
+    provider_id   VARCHAR(255),
+    -- HTTP status returned by the provider; NULL when the request never
+    -- reached it.
+    response_code SMALLINT,
+    error_code    VARCHAR(100),
+    error_message TEXT,
+    latency_ms    INTEGER      NOT NULL,
+    started_at    TIMESTAMPTZ  NOT NULL,
+    finished_at   TIMESTAMPTZ  NOT NULL
+);
+
+CREATE INDEX idx_delivery_attempts_recipient_id ON delivery_attempts (recipient_id, attempt);
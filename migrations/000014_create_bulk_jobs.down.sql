@@ -0,0 +1,4 @@
+-- 014_create_bulk_jobs (DOWN)
+
+DROP TABLE IF EXISTS bulk_job_items;
+DROP TABLE IF EXISTS bulk_jobs;
@@ -0,0 +1,33 @@
+-- 014_create_bulk_jobs (UP)
+
+-- Bulk sends accepted by POST /messages/bulk and worked through in the
+-- background. Progress is counted from the items.
+CREATE TABLE bulk_jobs (
+    id           UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id      UUID         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    api_key_id   UUID         REFERENCES api_keys(id) ON DELETE SET NULL,
+    request_id   VARCHAR(128),
+    status       VARCHAR(20)  NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed')),
+    total        INTEGER      NOT NULL,
+    started_at   TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_bulk_jobs_user_id ON bulk_jobs (user_id);
+
+-- One row per message of a job, holding the request until it is processed
+-- and its outcome afterwards.
+CREATE TABLE bulk_job_items (
+    job_id       UUID         NOT NULL REFERENCES bulk_jobs(id) ON DELETE CASCADE,
+    idx          INTEGER      NOT NULL,
+    request      JSONB        NOT NULL,
+    status       VARCHAR(20)  NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    message_id   UUID         REFERENCES messages(id) ON DELETE SET NULL,
+    error        TEXT,
+    processed_at TIMESTAMPTZ,
+    PRIMARY KEY (job_id, idx)
+);
+
+CREATE INDEX idx_bulk_job_items_pending ON bulk_job_items (job_id, idx) WHERE status = 'pending';
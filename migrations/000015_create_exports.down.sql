@@ -0,0 +1,3 @@
+-- 015_create_exports (DOWN)
+
+DROP TABLE IF EXISTS exports;
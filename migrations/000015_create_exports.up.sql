@@ -0,0 +1,25 @@
+-- 015_create_exports (UP)
+
+-- Asynchronous exports of a user's messages and their recipients. The file
+-- lives in the configured store under storage_key until expires_at.
+CREATE TABLE exports (
+    id           UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id      UUID         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    format       VARCHAR(10)  NOT NULL CHECK (format IN ('csv', 'ndjson', 'parquet')),
+    filters      JSONB        NOT NULL DEFAULT '{}',
+    status       VARCHAR(20)  NOT NULL DEFAULT 'pending'
+                 CHECK (status IN ('pending', 'running', 'completed', 'failed', 'expired')),
+    storage_key  TEXT,
+    row_count    BIGINT       NOT NULL DEFAULT 0,
+    size_bytes   BIGINT       NOT NULL DEFAULT 0,
+    error        TEXT,
+    started_at   TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    expires_at   TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_exports_user_id ON exports (user_id, created_at DESC);
+CREATE INDEX idx_exports_pending ON exports (created_at) WHERE status IN ('pending', 'running');
+CREATE INDEX idx_exports_expires_at ON exports (expires_at) WHERE status = 'completed';
@@ -0,0 +1,8 @@
+-- 016_create_delivery_rollups (DOWN)
+
+DROP INDEX IF EXISTS idx_recipients_created_at;
+DROP INDEX IF EXISTS idx_recipients_updated_at;
+DROP TABLE IF EXISTS analytics_rollup_state;
+DROP TABLE IF EXISTS delivery_errors_hourly;
+DROP TABLE IF EXISTS delivery_latency_hourly;
+DROP TABLE IF EXISTS delivery_stats_hourly;
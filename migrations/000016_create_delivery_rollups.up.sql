@@ -0,0 +1,54 @@
+-- 016_create_delivery_rollups (UP)
+
+-- Hourly rollups of message_recipients for the delivery analytics API.
+-- Recipients are bucketed by the hour they were created in (UTC). The
+-- analytics rollup job recomputes every hour that has a recipient updated
+-- since the watermark in analytics_rollup_state.
+
+-- Recipients per status.
+CREATE TABLE delivery_stats_hourly (
+    bucket     TIMESTAMPTZ  NOT NULL,
+    user_id    UUID         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    platform   VARCHAR(20)  NOT NULL,
+    status     SMALLINT     NOT NULL,
+    recipients BIGINT       NOT NULL,
+    PRIMARY KEY (bucket, user_id, platform, status)
+);
+
+-- Histograms of time from accepted (or scheduled) to sent and to delivered.
+-- slot is the index returned by width_bucket for the latency in
+-- milliseconds against model.DeliveryLatencyBounds.
+CREATE TABLE delivery_latency_hourly (
+    bucket     TIMESTAMPTZ  NOT NULL,
+    user_id    UUID         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    platform   VARCHAR(20)  NOT NULL,
+    stage      VARCHAR(10)  NOT NULL CHECK (stage IN ('sent', 'delivered')),
+    slot       SMALLINT     NOT NULL,
+    recipients BIGINT       NOT NULL,
+    PRIMARY KEY (bucket, user_id, platform, stage, slot)
+);
+
+-- Failed recipients per error message, truncated to 200 characters.
+CREATE TABLE delivery_errors_hourly (
+    bucket     TIMESTAMPTZ  NOT NULL,
+    user_id    UUID         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    platform   VARCHAR(20)  NOT NULL,
+    error      VARCHAR(200) NOT NULL,
+    recipients BIGINT       NOT NULL,
+    PRIMARY KEY (bucket, user_id, platform, error)
+);
+
+-- Rollups are up to date for recipients updated at or before watermark.
+-- NULL means nothing has been rolled up yet.
+CREATE TABLE analytics_rollup_state (
+    name       VARCHAR(50)  PRIMARY KEY,
+    watermark  TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO analytics_rollup_state (name) VALUES ('deliveries');
+
+-- The rollup job finds changed recipients by updated_at and recomputes each
+-- hour by created_at.
+CREATE INDEX idx_recipients_updated_at ON message_recipients (updated_at);
+CREATE INDEX idx_recipients_created_at ON message_recipients (created_at);
@@ -0,0 +1,5 @@
+-- 017_add_dispatch_attempts (DOWN)
+
+ALTER TABLE message_recipients DROP COLUMN IF EXISTS dispatch_attempts;
+ALTER TABLE messages DROP COLUMN IF EXISTS dispatch_retry_at;
+ALTER TABLE messages DROP COLUMN IF EXISTS dispatch_attempts;
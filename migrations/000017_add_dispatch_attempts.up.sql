@@ -0,0 +1,9 @@
+-- 017_add_dispatch_attempts (UP)
+
+-- Failed attempts to hand a scheduled message, or a deferred recipient, to
+-- the queue. A message that fails waits until dispatch_retry_at, and a
+-- recipient until its deferred_until is pushed back, so a few bad rows can't
+-- hold up everything due after them. Both are failed after too many attempts.
+ALTER TABLE messages ADD COLUMN dispatch_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE messages ADD COLUMN dispatch_retry_at TIMESTAMPTZ;
+ALTER TABLE message_recipients ADD COLUMN dispatch_attempts INT NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- 018_create_rollup_deletions (DOWN)
+
+DROP TABLE IF EXISTS delivery_rollup_deletions;
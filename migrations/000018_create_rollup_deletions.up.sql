@@ -0,0 +1,12 @@
+-- 018_create_rollup_deletions (UP)
+
+-- Hours that lost recipients to a delete, such as replacing the recipients
+-- of a scheduled message. A deleted recipient leaves no updated_at behind,
+-- so the analytics rollup job also recomputes every hour deleted from since
+-- the watermark, and prunes the rows once they are behind it.
+CREATE TABLE delivery_rollup_deletions (
+    bucket     TIMESTAMPTZ  NOT NULL,
+    deleted_at TIMESTAMPTZ  NOT NULL
+);
+
+CREATE INDEX idx_rollup_deletions_deleted_at ON delivery_rollup_deletions (deleted_at);
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"context"
 	"os"
 	"time"
 
@@ -33,3 +34,18 @@ func Init(level, format string) {
 func Get() *zerolog.Logger {
 	return &log
 }
+
+// Ctx returns the logger attached to ctx, falling back to the global logger.
+func Ctx(ctx context.Context) *zerolog.Logger {
+	if l := zerolog.Ctx(ctx); l.GetLevel() != zerolog.Disabled {
+		return l
+	}
+	return &log
+}
+
+// WithRequestID returns a copy of ctx whose logger tags every entry with the
+// given request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	l := Ctx(ctx).With().Str("request_id", id).Logger()
+	return l.WithContext(ctx)
+}
@@ -0,0 +1,51 @@
+package requestid
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Header is the HTTP header carrying the request ID.
+const Header = "X-Request-ID"
+
+// AMQPHeader is the AMQP message header carrying the request ID.
+const AMQPHeader = "x-request-id"
+
+// MetadataKey is the MessageQueuedEvent metadata key carrying the request ID.
+const MetadataKey = "request_id"
+
+// maxLen bounds caller-supplied IDs so they can't bloat logs and headers.
+const maxLen = 128
+
+type ctxKey struct{}
+
+// New generates a new request ID.
+func New() string {
+	return uuid.New().String()
+}
+
+// Valid reports whether a caller-supplied ID is acceptable: non-empty, at most
+// 128 characters, and printable ASCII without spaces.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// NewContext returns a copy of ctx carrying id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the request ID carried by ctx, or "" if there is none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
@@ -0,0 +1,135 @@
+package sms
+
+import (
+	"unicode/utf16"
+)
+
+// Encoding identifies the character set an SMS body is sent with.
+type Encoding string
+
+const (
+	EncodingGSM7 Encoding = "GSM-7"
+	EncodingUCS2 Encoding = "UCS-2"
+)
+
+// Segment capacities as defined by GSM 03.38 / 03.40. Concatenated messages
+// lose part of each segment to the user data header.
+const (
+	gsm7SingleSegment = 160
+	gsm7MultiSegment  = 153
+	ucs2SingleSegment = 70
+	ucs2MultiSegment  = 67
+)
+
+// gsm7Basic is the GSM 03.38 default alphabet (excluding the escape character).
+const gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+// gsm7Extended is the GSM 03.38 extension table. Each of these characters is
+// sent as an escape sequence and occupies two septets.
+const gsm7Extended = "\f^{}\\[~]|€"
+
+var (
+	basicSet    = runeSet(gsm7Basic)
+	extendedSet = runeSet(gsm7Extended)
+)
+
+func runeSet(s string) map[rune]struct{} {
+	set := make(map[rune]struct{}, len(s))
+	for _, r := range s {
+		set[r] = struct{}{}
+	}
+	return set
+}
+
+// Analysis describes how an SMS body will be encoded and billed.
+type Analysis struct {
+	Encoding   Encoding `json:"encoding"`
+	Characters int      `json:"characters"` // number of Unicode characters in the body
+	Units      int      `json:"units"`      // septets for GSM-7, UTF-16 code units for UCS-2
+	Segments   int      `json:"segments"`
+}
+
+// IsGSM7 reports whether every character of body can be sent with the GSM-7
+// alphabet, including the extension table.
+func IsGSM7(body string) bool {
+	for _, r := range body {
+		if septets(r) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Analyze determines the encoding of body and the number of segments it
+// will be split into. An empty body still counts as one segment.
+func Analyze(body string) Analysis {
+	if IsGSM7(body) {
+		return analyzeGSM7(body)
+	}
+	return analyzeUCS2(body)
+}
+
+// septets returns the number of septets r occupies in GSM-7, or 0 if r is
+// not representable.
+func septets(r rune) int {
+	if _, ok := basicSet[r]; ok {
+		return 1
+	}
+	if _, ok := extendedSet[r]; ok {
+		return 2
+	}
+	return 0
+}
+
+func analyzeGSM7(body string) Analysis {
+	a := Analysis{Encoding: EncodingGSM7}
+	for _, r := range body {
+		a.Characters++
+		a.Units += septets(r)
+	}
+
+	if a.Units <= gsm7SingleSegment {
+		a.Segments = 1
+		return a
+	}
+
+	// Escape sequences must not be split across segment boundaries.
+	a.Segments = 1
+	used := 0
+	for _, r := range body {
+		n := septets(r)
+		if used+n > gsm7MultiSegment {
+			a.Segments++
+			used = 0
+		}
+		used += n
+	}
+	return a
+}
+
+func analyzeUCS2(body string) Analysis {
+	a := Analysis{Encoding: EncodingUCS2}
+	for _, r := range body {
+		a.Characters++
+		a.Units += utf16.RuneLen(r)
+	}
+
+	if a.Units <= ucs2SingleSegment {
+		a.Segments = 1
+		return a
+	}
+
+	// Surrogate pairs must not be split across segment boundaries.
+	a.Segments = 1
+	used := 0
+	for _, r := range body {
+		n := utf16.RuneLen(r)
+		if used+n > ucs2MultiSegment {
+			a.Segments++
+			used = 0
+		}
+		used += n
+	}
+	return a
+}
@@ -0,0 +1,76 @@
+package sms
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	a := strings.Repeat
+	tests := []struct {
+		name     string
+		body     string
+		encoding Encoding
+		chars    int
+		units    int
+		segments int
+	}{
+		{"empty", "", EncodingGSM7, 0, 0, 1},
+		{"gsm-7 single segment limit", a("a", 160), EncodingGSM7, 160, 160, 1},
+		{"gsm-7 over single segment", a("a", 161), EncodingGSM7, 161, 161, 2},
+		{"gsm-7 two full segments", a("a", 306), EncodingGSM7, 306, 306, 2},
+		{"gsm-7 over two segments", a("a", 307), EncodingGSM7, 307, 307, 3},
+		{"gsm-7 basic accents", "Ça va? Où est Øresund, señor?", EncodingGSM7, 29, 29, 1},
+		{"extension characters take two septets", "{€}", EncodingGSM7, 3, 6, 1},
+		{"extension characters at single segment limit", a("€", 80), EncodingGSM7, 80, 160, 1},
+		{"extension characters over single segment", a("€", 80) + "a", EncodingGSM7, 81, 161, 2},
+		// The escape sequence can't straddle segments, so the € moves to
+		// the second segment and pushes one septet into a third.
+		{"escape sequence not split", a("a", 152) + "€" + a("a", 152), EncodingGSM7, 305, 306, 3},
+		{"ucs-2 single segment limit", a("ж", 70), EncodingUCS2, 70, 70, 1},
+		{"ucs-2 over single segment", a("ж", 71), EncodingUCS2, 71, 71, 2},
+		{"ucs-2 two full segments", a("ж", 134), EncodingUCS2, 134, 134, 2},
+		{"ucs-2 over two segments", a("ж", 135), EncodingUCS2, 135, 135, 3},
+		{"one non-gsm character makes the body ucs-2", a("a", 159) + "ж", EncodingUCS2, 160, 160, 3},
+		{"tab is not gsm-7", "a\tb", EncodingUCS2, 3, 3, 1},
+		{"surrogate pairs take two units", "😀", EncodingUCS2, 1, 2, 1},
+		{"surrogate pairs at single segment limit", a("😀", 35), EncodingUCS2, 35, 70, 1},
+		{"surrogate pairs over single segment", a("😀", 36), EncodingUCS2, 36, 72, 2},
+		// Likewise a surrogate pair can't be split across segments.
+		{"surrogate pair not split", a("ж", 66) + "😀" + a("ж", 66), EncodingUCS2, 133, 134, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Analyze(tt.body)
+			want := Analysis{Encoding: tt.encoding, Characters: tt.chars, Units: tt.units, Segments: tt.segments}
+			if got != want {
+				t.Errorf("Analyze: got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestIsGSM7(t *testing.T) {
+	tests := []struct {
+		body string
+		want bool
+	}{
+		{"", true},
+		{"Hello, world!", true},
+		{"Price: 5€ [incl. VAT] ~ {approx} | a\\b ^", true},
+		{"line\nbreak\rand\fform feed", true},
+		{"Ç", true},
+		{"ç", false},
+		{"naïve", false},
+		{"“quoted”", false},
+		{"你好", false},
+		{"👍", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsGSM7(tt.body); got != tt.want {
+			t.Errorf("IsGSM7(%q) = %t, want %t", tt.body, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package sms
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// replacements maps common non-GSM characters to their closest GSM-7 equivalent.
+// Characters that only differ by a diacritic are handled by decomposition instead.
+var replacements = map[rune]string{
+	'‘': "'", '’': "'", '‚': "'", '‛': "'", '′': "'", '`': "'",
+	'“': "\"", '”': "\"", '„': "\"", '‟': "\"", '″': "\"", '«': "\"", '»': "\"",
+	'‐': "-", '‑': "-", '‒': "-", '–': "-", '—': "-", '―': "-", '−': "-",
+	'…': "...", '•': "-", '·': ".", '×': "x", '÷': "/",
+	'\t': " ", '\u00a0': " ", '\u2002': " ", '\u2003': " ", '\u2009': " ",
+	'\u200b': "", '\u200c': "", '\u200d': "", '\ufeff': "",
+	'ł': "l", 'Ł': "L", 'đ': "d", 'Đ': "D",
+	'œ': "oe", 'Œ': "OE", 'þ': "th", 'Þ': "TH", 'ð': "d",
+	'™': "TM", '©': "(c)", '®': "(R)", '¢': "c", '°': "o",
+}
+
+// Transliterate replaces characters outside the GSM-7 alphabet with their
+// closest GSM-7 equivalent where one exists, so that the body can be sent
+// with GSM-7 instead of UCS-2. Characters without an equivalent (for
+// example emoji or CJK scripts) are left untouched.
+func Transliterate(body string) string {
+	if IsGSM7(body) {
+		return body
+	}
+
+	var b strings.Builder
+	b.Grow(len(body))
+	for _, r := range body {
+		if septets(r) > 0 {
+			b.WriteRune(r)
+			continue
+		}
+		if rep, ok := replacements[r]; ok {
+			b.WriteString(rep)
+			continue
+		}
+		if base, ok := stripDiacritics(r); ok {
+			b.WriteString(base)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// stripDiacritics decomposes r and drops combining marks, returning the
+// result only if it is representable in GSM-7 (e.g. "á" -> "a").
+func stripDiacritics(r rune) (string, bool) {
+	var b strings.Builder
+	for _, d := range norm.NFD.String(string(r)) {
+		if unicode.Is(unicode.Mn, d) {
+			continue
+		}
+		if septets(d) == 0 {
+			return "", false
+		}
+		b.WriteRune(d)
+	}
+	if b.Len() == 0 {
+		return "", false
+	}
+	return b.String(), true
+}
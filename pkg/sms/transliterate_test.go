@@ -0,0 +1,47 @@
+package sms
+
+import "testing"
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"gsm-7 unchanged", "Hello {name}, that's 5€.", "Hello {name}, that's 5€."},
+		{"smart quotes", "“Don’t” ‘panic’", "\"Don't\" 'panic'"},
+		{"dashes and ellipsis", "9–5 — wait…", "9-5 - wait..."},
+		{"diacritics stripped", "naïve façade Łódź", "naive facade Lodz"},
+		{"gsm-7 accents kept", "Müller à Paris", "Müller à Paris"},
+		{"ligatures and symbols", "Œuvre™ ©2025", "OEuvreTM (c)2025"},
+		{"whitespace", "a\tb c", "a b c"},
+		{"zero-width characters removed", "a\u200bb\ufeff", "ab"},
+		{"emoji left untouched", "Thanks 👍", "Thanks 👍"},
+		{"cjk left untouched", "你好 “world”", "你好 \"world\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Transliterate(tt.body); got != tt.want {
+				t.Errorf("Transliterate(%q) = %q, want %q", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransliterateEncoding(t *testing.T) {
+	// Transliteration brings a body that only needed UCS-2 for punctuation
+	// back to GSM-7, with its larger segments.
+	body := "We’re open 9–5 “every” day… see you soon!"
+	if got := Analyze(body).Encoding; got != EncodingUCS2 {
+		t.Fatalf("before: got %s, want %s", got, EncodingUCS2)
+	}
+	if got := Analyze(Transliterate(body)).Encoding; got != EncodingGSM7 {
+		t.Errorf("after: got %s, want %s", got, EncodingGSM7)
+	}
+
+	// A character without an equivalent keeps the body UCS-2.
+	if got := Analyze(Transliterate("On my way 🚗")).Encoding; got != EncodingUCS2 {
+		t.Errorf("with emoji: got %s, want %s", got, EncodingUCS2)
+	}
+}